@@ -0,0 +1,153 @@
+package intervaltree
+
+import (
+	"math"
+	"sort"
+)
+
+// -----------------------------------------------------
+// 				CLASSIC INTERVAL ALGORITHMS
+// -----------------------------------------------------
+
+// MinStabbingPoints returns a minimum set of coordinates such that every interval contains at least one of them,
+// in ascending order. It uses the greedy algorithm taking the last point of the first interval not yet stabbed
+// when intervals are sorted by their last point, End or End - 1 for an open end.
+// Complexity: O(n log n), n = number of stored intervals
+func (t *IntervalTree) MinStabbingPoints() []int {
+	t = t.orEmpty()
+	intervals := t.intervals()
+	sort.Slice(
+		intervals, func(i, j int) bool {
+			return t.last(intervals[i]) < t.last(intervals[j])
+		},
+	)
+	var res []int
+	for _, in := range intervals {
+		if len(res) == 0 || in.first() > res[len(res)-1] {
+			res = append(res, t.last(in))
+		}
+	}
+	return res
+}
+
+// MaxDisjointSubset returns a maximum set of stored intervals such that no two of them intersect, sorted by Start.
+// Closed intervals sharing an endpoint intersect, as for Intersecting. It uses the greedy algorithm keeping the interval
+// ending first among the ones starting after the last kept interval.
+// Complexity: O(n log n), n = number of stored intervals
+func (t *IntervalTree) MaxDisjointSubset() []*Interval {
+	t = t.orEmpty()
+	intervals := t.intervals()
+	sort.Slice(
+		intervals, func(i, j int) bool {
+			return t.last(intervals[i]) < t.last(intervals[j])
+		},
+	)
+	var res []*Interval
+	for _, in := range intervals {
+		if len(res) == 0 || in.first() > t.last(res[len(res)-1]) {
+			res = append(res, in) // disjoint intervals sorted by End are also sorted by Start
+		}
+	}
+	return res
+}
+
+// OverlappingPairs calls fn once for every unordered pair of stored intervals that intersect each other, stops as
+// soon as fn returns false. Closed intervals sharing an endpoint intersect, as for Intersecting. It sweeps the endpoints
+// of the BST while keeping the set of intervals covering the current point.
+// Output sensitive: Complexity of O(n log n + p), n = number of stored intervals and p = number of reported pairs
+func (t *IntervalTree) OverlappingPairs(fn func(a, b *Interval) bool) {
+	t = t.orEmpty()
+	active := newActiveSet()
+	for _, p := range t.pointsIn(math.MinInt, math.MaxInt) {
+		// start the ones beginning at p before ending the ones whose last point is p
+		started := active.startAt(
+			p, func(in *Interval) bool {
+				for _, other := range active.items {
+					if !fn(other, in) {
+						return false
+					}
+				}
+				return true
+			},
+		)
+		if !started {
+			return
+		}
+		active.endAt(p, t.open)
+	}
+}
+
+// activeSet is the set of intervals covering the current point of a sweep
+type activeSet struct {
+	items    []*Interval
+	position map[*Interval]int // index of each interval in items
+}
+
+// newActiveSet creates an empty activeSet
+func newActiveSet() *activeSet {
+	return &activeSet{position: make(map[*Interval]int)}
+}
+
+// has tells if the interval is in the set
+func (s *activeSet) has(in *Interval) bool {
+	_, ok := s.position[in]
+	return ok
+}
+
+// startAt adds to the set all the intervals starting at the point, calling fn on each of them right before it is
+// added. It returns false as soon as fn returns false
+// Complexity: O(k), k = number of intervals referenced by the point
+func (s *activeSet) startAt(p *Point, fn func(in *Interval) bool) bool {
+	for _, in := range p.ptrs {
+		if in.first() != p.x || s.has(in) {
+			continue // the End of an interval, or a single point interval already started
+		}
+		if !fn(in) {
+			return false
+		}
+		s.position[in] = len(s.items)
+		s.items = append(s.items, in)
+	}
+	return true
+}
+
+// endAt removes from the set all the intervals whose last point is the point, see lastPoint
+// Complexity: O(k), k = number of intervals referenced by the point
+func (s *activeSet) endAt(p *Point, open int) {
+	for _, in := range p.ptrs {
+		i, ok := s.position[in]
+		if !ok || lastPoint(in, open) != p.x {
+			continue // already ended, or the Start of an interval
+		}
+		last := s.items[len(s.items)-1]
+		s.items[i], s.position[last] = last, i
+		s.items = s.items[:len(s.items)-1]
+		delete(s.position, in)
+	}
+}
+
+// ConnectedComponents groups the stored intervals into maximal sets connected by intersections: when a intersects
+// b and b intersects c, all three are in the same component. Components are sorted by their leftmost Start and
+// the intervals of a component by Start.
+// Complexity: O(n log n), n = number of stored intervals, as it sweeps the intervals sorted by Start
+func (t *IntervalTree) ConnectedComponents() [][]*Interval {
+	t = t.orEmpty()
+	intervals := t.intervals()
+	sort.Slice(
+		intervals, func(i, j int) bool {
+			return intervals[i].lessStart(intervals[j])
+		},
+	)
+	var res [][]*Interval
+	maxEnd := 0 // biggest last point of the current component
+	for _, in := range intervals {
+		if len(res) == 0 || in.first() > maxEnd {
+			res = append(res, []*Interval{in})
+			maxEnd = t.last(in)
+			continue
+		}
+		res[len(res)-1] = append(res[len(res)-1], in)
+		maxEnd = maxInt(maxEnd, t.last(in))
+	}
+	return res
+}
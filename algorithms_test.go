@@ -0,0 +1,249 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// bruteMinStabbing returns the size of a minimum stabbing set by trying every subset of the candidate ends
+func bruteMinStabbing(intervals []*Interval) int {
+	ends := make(map[int]bool)
+	for _, in := range intervals {
+		ends[in.End] = true
+	}
+	var candidates []int
+	for e := range ends {
+		candidates = append(candidates, e)
+	}
+	best := len(candidates)
+	for mask := 0; mask < 1<<len(candidates); mask++ {
+		size, all := 0, true
+		for k := range candidates {
+			if mask&(1<<k) != 0 {
+				size++
+			}
+		}
+		if size >= best {
+			continue
+		}
+		for _, in := range intervals {
+			hit := false
+			for k, c := range candidates {
+				if mask&(1<<k) != 0 && in.Start <= c && c <= in.End {
+					hit = true
+					break
+				}
+			}
+			if !hit {
+				all = false
+				break
+			}
+		}
+		if all {
+			best = size
+		}
+	}
+	return best
+}
+
+func TestIntervalTree_MinStabbingPoints(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(12), 100, 20)
+		// single point intervals and identical intervals
+		if len(intervals) > 2 {
+			intervals[0].End = intervals[0].Start
+			intervals[1] = &Interval{Start: intervals[2].Start, End: intervals[2].End}
+		}
+		tree := MustNewIntervalTree(intervals)
+		points := tree.MinStabbingPoints()
+		stabbed := make(map[*Interval]bool)
+		for _, x := range points {
+			for _, in := range tree.Containing(x) {
+				stabbed[in] = true
+			}
+		}
+		if len(stabbed) != len(intervals) {
+			t.Fatalf("POINTS %v STAB %d INTERVALS OUT OF %d", points, len(stabbed), len(intervals))
+		}
+		if want := bruteMinStabbing(intervals); len(points) != want {
+			t.Fatalf("EXPECTING %d POINTS, GOT %v", want, points)
+		}
+	}
+	if points := MustNewIntervalTree(nil).MinStabbingPoints(); len(points) != 0 {
+		t.Fatalf("EMPTY: EXPECTING NO POINT, GOT %v", points)
+	}
+}
+
+// bruteMaxDisjoint returns the size of a maximum subset of pairwise non intersecting intervals
+func bruteMaxDisjoint(intervals []*Interval) int {
+	best := 0
+	for mask := 0; mask < 1<<len(intervals); mask++ {
+		var subset []*Interval
+		for k, in := range intervals {
+			if mask&(1<<k) != 0 {
+				subset = append(subset, in)
+			}
+		}
+		ok := true
+		for a := 0; a < len(subset) && ok; a++ {
+			for b := a + 1; b < len(subset); b++ {
+				if subset[a].Start <= subset[b].End && subset[b].Start <= subset[a].End {
+					ok = false
+					break
+				}
+			}
+		}
+		if ok && len(subset) > best {
+			best = len(subset)
+		}
+	}
+	return best
+}
+
+func TestIntervalTree_MaxDisjointSubset(t *testing.T) {
+	// greedy by Start would keep [0, 10] only
+	tree := MustNewIntervalTree([]*Interval{{Start: 0, End: 10}, {Start: 1, End: 2}, {Start: 3, End: 4}, {Start: 4, End: 8}, {Start: 9, End: 12}})
+	got := tree.MaxDisjointSubset()
+	if len(got) != 3 || got[0].Start != 1 || got[1].Start != 3 || got[2].Start != 9 {
+		t.Fatalf("EXPECTING [1 - 2] [3 - 4] [9 - 12], GOT %v", got)
+	}
+	// touching endpoints intersect
+	if got := MustNewIntervalTree([]*Interval{{Start: 0, End: 5}, {Start: 5, End: 9}}).MaxDisjointSubset(); len(got) != 1 {
+		t.Fatalf("TOUCHING: EXPECTING 1 INTERVAL, GOT %v", got)
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(12), 100, 25)
+		got := MustNewIntervalTree(intervals).MaxDisjointSubset()
+		for k := 1; k < len(got); k++ {
+			if got[k].Start <= got[k-1].End {
+				t.Fatalf("%s AND %s INTERSECT OR ARE NOT SORTED", got[k-1], got[k])
+			}
+		}
+		if want := bruteMaxDisjoint(intervals); len(got) != want {
+			t.Fatalf("EXPECTING %d INTERVALS, GOT %v", want, got)
+		}
+	}
+}
+
+func TestIntervalTree_OverlappingPairs(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(60), 200, 30)
+		// duplicates and shared endpoints
+		for k := 0; k+1 < len(intervals); k += 7 {
+			intervals[k+1] = &Interval{Start: intervals[k].Start, End: intervals[k].End}
+		}
+		for k := 2; k+1 < len(intervals); k += 5 {
+			intervals[k+1].Start = intervals[k].End
+			intervals[k+1].End = intervals[k+1].Start + rnd.Intn(3)
+		}
+		type pair struct{ a, b *Interval }
+		want := make(map[pair]bool)
+		for a := 0; a < len(intervals); a++ {
+			for b := a + 1; b < len(intervals); b++ {
+				if intervals[a].Start <= intervals[b].End && intervals[b].Start <= intervals[a].End {
+					want[pair{intervals[a], intervals[b]}] = true
+				}
+			}
+		}
+		got := make(map[pair]bool)
+		MustNewIntervalTree(intervals).OverlappingPairs(
+			func(a, b *Interval) bool {
+				if a == b || got[pair{a, b}] || got[pair{b, a}] {
+					t.Fatalf("PAIR (%s, %s) REPORTED TWICE", a, b)
+				}
+				if want[pair{b, a}] {
+					a, b = b, a
+				}
+				if !want[pair{a, b}] {
+					t.Fatalf("(%s, %s) DO NOT OVERLAP", a, b)
+				}
+				got[pair{a, b}] = true
+				return true
+			},
+		)
+		if len(got) != len(want) {
+			t.Fatalf("EXPECTING %d PAIRS, GOT %d", len(want), len(got))
+		}
+	}
+
+	calls := 0
+	tree := MustNewIntervalTree([]*Interval{{Start: 0, End: 10}, {Start: 1, End: 10}, {Start: 2, End: 10}})
+	tree.OverlappingPairs(func(a, b *Interval) bool { calls++; return false })
+	if calls != 1 {
+		t.Fatalf("EXPECTING THE SWEEP TO STOP AFTER 1 PAIR, GOT %d", calls)
+	}
+}
+
+func TestIntervalTree_ConnectedComponents(t *testing.T) {
+	// [0, 4] and [8, 12] only connect through [4, 8], [20, 20] and [22, 25] are singletons
+	intervals := []*Interval{{Start: 8, End: 12}, {Start: 22, End: 25}, {Start: 0, End: 4}, {Start: 20, End: 20}, {Start: 4, End: 8}, {Start: 10, End: 11}}
+	got := MustNewIntervalTree(intervals).ConnectedComponents()
+	want := [][]Interval{
+		{{Start: 0, End: 4}, {Start: 4, End: 8}, {Start: 8, End: 12}, {Start: 10, End: 11}},
+		{{Start: 20, End: 20}},
+		{{Start: 22, End: 25}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("EXPECTING %v, GOT %v", want, got)
+	}
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("EXPECTING %v, GOT %v", want, got)
+		}
+		for k := range want[i] {
+			if got[i][k].Start != want[i][k].Start || got[i][k].End != want[i][k].End {
+				t.Fatalf("EXPECTING %v, GOT %v", want, got)
+			}
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(50), 500, 20)
+		components := MustNewIntervalTree(intervals).ConnectedComponents()
+		component := make(map[*Interval]int)
+		for c, members := range components {
+			if c > 0 && members[0].Start < components[c-1][0].Start {
+				t.Fatalf("COMPONENTS ARE NOT SORTED BY LEFTMOST START")
+			}
+			for _, in := range members {
+				if _, ok := component[in]; ok {
+					t.Fatalf("%s IS IN TWO COMPONENTS", in)
+				}
+				component[in] = c
+			}
+		}
+		if len(component) != len(intervals) {
+			t.Fatalf("EXPECTING %d INTERVALS IN COMPONENTS, GOT %d", len(intervals), len(component))
+		}
+		// union find over all intersecting pairs gives the same partition
+		parent := make(map[*Interval]*Interval)
+		var find func(in *Interval) *Interval
+		find = func(in *Interval) *Interval {
+			if p, ok := parent[in]; ok && p != in {
+				parent[in] = find(p)
+				return parent[in]
+			}
+			return in
+		}
+		for _, a := range intervals {
+			for _, b := range intervals {
+				if a.Start <= b.End && b.Start <= a.End {
+					parent[find(a)] = find(b)
+				}
+			}
+		}
+		for _, a := range intervals {
+			for _, b := range intervals {
+				if (find(a) == find(b)) != (component[a] == component[b]) {
+					t.Fatalf("%s AND %s ARE NOT GROUPED AS THEY SHOULD", a, b)
+				}
+			}
+		}
+	}
+}
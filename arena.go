@@ -0,0 +1,41 @@
+package intervaltree
+
+// -----------------------------------------------------
+// 				NODE ARENA
+// -----------------------------------------------------
+
+// eltChunk is the number of elements an arena allocates at once
+const eltChunk = 1024
+
+// arena carves the elements built by fromEndpoints and their slices out of a few big allocations instead of three
+// per node, so that a large tree is made of few heap objects for the garbage collector to scan. Every interval is
+// held by a single node, so the storage of the slices is known from the number of intervals. Each slice is capped
+// at its length: growing it in Insert moves it out of the arena, and the removed intervals of a node only free
+// their room when the whole tree is dropped
+type arena struct {
+	elts      []elt       // the elements of the current chunk not given yet
+	intervals []*Interval // the storage of leftSorted not given yet
+	positions []int32     // the storage of byEnd not given yet
+}
+
+// newArena returns an arena holding the nodes of n intervals
+func newArena(n int) *arena {
+	return &arena{intervals: make([]*Interval, n), positions: make([]int32, n)}
+}
+
+// newElt creates a new element as newElt does, carved out of the arena, or allocated on the heap if the arena is nil
+func (a *arena) newElt(intervals []*Interval, xMid int, tie func(a, b *Interval) bool) *elt {
+	if a == nil {
+		return newElt(intervals, xMid, tie)
+	}
+	if len(a.elts) == 0 {
+		// every node holds an interval, so no more nodes than intervals are left to carve
+		a.elts = make([]elt, minInt(eltChunk, len(a.intervals)))
+	}
+	e := &a.elts[0]
+	a.elts = a.elts[1:]
+	n := len(intervals)
+	e.fill(intervals, a.intervals[:n:n], a.positions[:n:n], xMid, tie)
+	a.intervals, a.positions = a.intervals[n:], a.positions[n:]
+	return e
+}
@@ -0,0 +1,98 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestArena(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomIntervals(rnd, 1+rnd.Intn(2000), 1000, 1+rnd.Intn(100))
+		tree := MustNewIntervalTree(intervals, WithSmallThreshold(0))
+		naive := NewNaiveIntervalSet(intervals)
+		// the slices of the nodes are neighbours in the arena, growing or shrinking one must not touch the others
+		for m := 0; m < 500; m++ {
+			if rnd.Intn(2) == 0 {
+				in := &Interval{Start: rnd.Intn(1000), End: 0}
+				in.End = in.Start + rnd.Intn(100)
+				_ = tree.Insert(in)
+				naive.Insert(in)
+			} else if all := naive.All(); len(all) > 0 {
+				in := all[rnd.Intn(len(all))]
+				tree.Delete(in)
+				naive.Delete(in)
+			}
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		queries := make([]Query, 100)
+		for q := range queries {
+			x := rnd.Intn(1200) - 100
+			queries[q] = Query{X: x}
+			if q%2 == 1 {
+				queries[q].Window = &Interval{Start: x, End: x + rnd.Intn(100)}
+			}
+		}
+		if err := CheckAgainstNaive(tree, naive, queries); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+	}
+}
+
+// benchmarkArenaIntervals are the 2M intervals of the benchmarks of the arena
+func benchmarkArenaIntervals() []*Interval {
+	return randomIntervals(rand.New(rand.NewSource(1)), 2_000_000, 200_000_000, 10_000)
+}
+
+// buildNodes builds the nodes of the intervals with the arena given to fromEndpoints
+func buildNodes(intervals []*Interval, withArena bool) *nodeTree {
+	s := &scratch{}
+	ends, buf := s.endpoints(intervals, 0)
+	var mem *arena
+	if withArena {
+		mem = newArena(len(intervals))
+	}
+	return fromEndpoints(ends, buf, &s.mid, mem, nil, 0)
+}
+
+// benchmarkArenaConstruction reports the allocations building the nodes of 2M intervals
+func benchmarkArenaConstruction(b *testing.B, withArena bool) {
+	intervals := benchmarkArenaIntervals()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = buildNodes(intervals, withArena)
+	}
+}
+
+func BenchmarkArena_Construction(b *testing.B) {
+	benchmarkArenaConstruction(b, true)
+}
+
+func BenchmarkArena_Construction_Heap(b *testing.B) {
+	benchmarkArenaConstruction(b, false)
+}
+
+// benchmarkArenaGC times a garbage collection with the nodes of 2M intervals alive
+func benchmarkArenaGC(b *testing.B, withArena bool) {
+	nodes := buildNodes(benchmarkArenaIntervals(), withArena)
+	runtime.GC()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+	}
+	b.StopTimer()
+	runtime.KeepAlive(nodes)
+}
+
+func BenchmarkArena_GC(b *testing.B) {
+	benchmarkArenaGC(b, true)
+}
+
+func BenchmarkArena_GC_Heap(b *testing.B) {
+	benchmarkArenaGC(b, false)
+}
@@ -0,0 +1,98 @@
+package intervaltree
+
+import (
+	"slices"
+	"sort"
+)
+
+// -----------------------------------------------------
+// 				OFFLINE QUERIES
+// -----------------------------------------------------
+
+// AnswerAll returns for every point given in parameter the intervals containing it, as Containing and in the same
+// order, res[i] answering points[i]. The points are sorted and the tree is walked once for all of them: each node
+// hands its points down to its children, those before xMid to the left and those after it to the right, and
+// reports its intervals to each with a single scan of its lists, the prefix holding a point growing with it. The
+// upper nodes are thus visited once instead of once per point, which pays off on dense batches. A point given
+// several times gets a copy of the same result
+// Complexity: O(m ln m + p + k), m = len(points), p = nodes on the paths of the points and k = returned intervals
+func (t *IntervalTree) AnswerAll(points []int) [][]*Interval {
+	t = t.orEmpty()
+	t.heal()
+	t.checkIntegrity()
+	res := make([][]*Interval, len(points))
+	if len(points) == 0 {
+		return res
+	}
+	if t.small != nil {
+		for i, x := range points {
+			res[i] = t.collectSmall(x, x)
+		}
+		return res
+	}
+	xs := slices.Clone(points)
+	slices.Sort(xs)
+	xs = slices.Compact(xs)
+	found := make([][]*Interval, len(xs))
+	answerAll(t.nodes().root(), xs, t.open, found)
+	// the first occurrence of a point takes its result, the others a copy
+	taken := make([]bool, len(xs))
+	for i, x := range points {
+		at := sort.SearchInts(xs, x)
+		if res[i] = found[at]; taken[at] {
+			res[i] = slices.Clone(found[at])
+		}
+		taken[at] = true
+	}
+	return res
+}
+
+// answerAll appends to found[i] the intervals containing xs[i], for the sorted distinct points xs, walking the
+// subtree once with an explicit stack of the nodes and of the range of points reaching them
+func answerAll(root *iterator, xs []int, open int, found [][]*Interval) {
+	type pending struct {
+		itr    iterator // moved by value, see stab
+		lo, hi int      // the points reaching the node are xs[lo:hi]
+	}
+	stack := []pending{{*root, 0, len(xs)}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if p.itr.isBottom() {
+			continue
+		}
+		e := p.itr.consult()
+		e.check()
+		below := p.lo + sort.SearchInts(xs[p.lo:p.hi], e.xMid) // xs[lo:below] are before xMid
+		above := below                                         // xs[above:hi] are after it
+		if above < p.hi && xs[above] == e.xMid {
+			found[above] = append(found[above], e.leftSorted...)
+			above++
+		}
+		// the intervals containing a point before xMid are those starting at or before it, a prefix of leftSorted
+		cut := 0
+		for i := p.lo; i < below; i++ {
+			for cut < len(e.leftSorted) && e.leftSorted[cut].first() <= xs[i] {
+				cut++
+			}
+			found[i] = append(found[i], e.leftSorted[:cut]...)
+		}
+		// those containing a point after it end at or after it, a prefix of the order by end, longer for the
+		// points closer to xMid
+		cut = 0
+		for i := p.hi - 1; i >= above; i-- {
+			for cut < len(e.byEnd) && lastPoint(e.endAt(cut), open) >= xs[i] {
+				cut++
+			}
+			for k := 0; k < cut; k++ {
+				found[i] = append(found[i], e.endAt(k))
+			}
+		}
+		if p.lo < below {
+			stack = append(stack, pending{*p.itr.left(), p.lo, below})
+		}
+		if above < p.hi {
+			stack = append(stack, pending{*p.itr.right(), above, p.hi})
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_AnswerAll(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals, mode := randomFlagged(rnd, rnd.Intn(2000), 1000, 1+rnd.Intn(200)), []Option(nil)
+		if i%2 == 1 {
+			intervals, mode = randomHalfOpen(rnd, rnd.Intn(2000), 1000, 1+rnd.Intn(200)), []Option{WithHalfOpenIntervals()}
+		}
+		for _, tree := range append(treesOf(t, intervals, mode...), MustNewIntervalTree(intervals[:len(intervals)/100], mode...)) {
+			// few distinct values, so that most points are given several times
+			points := make([]int, rnd.Intn(500))
+			for j := range points {
+				points[j] = rnd.Intn(300)*4 - 100
+			}
+			res := tree.AnswerAll(points)
+			if len(res) != len(points) {
+				t.Fatalf("EXPECTING %d RESULTS, GOT %d", len(points), len(res))
+			}
+			for j, x := range points {
+				if want := tree.Containing(x); !slices.Equal(res[j], want) {
+					t.Fatalf("%d: EXPECTING %d INTERVALS AS CONTAINING, GOT %d", x, len(want), len(res[j]))
+				}
+			}
+			// the results of a duplicate point do not share their backing array
+			for j := 1; j < len(points); j++ {
+				if points[j] == points[0] && len(res[0]) > 0 && &res[j][0] == &res[0][0] {
+					t.Fatalf("%d: THE RESULTS OF A DUPLICATE POINT MUST BE COPIES", points[0])
+				}
+			}
+		}
+	}
+	var nilTree *IntervalTree
+	if res := nilTree.AnswerAll([]int{1, 2}); len(res) != 2 || res[0] != nil || res[1] != nil {
+		t.Fatalf("A NIL TREE MUST ANSWER NOTHING FOR EVERY POINT")
+	}
+	if res := MustNewIntervalTree(nil).AnswerAll(nil); len(res) != 0 {
+		t.Fatalf("NO POINT MUST GIVE NO RESULT")
+	}
+}
+
+// benchmarkAnswerAll answers a dense batch of points sharing the upper nodes of the tree
+func benchmarkAnswerAll(b *testing.B, answer func(tree *IntervalTree, points []int) [][]*Interval) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 200_000, 1_000_000, 100))
+	points := make([]int, 100_000)
+	for i := range points {
+		points[i] = rnd.Intn(1_000_000)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		answer(tree, points)
+	}
+}
+
+func BenchmarkAnswerAll(b *testing.B) {
+	benchmarkAnswerAll(b, (*IntervalTree).AnswerAll)
+}
+
+// BenchmarkAnswerAll_Loop answers the points one by one with Containing
+func BenchmarkAnswerAll_Loop(b *testing.B) {
+	benchmarkAnswerAll(
+		b, func(tree *IntervalTree, points []int) [][]*Interval {
+			res := make([][]*Interval, len(points))
+			for i, x := range points {
+				res[i] = tree.Containing(x)
+			}
+			return res
+		},
+	)
+}
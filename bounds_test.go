@@ -0,0 +1,279 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+// randomFlagged generates n intervals as randomIntervals does, with random open sides leaving at least one point
+func randomFlagged(rnd *rand.Rand, n, maxCoord, maxLength int) []*Interval {
+	intervals := randomIntervals(rnd, n, maxCoord, maxLength)
+	for _, in := range intervals {
+		in.StartOpen, in.EndOpen = rnd.Intn(2) == 0, rnd.Intn(2) == 0
+		if vacant(in, 0) {
+			in.StartOpen = false
+		}
+		if vacant(in, 0) {
+			in.EndOpen = false
+		}
+	}
+	return intervals
+}
+
+func TestInterval_String(t *testing.T) {
+	for _, c := range []struct {
+		in   Interval
+		want string
+	}{
+		{Interval{Start: 3, End: 7}, "[ 3 - 7 ]"},
+		{Interval{Start: 3, End: 7, StartOpen: true}, "( 3 - 7 ]"},
+		{Interval{Start: 3, End: 7, EndOpen: true}, "[ 3 - 7 )"},
+		{Interval{Start: -3, End: 7, StartOpen: true, EndOpen: true}, "( -3 - 7 )"},
+	} {
+		if got := c.in.String(); got != c.want {
+			t.Fatalf("EXPECTING %q, GOT %q", c.want, got)
+		}
+	}
+}
+
+func TestBoundaryFlags_Touching(t *testing.T) {
+	flags := []bool{false, true}
+	for _, aStart := range flags {
+		for _, aEnd := range flags {
+			for _, bStart := range flags {
+				for _, bEnd := range flags {
+					a := &Interval{Start: 0, End: 10, StartOpen: aStart, EndOpen: aEnd}
+					b := &Interval{Start: 10, End: 20, StartOpen: bStart, EndOpen: bEnd}
+					touching := !aEnd && !bStart
+					for _, tree := range treesOf(t, []*Interval{a, b}) {
+						checkStructure(t, tree)
+						want := []*Interval{a}
+						if touching {
+							want = append(want, b)
+						}
+						if got := tree.Intersecting(a); !sameIntervals(got, want) {
+							t.Fatalf("INTERSECTING(%s) WITH %s: EXPECTING %v, GOT %v", a, b, want, got)
+						}
+						if got := slices.Collect(tree.IntersectingSeq(a)); !sameIntervals(got, want) {
+							t.Fatalf("INTERSECTINGSEQ(%s) WITH %s: EXPECTING %v, GOT %v", a, b, want, got)
+						}
+						if got := tree.IntersectingWith(a); !sameIntervals(got, want) {
+							t.Fatalf("INTERSECTINGWITH(%s) WITH %s: EXPECTING %v, GOT %v", a, b, want, got)
+						}
+						pairs := 0
+						tree.OverlappingPairs(func(x, y *Interval) bool { pairs++; return true })
+						if touching != (pairs == 1) {
+							t.Fatalf("%s AND %s: EXPECTING TOUCHING %v, GOT %d PAIRS", a, b, touching, pairs)
+						}
+						want = nil
+						if !aEnd {
+							want = append(want, a)
+						}
+						if !bStart {
+							want = append(want, b)
+						}
+						if got := tree.Containing(10); !sameIntervals(got, want) {
+							t.Fatalf("CONTAINING(10) WITH %s AND %s: EXPECTING %v, GOT %v", a, b, want, got)
+						}
+						if got := tree.CountContaining(10); got != len(want) {
+							t.Fatalf("COUNTCONTAINING(10) WITH %s AND %s: EXPECTING %d, GOT %d", a, b, len(want), got)
+						}
+						if got := len(tree.Containing(0)); got != len(tree.Containing(1))-boolToInt(aStart) {
+							t.Fatalf("CONTAINING(0) MUST FOLLOW THE START OF %s, GOT %d", a, got)
+						}
+						if got := len(tree.Containing(20)); got != len(tree.Containing(19))-boolToInt(bEnd) {
+							t.Fatalf("CONTAINING(20) MUST FOLLOW THE END OF %s, GOT %d", b, got)
+						}
+						if got, want := len(tree.ConnectedComponents()), 2-boolToInt(touching); got != want {
+							t.Fatalf("%s AND %s: EXPECTING %d COMPONENTS, GOT %d", a, b, want, got)
+						}
+						if got := tree.Boundaries(); !slices.Equal(got, []int{0, 10, 20}) {
+							t.Fatalf("EXPECTING THE BOUNDARIES 0, 10 AND 20, GOT %v", got)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// boolToInt returns 1 for true and 0 for false
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestBoundaryFlags_Queries(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomFlagged(rnd, rnd.Intn(300), 1000, 50)
+		shadow := MustNewIntervalTree(closedCopies(intervals, 0))
+		for _, tree := range treesOf(t, intervals) {
+			checkStructure(t, tree)
+			checkQueries(t, rnd, tree, intervals, 1000)
+			for q := 0; q < 20; q++ {
+				x := rnd.Intn(1100)
+				want := originals(shadow.Containing(x))
+				if got := tree.Containing(x); !sameIntervals(got, want) {
+					t.Fatalf("CONTAINING(%d): EXPECTING %v, GOT %v", x, want, got)
+				}
+				if got := slices.Collect(tree.ContainingSeq(x)); !sameIntervals(got, want) {
+					t.Fatalf("CONTAININGSEQ(%d): EXPECTING %v, GOT %v", x, want, got)
+				}
+				query := randomFlagged(rnd, 1, 1000, 100)[0]
+				query.StartOpen, query.EndOpen = rnd.Intn(2) == 0, rnd.Intn(2) == 0 // may be empty
+				want = nil
+				if !vacant(query, 0) {
+					want = originals(shadow.Intersecting(closedCopies([]*Interval{query}, 0)[0]))
+				}
+				if got := tree.Intersecting(query); !sameIntervals(got, want) {
+					t.Fatalf("INTERSECTING(%s): EXPECTING %v, GOT %v", query, want, got)
+				}
+				if got := slices.Collect(tree.IntersectingSeq(query)); !sameIntervals(got, want) {
+					t.Fatalf("INTERSECTINGSEQ(%s): EXPECTING %v, GOT %v", query, want, got)
+				}
+				if got := tree.IntersectingWith(query); !sameIntervals(got, want) {
+					t.Fatalf("INTERSECTINGWITH(%s): EXPECTING %v, GOT %v", query, want, got)
+				}
+				var starting, ending []*Interval
+				for _, in := range intervals {
+					if in.Start >= x {
+						starting = append(starting, in)
+					}
+					if in.End <= x {
+						ending = append(ending, in)
+					}
+				}
+				if got := tree.StartingAt(x); !sameIntervals(got, starting) {
+					t.Fatalf("STARTINGAT(%d): EXPECTING %v, GOT %v", x, starting, got)
+				}
+				if got := tree.EndingAt(x); !sameIntervals(got, ending) {
+					t.Fatalf("ENDINGAT(%d): EXPECTING %v, GOT %v", x, ending, got)
+				}
+			}
+			if !slices.Equal(tree.Compact(), shadow.Compact()) {
+				t.Fatalf("COMPACT: EXPECTING %v, GOT %v", shadow.Compact(), tree.Compact())
+			}
+			if got, want := len(tree.MinStabbingPoints()), len(shadow.MinStabbingPoints()); got != want {
+				t.Fatalf("EXPECTING %d STABBING POINTS, GOT %d", want, got)
+			}
+			if got, want := len(tree.MaxDisjointSubset()), len(shadow.MaxDisjointSubset()); got != want {
+				t.Fatalf("EXPECTING %d DISJOINT INTERVALS, GOT %d", want, got)
+			}
+			var boundaries []int
+			for _, in := range intervals {
+				boundaries = append(boundaries, in.Start, in.End)
+			}
+			slices.Sort(boundaries)
+			if got := tree.Boundaries(); !slices.Equal(got, slices.Compact(boundaries)) {
+				t.Fatalf("BOUNDARIES: EXPECTING %v, GOT %v", slices.Compact(boundaries), got)
+			}
+		}
+	}
+}
+
+func TestBoundaryFlags_Mutations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomFlagged(rnd, rnd.Intn(200), 1000, 100)
+		tree := MustNewIntervalTree(intervals, WithSmallThreshold(0))
+		for k := 0; k < 200; k++ {
+			if rnd.Intn(2) == 0 || len(intervals) == 0 {
+				in := randomFlagged(rnd, 1, 1000, 100)[0]
+				if err := tree.Insert(in); err != nil {
+					t.Fatalf("UNEXPECTED ERROR %v", err)
+				}
+				intervals = append(intervals, in)
+				continue
+			}
+			j := rnd.Intn(len(intervals))
+			if found := tree.FindLike(intervals[j]); !slices.Contains(found, intervals[j]) {
+				t.Fatalf("FINDLIKE(%s) MUST FIND IT, GOT %v", intervals[j], found)
+			}
+			if !tree.Delete(intervals[j]) {
+				t.Fatalf("CANNOT DELETE %s", intervals[j])
+			}
+			intervals = slices.Delete(intervals, j, j+1)
+		}
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, intervals, 1000)
+	}
+
+	open := &Interval{Start: 3, End: 7, StartOpen: true}
+	tree := MustNewIntervalTree([]*Interval{open, {Start: 3, End: 7}}, WithMultiplicity())
+	if tree.Len() != 2 || len(tree.Find(3, 7)) != 1 || tree.FindLike(&Interval{Start: 3, End: 7, StartOpen: true})[0] != open {
+		t.Fatalf("( 3 - 7 ] AND [ 3 - 7 ] ARE DISTINCT INTERVALS")
+	}
+	if err := tree.Replace(open, 3, 4); err != nil || !open.StartOpen || len(tree.Containing(3)) != 1 {
+		t.Fatalf("REPLACE MUST KEEP THE OPEN START, GOT %s AND %v", open, err)
+	}
+	if err := tree.Replace(open, 3, 3); !errors.Is(err, ErrEmptyInterval) {
+		t.Fatalf("EXPECTING ErrEmptyInterval FOR ( 3 - 3 ], GOT %v", err)
+	}
+}
+
+func TestBoundaryFlags_Coverage(t *testing.T) {
+	tree := MustNewIntervalTree(
+		[]*Interval{{Start: 0, End: 4, EndOpen: true}, {Start: 4, End: 8, StartOpen: true}, {Start: 8, End: 10}},
+	)
+	if got := tree.Gaps(&Interval{Start: 0, End: 10}); len(got) != 1 || *got[0] != (Interval{Start: 4, End: 4}) {
+		t.Fatalf("EXPECTING THE GAP [ 4 - 4 ], GOT %v", got)
+	}
+	if got := tree.CoveredLength(&Interval{Start: 0, End: 10, StartOpen: true, EndOpen: true}); got != 8 {
+		t.Fatalf("EXPECTING 8 COVERED COORDINATES IN ( 0 - 10 ), GOT %d", got)
+	}
+	if !tree.IsCovered(&Interval{Start: 4, End: 10, StartOpen: true}) || tree.IsCovered(&Interval{Start: 3, End: 5}) {
+		t.Fatalf("( 4 - 10 ] IS COVERED, [ 3 - 5 ] IS NOT")
+	}
+	coalesced := tree.Coalesce(nil)
+	if len(coalesced) != 2 || coalesced[0].String() != "[ 0 - 4 )" || coalesced[1].String() != "( 4 - 10 ]" {
+		t.Fatalf("EXPECTING [ 0 - 4 ) AND ( 4 - 10 ], GOT %v", coalesced)
+	}
+	profile := tree.CoverageProfile(&Interval{Start: 0, End: 10, EndOpen: true})
+	want := []CoverageSegment{{0, 3, 1}, {4, 4, 0}, {5, 7, 1}, {8, 8, 2}, {9, 9, 1}}
+	if !slices.Equal(profile, want) {
+		t.Fatalf("EXPECTING THE PROFILE %v, GOT %v", want, profile)
+	}
+
+	clipped := tree.Clip(&Interval{Start: 2, End: 9, StartOpen: true, EndOpen: true}).AllSorted(ByStart)
+	if len(clipped) != 3 || clipped[0].String() != "( 2 - 4 )" || clipped[2].String() != "[ 8 - 9 )" {
+		t.Fatalf("EXPECTING ( 2 - 4 ), ( 4 - 8 ] AND [ 8 - 9 ), GOT %v", clipped)
+	}
+	left, right := tree.Split(6, SplitClip)
+	if l, r := left.AllSorted(ByStart), right.AllSorted(ByStart); len(l) != 2 || l[1].String() != "( 4 - 6 ]" ||
+		len(r) != 2 || r[0].String() != "[ 6 - 8 ]" {
+		t.Fatalf("EXPECTING ( 4 - 6 ] ON THE LEFT AND [ 6 - 8 ] ON THE RIGHT, GOT %v AND %v", l, r)
+	}
+	if err := tree.Scale(-1, 1, RoundFloor, SwapReversed()); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	checkStructure(t, tree)
+	if got := tree.AllSorted(ByStart); got[1].String() != "[ -8 - -4 )" || got[2].String() != "( -4 - 0 ]" {
+		t.Fatalf("SWAPPING MUST EXCHANGE THE OPEN SIDES, GOT %v", got)
+	}
+}
+
+func TestBoundaryFlags_Errors(t *testing.T) {
+	for _, in := range []*Interval{
+		{Start: 5, End: 5, StartOpen: true}, {Start: 5, End: 5, EndOpen: true}, {Start: 5, End: 6, StartOpen: true, EndOpen: true},
+	} {
+		if _, err := NewIntervalTree([]*Interval{in}); !errors.Is(err, ErrEmptyInterval) {
+			t.Fatalf("%s: EXPECTING ErrEmptyInterval, GOT %v", in, err)
+		}
+	}
+	if _, err := NewIntervalTree([]*Interval{{Start: 5, End: 6, StartOpen: true}}, WithHalfOpenIntervals()); !errors.Is(err, ErrEmptyInterval) {
+		t.Fatalf("( 5 - 6 ) IS EMPTY IN A HALF-OPEN TREE, GOT %v", err)
+	}
+	tree := MustNewIntervalTree([]*Interval{{Start: 5, End: 7, StartOpen: true}, {Start: 5, End: 7}}, WithHalfOpenIntervals())
+	if got := tree.Containing(5); len(got) != 1 || got[0].StartOpen || len(tree.Containing(7)) != 0 {
+		t.Fatalf("ONLY [ 5 - 7 ] CONTAINS 5 IN A HALF-OPEN TREE, GOT %v", got)
+	}
+	if _, err := NewIntervalTreeFromSorted([]*Interval{{Start: 3, End: 9, StartOpen: true}, {Start: 3, End: 9}}); !errors.Is(err, ErrUnsorted) {
+		t.Fatalf("( 3 - 9 ] STARTS AFTER [ 3 - 9 ], GOT %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package intervaltree
+
+import (
+	"fmt"
+	"slices"
+)
+
+// -----------------------------------------------------
+// 				BUILDER
+// -----------------------------------------------------
+
+// Builder collects intervals one by one to construct an IntervalTree once with Build. It keeps its buffers from one
+// Build to the next, so rebuilding trees of about the same size allocates little more than the trees themselves
+type Builder struct {
+	intervals []*Interval
+	opts      []Option
+	cfg       *config
+	scratch   *scratch
+}
+
+// NewBuilder creates an empty Builder, the options are given to the IntervalTree at Build. WithCapacity
+// preallocates the room for the intervals to collect and the buffers of the construction
+func NewBuilder(opts ...Option) *Builder {
+	cfg := newConfig(opts)
+	b := &Builder{opts: opts, cfg: cfg, scratch: &scratch{seen: make(map[*Interval]struct{}, cfg.capacity)}}
+	b.Grow(cfg.capacity)
+	b.scratch.grow(cfg.capacity)
+	return b
+}
+
+// Grow preallocates room for n more intervals
+func (b *Builder) Grow(n int) {
+	if n > cap(b.intervals)-len(b.intervals) {
+		grown := make([]*Interval, len(b.intervals), len(b.intervals)+n)
+		copy(grown, b.intervals)
+		b.intervals = grown
+	}
+}
+
+// Len returns the number of intervals collected since the last Reset
+func (b *Builder) Len() int {
+	return len(b.intervals)
+}
+
+// Add collects a new interval with the endpoints and the payload given in parameter
+func (b *Builder) Add(start, end int, payload interface{}) error {
+	return b.AddInterval(&Interval{Start: start, End: end, Payload: payload})
+}
+
+// AddInterval collects the interval, which is stored as is by the IntervalTree
+func (b *Builder) AddInterval(in *Interval) error {
+	if err := validate(in, b.cfg.open); err != nil {
+		return err
+	}
+	b.intervals = append(b.intervals, in)
+	return nil
+}
+
+// AddAll collects all the intervals of the slice, or none of them if one is invalid
+func (b *Builder) AddAll(intervals []*Interval) error {
+	for i, in := range intervals {
+		if err := validate(in, b.cfg.open); err != nil {
+			return fmt.Errorf("interval %d: %w", i, err)
+		}
+	}
+	b.intervals = append(b.intervals, intervals...)
+	return nil
+}
+
+// Build constructs an IntervalTree holding all the collected intervals, see NewIntervalTree. The Builder keeps
+// them, call Reset to start collecting a new set
+// Build complexity: O(n log n), n = number of collected intervals, the endpoints being sorted once
+func (b *Builder) Build() (*IntervalTree, error) {
+	return newIntervalTree(b.intervals, b.cfg, b.scratch)
+}
+
+// Reset forgets the collected intervals but keeps the allocated room and buffers for the next ones
+func (b *Builder) Reset() {
+	clear(b.intervals)
+	b.intervals = b.intervals[:0]
+	b.scratch.reset()
+}
+
+// scratch holds the buffers used to build an IntervalTree from the endpoints of its intervals sorted once, rather
+// than at every level as fromIntervals does
+type scratch struct {
+	sorted    bool                   // the intervals are sorted by Start
+	ends, buf []endpoint             // the sorted endpoints and the buffer to partition them, see fromEndpoints
+	mid       []*Interval            // the intervals of the node being built
+	seen      map[*Interval]struct{} // the intervals met, see distinctPointers
+}
+
+// grow preallocates the buffers for n intervals
+func (s *scratch) grow(n int) {
+	if cap(s.ends) < 2*n {
+		s.ends, s.buf = make([]endpoint, 2*n), make([]endpoint, 2*n)
+	}
+}
+
+// endpoints returns the endpoints of the intervals sorted by coordinate and a buffer of the same length, both in
+// the buffers of the scratch, an interval going from its first to its last point, see lastPoint. The sort is stable
+// so the first points at the same coordinate keep the order of the intervals, the nodes listing the intervals with
+// the same endpoints in that order. Sorted intervals only need their Ends to be sorted before merging them with the
+// Starts
+// Complexity: O(n log n), n = len(intervals)
+func (s *scratch) endpoints(intervals []*Interval, open int) (ends, buf []endpoint) {
+	n := len(intervals)
+	s.grow(n)
+	ends, buf = s.ends[:2*n], s.buf[:2*n]
+	if s.sorted {
+		for i, in := range intervals {
+			buf[i] = endpoint{in.first(), in, true}
+			buf[n+i] = endpoint{lastPoint(in, open), in, false}
+		}
+		slices.SortStableFunc(buf[n:], compareEndpoints)
+		mergeInto(ends, buf[:n], buf[n:])
+		return ends, buf
+	}
+	for i, in := range intervals {
+		ends[2*i] = endpoint{in.first(), in, true}
+		ends[2*i+1] = endpoint{lastPoint(in, open), in, false}
+	}
+	slices.SortStableFunc(ends, compareEndpoints)
+	return ends, buf
+}
+
+// reset drops the intervals referenced by the buffers, keeping their room
+func (s *scratch) reset() {
+	clear(s.ends)
+	clear(s.buf)
+	clear(s.mid)
+}
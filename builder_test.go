@@ -0,0 +1,113 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(WithSequenceNumbers())
+	b.Grow(100)
+	if cap(b.intervals) < 100 {
+		t.Fatalf("GROW MUST PREALLOCATE, GOT A CAPACITY OF %d", cap(b.intervals))
+	}
+	for i := 0; i < 50; i++ {
+		if err := b.Add(i, i+10, i); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+	}
+	if err := b.Add(5, 4, nil); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING ErrReversedInterval, GOT %v", err)
+	}
+	if err := b.AddInterval(nil); !errors.Is(err, ErrNilInterval) {
+		t.Fatalf("EXPECTING ErrNilInterval, GOT %v", err)
+	}
+	if err := b.AddAll([]*Interval{{Start: 100, End: 101}, {Start: 3, End: 1}}); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING ErrReversedInterval, GOT %v", err)
+	}
+	if err := b.AddAll([]*Interval{{Start: 100, End: 101}, {Start: 102, End: 103}}); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	if b.Len() != 52 {
+		t.Fatalf("EXPECTING 52 INTERVALS, GOT %d", b.Len())
+	}
+	tree, err := b.Build()
+	if err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	if tree.Len() != 52 || len(tree.Containing(15)) != 11 || len(tree.Containing(100)) != 1 {
+		t.Fatalf("UNEXPECTED TREE %s", tree.Stats())
+	}
+	if seq, ok := tree.SeqOf(tree.Containing(100)[0]); !ok || seq != 50 {
+		t.Fatalf("THE OPTIONS MUST BE GIVEN TO THE TREE, GOT SEQUENCE %d (%t)", seq, ok)
+	}
+
+	capacity := cap(b.intervals)
+	b.Reset()
+	if b.Len() != 0 || cap(b.intervals) != capacity {
+		t.Fatalf("RESET MUST KEEP THE ROOM, GOT LEN %d AND CAPACITY %d", b.Len(), cap(b.intervals))
+	}
+	_ = b.Add(0, 1, nil)
+	if next, _ := b.Build(); next.Len() != 1 || tree.Len() != 52 || len(tree.Containing(15)) != 11 {
+		t.Fatalf("A BUILT TREE MUST NOT DEPEND ON THE BUILDER")
+	}
+}
+
+func TestBuilder_Rebuild(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	b := NewBuilder(WithCapacity(100), WithSequenceNumbers())
+	if cap(b.intervals) < 100 || cap(b.scratch.ends) < 200 {
+		t.Fatalf("WITHCAPACITY MUST PREALLOCATE, GOT CAPACITIES %d AND %d", cap(b.intervals), cap(b.scratch.ends))
+	}
+	for i := 0; i < 100; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(150), 1000, 50)
+		if len(intervals) > 0 {
+			intervals = append(intervals, intervals[0]) // stored once
+		}
+		b.Reset()
+		if err := b.AddAll(intervals); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		tree, err := b.Build()
+		if err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		if len(intervals) > 0 {
+			intervals = intervals[:len(intervals)-1]
+		}
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, intervals, 1000)
+		for k, in := range tree.AllSorted(BySequence) {
+			if in != intervals[k] {
+				t.Fatalf("EXPECTING %s AT SEQUENCE %d, GOT %s", intervals[k], k, in)
+			}
+		}
+	}
+}
+
+func benchmarkBuilderIntervals() []*Interval {
+	return randomIntervals(rand.New(rand.NewSource(1)), 100_000, 10_000_000, 1_000)
+}
+
+func BenchmarkBuilder_Build(b *testing.B) {
+	intervals := benchmarkBuilderIntervals()
+	builder := NewBuilder(WithCapacity(len(intervals)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		builder.Reset()
+		_ = builder.AddAll(intervals)
+		_, _ = builder.Build()
+	}
+}
+
+func BenchmarkBuilder_NewIntervalTree(b *testing.B) {
+	intervals := benchmarkBuilderIntervals()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = NewIntervalTree(intervals)
+	}
+}
@@ -0,0 +1,176 @@
+package intervaltree
+
+import (
+	"container/list"
+	"slices"
+	"sync"
+)
+
+// -----------------------------------------------------
+// 				QUERY CACHE
+// -----------------------------------------------------
+
+// CachedIntervalTree answers Containing and Intersecting from an LRU cache of their results, for the workloads
+// repeating a few queries many times between mutations. The other methods are the ones of the embedded
+// IntervalTree, whose Stats is reached through the IntervalTree field. The cache does not watch the tree: after a
+// mutation, Invalidate must be called with the intervals inserted or deleted, or InvalidateAll, else the cached
+// results of the queries they intersect are returned as they were. The queries may be run concurrently, the
+// mutations of the tree may not, as with an IntervalTree
+type CachedIntervalTree struct {
+	*IntervalTree
+
+	mu         sync.RWMutex
+	maxEntries int
+	entries    map[cacheKey]*list.Element // the element of every cached query in recent
+	recent     *list.List                 // the *cacheEntry, most recently used first
+	generation uint64                     // incremented by every invalidation, see lookup
+	stats      CacheStats
+}
+
+// cacheKey is the query of a cached result: its first and last points. Intersecting only depends on them, and the
+// single point query [x, x] returns what Containing(x) does, so both share the entry
+type cacheKey struct {
+	start, end int
+}
+
+// cacheEntry is a cached result, a copy owned by the cache
+type cacheEntry struct {
+	key cacheKey
+	res []*Interval
+}
+
+// CacheStats counts the lookups of a CachedIntervalTree since its creation
+type CacheStats struct {
+	Hits      uint64 // queries answered from the cache
+	Misses    uint64 // queries answered by the tree
+	Evictions uint64 // results dropped to make room, the invalidations excluded
+	Entries   int    // results currently cached
+}
+
+// NewCachedIntervalTree creates a CachedIntervalTree over the tree given in parameter, keeping the results of up to
+// maxEntries queries, none if maxEntries is not positive
+func NewCachedIntervalTree(tree *IntervalTree, maxEntries int) *CachedIntervalTree {
+	return &CachedIntervalTree{
+		IntervalTree: tree,
+		maxEntries:   maxEntries,
+		entries:      make(map[cacheKey]*list.Element),
+		recent:       list.New(),
+	}
+}
+
+// Containing returns all intervals containing the value x, as IntervalTree.Containing, in a new slice the caller may
+// modify without affecting the cache
+// Complexity: O(k) on a hit and O(ln n + k) on a miss, n = len(intervals in struct) and k = returned intervals
+func (c *CachedIntervalTree) Containing(x int) []*Interval {
+	return c.lookup(
+		cacheKey{x, x}, func() []*Interval {
+			return c.IntervalTree.Containing(x)
+		},
+	)
+}
+
+// Intersecting returns all intervals intersecting the Interval given in parameter, as IntervalTree.Intersecting, in
+// a new slice the caller may modify without affecting the cache. The queries holding no point are not cached
+// Complexity: O(k) on a hit and O(ln n + k) on a miss, n = len(intervals in struct) and k = returned intervals
+func (c *CachedIntervalTree) Intersecting(interval *Interval) []*Interval {
+	t := c.IntervalTree.orEmpty()
+	window, err := t.window(interval)
+	if err != nil || t.empty(window) {
+		return t.Intersecting(interval)
+	}
+	return c.lookup(
+		cacheKey{window.first(), t.last(window)}, func() []*Interval {
+			return t.Intersecting(interval)
+		},
+	)
+}
+
+// lookup returns a copy of the cached result of the query, or runs it and caches a copy of its result. The query
+// runs outside of the lock, so an invalidation may happen meanwhile: the result is then not cached, being possibly
+// computed before the mutation
+func (c *CachedIntervalTree) lookup(key cacheKey, query func() []*Interval) []*Interval {
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		// a hit moves the entry, so even the lookups take the write lock
+		c.recent.MoveToFront(element)
+		c.stats.Hits++
+		res := slices.Clone(element.Value.(*cacheEntry).res)
+		c.mu.Unlock()
+		return res
+	}
+	c.stats.Misses++
+	generation := c.generation
+	c.mu.Unlock()
+	res := query()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation == c.generation {
+		c.store(key, slices.Clone(res))
+	}
+	return res
+}
+
+// store caches the result of the query, evicting the least recently used ones beyond maxEntries. A query run by
+// several goroutines at once is stored once
+func (c *CachedIntervalTree) store(key cacheKey, res []*Interval) {
+	if c.maxEntries <= 0 {
+		return
+	}
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*cacheEntry).res = res
+		c.recent.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.recent.PushFront(&cacheEntry{key, res})
+	for c.recent.Len() > c.maxEntries {
+		c.remove(c.recent.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove drops the cached result of the element
+func (c *CachedIntervalTree) remove(element *list.Element) {
+	delete(c.entries, element.Value.(*cacheEntry).key)
+	c.recent.Remove(element)
+}
+
+// Invalidate drops the cached results of the queries intersecting the Interval given in parameter, to be called
+// with every interval inserted in the tree or deleted from it. A nil interval drops nothing, and a reversed one is
+// swapped if the tree was built WithSwappedQueries, as an Intersecting query
+// Complexity: O(e), e = number of cached results
+func (c *CachedIntervalTree) Invalidate(interval *Interval) {
+	t := c.IntervalTree.orEmpty()
+	window, err := t.window(interval)
+	if err != nil || t.empty(window) {
+		return
+	}
+	start, end := window.first(), t.last(window)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for element := c.recent.Front(); element != nil; {
+		next := element.Next()
+		if key := element.Value.(*cacheEntry).key; key.start <= end && start <= key.end {
+			c.remove(element)
+		}
+		element = next
+	}
+}
+
+// InvalidateAll drops every cached result, to be called after any mutation of the tree
+func (c *CachedIntervalTree) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+	c.recent.Init()
+}
+
+// Stats returns the counters of the cache. The statistics of the tree are given by IntervalTree.Stats
+func (c *CachedIntervalTree) Stats() CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s := c.stats
+	s.Entries = c.recent.Len()
+	return s
+}
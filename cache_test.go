@@ -0,0 +1,159 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCachedIntervalTree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 20; i++ {
+		intervals, mode := randomFlagged(rnd, rnd.Intn(2000), 1000, 1+rnd.Intn(100)), []Option(nil)
+		if i%2 == 1 {
+			intervals, mode = randomHalfOpen(rnd, rnd.Intn(2000), 1000, 1+rnd.Intn(100)), []Option{WithHalfOpenIntervals()}
+		}
+		tree := MustNewIntervalTree(intervals, mode...)
+		cached := NewCachedIntervalTree(tree, 16)
+		// a handful of points and windows repeated many times
+		points := make([]int, 8)
+		for j := range points {
+			points[j] = rnd.Intn(1000)
+		}
+		for q := 0; q < 500; q++ {
+			x := points[rnd.Intn(len(points))]
+			if got, want := cached.Containing(x), tree.Containing(x); !slices.Equal(got, want) {
+				t.Fatalf("CONTAINING(%d): EXPECTING %d INTERVALS AS THE TREE, GOT %d", x, len(want), len(got))
+			}
+			window := &Interval{Start: x, End: x + x%50 + 1}
+			if got, want := cached.Intersecting(window), tree.Intersecting(window); !slices.Equal(got, want) {
+				t.Fatalf("INTERSECTING %s: EXPECTING %d INTERVALS AS THE TREE, GOT %d", window, len(want), len(got))
+			}
+		}
+		s := cached.Stats()
+		if s.Hits+s.Misses != 1000 || s.Misses > 16 || s.Evictions != 0 || s.Entries != int(s.Misses) {
+			t.Fatalf("EXPECTING AT MOST 16 MISSES OVER 1000 QUERIES, GOT %+v", s)
+		}
+		// the results are copies
+		for _, x := range points {
+			if res := cached.Containing(x); len(res) > 0 {
+				res[0] = nil
+				if cached.Containing(x)[0] == nil {
+					t.Fatalf("%d: THE CALLER MUST NOT MODIFY THE CACHED RESULT", x)
+				}
+			}
+		}
+		// an insertion invalidates the queries it intersects only
+		in := &Interval{Start: points[0], End: points[0] + 1 + tree.open}
+		_ = tree.Insert(in)
+		cached.Invalidate(in)
+		seen := make(map[int]bool)
+		for _, x := range points {
+			before := cached.Stats()
+			if got, want := cached.Containing(x), tree.Containing(x); !slices.Equal(got, want) {
+				t.Fatalf("CONTAINING(%d) AFTER INVALIDATE: EXPECTING %d INTERVALS, GOT %d", x, len(want), len(got))
+			}
+			hit := cached.Stats().Hits > before.Hits
+			if affected := x >= in.Start && x <= in.End-tree.open; affected == hit && !seen[x] {
+				t.Fatalf("CONTAINING(%d): ONLY THE QUERIES INTERSECTING %s MUST BE INVALIDATED", x, in)
+			}
+			seen[x] = true
+		}
+		cached.InvalidateAll()
+		if s := cached.Stats(); s.Entries != 0 {
+			t.Fatalf("EXPECTING NO ENTRY AFTER INVALIDATEALL, GOT %d", s.Entries)
+		}
+	}
+	// the least recently used query is evicted
+	cached := NewCachedIntervalTree(MustNewIntervalTree([]*Interval{{Start: 0, End: 10}}), 2)
+	cached.Containing(1)
+	cached.Containing(2)
+	cached.Containing(1)
+	cached.Containing(3) // evicts 2
+	cached.Containing(1)
+	if s := cached.Stats(); s.Hits != 2 || s.Misses != 3 || s.Evictions != 1 || s.Entries != 2 {
+		t.Fatalf("EXPECTING 2 HITS, 3 MISSES AND 1 EVICTION, GOT %+v", s)
+	}
+	cached.Containing(2)
+	if s := cached.Stats(); s.Misses != 4 {
+		t.Fatalf("THE EVICTED QUERY MUST MISS, GOT %+v", s)
+	}
+	// nothing is cached without room, nor for a query holding no point
+	none := NewCachedIntervalTree(MustNewIntervalTree(nil), 0)
+	none.Containing(1)
+	none.Intersecting(nil)
+	if s := none.Stats(); s.Entries != 0 || s.Misses != 1 {
+		t.Fatalf("EXPECTING A SINGLE MISS AND NO ENTRY, GOT %+v", s)
+	}
+}
+
+func TestCachedIntervalTree_Concurrent(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 5000, 10_000, 500))
+	cached := NewCachedIntervalTree(tree, 8)
+	points := make([]int, 16)
+	want := make([][]*Interval, len(points))
+	for j := range points {
+		points[j] = rnd.Intn(10_000)
+		want[j] = tree.Containing(points[j])
+	}
+	var wg sync.WaitGroup
+	errs := make(chan int, 32)
+	for g := 0; g < 32; g++ {
+		seed := rnd.Int63()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for q := 0; q < 200; q++ {
+				j := r.Intn(len(points))
+				if !slices.Equal(cached.Containing(points[j]), want[j]) {
+					errs <- points[j]
+					return
+				}
+				switch r.Intn(20) {
+				case 0:
+					cached.Invalidate(&Interval{Start: points[j], End: points[j]})
+				case 1:
+					cached.InvalidateAll()
+				case 2:
+					cached.Stats()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for x := range errs {
+		t.Fatalf("CONTAINING(%d): A CONCURRENT QUERY MUST RETURN THE RESULT OF THE TREE", x)
+	}
+	if s := cached.Stats(); s.Hits+s.Misses != 32*200 || s.Entries > 8 {
+		t.Fatalf("EXPECTING %d LOOKUPS AND AT MOST 8 ENTRIES, GOT %+v", 32*200, s)
+	}
+}
+
+// benchmarkCached repeats a handful of stabbing queries returning hundreds of intervals
+func benchmarkCached(b *testing.B, containing func(x int) []*Interval) {
+	rnd := rand.New(rand.NewSource(1))
+	points := make([]int, 8)
+	for i := range points {
+		points[i] = 1_000_000 + rnd.Intn(8_000_000)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		containing(points[i%len(points)])
+	}
+}
+
+func BenchmarkCachedIntervalTree(b *testing.B) {
+	tree := MustNewIntervalTree(randomIntervals(rand.New(rand.NewSource(1)), 1_000_000, 10_000_000, 10_000))
+	benchmarkCached(b, NewCachedIntervalTree(tree, 64).Containing)
+}
+
+func BenchmarkCachedIntervalTree_Uncached(b *testing.B) {
+	tree := MustNewIntervalTree(randomIntervals(rand.New(rand.NewSource(1)), 1_000_000, 10_000_000, 10_000))
+	benchmarkCached(b, tree.Containing)
+}
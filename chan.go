@@ -0,0 +1,42 @@
+package intervaltree
+
+import (
+	"context"
+	"iter"
+)
+
+// -----------------------------------------------------
+// 				STREAMING OVER CHANNELS
+// -----------------------------------------------------
+
+// ContainingChan streams the intervals containing the value x over a channel with a buffer of size buf. The
+// channel is closed once the traversal is done or ctx is cancelled, a cancelled ctx always unblocks the producer
+// so abandoning the channel does not leak it. The IntervalTree must not be mutated until the channel is closed
+func (t *IntervalTree) ContainingChan(ctx context.Context, x int, buf int) <-chan *Interval {
+	return stream(ctx, t.ContainingSeq(x), buf)
+}
+
+// IntersectingChan streams the intervals intersecting the Interval given in parameter over a channel with a
+// buffer of size buf, each once. It behaves as ContainingChan
+func (t *IntervalTree) IntersectingChan(ctx context.Context, interval *Interval, buf int) <-chan *Interval {
+	return stream(ctx, t.IntersectingSeq(interval), buf)
+}
+
+// stream sends the values of seq over a new channel from a producer goroutine, until seq ends or ctx is done
+func stream(ctx context.Context, seq iter.Seq[*Interval], buf int) <-chan *Interval {
+	ch := make(chan *Interval, buf)
+	go func() {
+		defer close(ch)
+		for in := range seq {
+			if ctx.Err() != nil {
+				return // select picks randomly when the consumer is also ready
+			}
+			select {
+			case ch <- in:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,70 @@
+package intervaltree
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Chan(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 3000, 1000, 300)
+	tree := MustNewIntervalTree(intervals)
+	for i := 0; i < 20; i++ {
+		x := rnd.Intn(1300)
+		query := &Interval{Start: x, End: x + rnd.Intn(100)}
+		want := make(map[*Interval]bool)
+		for _, in := range tree.Intersecting(query) {
+			want[in] = true
+		}
+		got := make(map[*Interval]bool)
+		for in := range tree.IntersectingChan(context.Background(), query, i) {
+			if got[in] || !want[in] {
+				t.Fatalf("%s STREAMED TWICE OR WRONGLY", in)
+			}
+			got[in] = true
+		}
+		if len(got) != len(want) {
+			t.Fatalf("EXPECTING %d VALUES, GOT %d", len(want), len(got))
+		}
+		count := 0
+		for range tree.ContainingChan(context.Background(), x, 0) {
+			count++
+		}
+		if count != len(tree.Containing(x)) {
+			t.Fatalf("EXPECTING %d VALUES, GOT %d", len(tree.Containing(x)), count)
+		}
+	}
+}
+
+func TestIntervalTree_ChanCancel(t *testing.T) {
+	var intervals []*Interval
+	for i := 0; i < 1000; i++ {
+		intervals = append(intervals, &Interval{Start: i, End: 2000})
+	}
+	tree := MustNewIntervalTree(intervals)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := tree.ContainingChan(ctx, 1500, 0)
+	for i := 0; i < 10; i++ {
+		<-ch
+	}
+	cancel()
+	// the producer must stop and close the channel instead of streaming the remaining values
+	remaining := 0
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				if remaining > 1 {
+					t.Fatalf("THE PRODUCER KEPT STREAMING %d VALUES AFTER CANCEL", remaining)
+				}
+				return
+			}
+			remaining++
+		case <-timeout:
+			t.Fatalf("THE CHANNEL WAS NOT CLOSED AFTER CANCEL")
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package intervaltree
+
+import (
+	"math"
+	"slices"
+)
+
+// -----------------------------------------------------
+// 				CLONING
+// -----------------------------------------------------
+
+// Clone returns an independent copy of the IntervalTree sharing the *Interval pointers, see CloneShallow
+func (t *IntervalTree) Clone() *IntervalTree {
+	return t.CloneShallow()
+}
+
+// CloneShallow returns a copy of the IntervalTree with its own nodes, BST, coverage and indexes, but sharing the
+// *Interval pointers: inserting or deleting in one tree does not change the other, modifying a stored Interval
+// does. The structure is copied as is, nothing is sorted again.
+// Complexity: O(n), n = len(intervals in struct)
+func (t *IntervalTree) CloneShallow() *IntervalTree {
+	t = t.orEmpty()
+	return t.clone(func(in *Interval) *Interval { return in })
+}
+
+// CloneDeep returns a copy of the IntervalTree holding copies of the stored intervals, so that the copy is fully
+// independent of the original. The payloads are shared, as they are copied by value.
+// Complexity: O(n), n = len(intervals in struct)
+func (t *IntervalTree) CloneDeep() *IntervalTree {
+	t = t.orEmpty()
+	copies := make(map[*Interval]*Interval, t.size)
+	return t.clone(
+		func(in *Interval) *Interval {
+			c, ok := copies[in]
+			if !ok {
+				c = in.copyWith(in.Payload)
+				copies[in] = c
+			}
+			return c
+		},
+	)
+}
+
+// Freeze makes the IntervalTree own its intervals, as WithCopiedIntervals does: every stored interval is replaced by
+// a copy, see CloneDeep, so modifying the intervals given to the tree can no longer corrupt it. The queries return
+// the copies from then on, and the views and iterators made before are invalidated.
+// Complexity: O(n), n = len(intervals in struct)
+func (t *IntervalTree) Freeze() {
+	t = t.orEmpty()
+	c := t.CloneDeep()
+	c.epoch = t.epoch + 1
+	*t = *c
+}
+
+// clone copies the IntervalTree, replacing every stored interval by the one given by the mapping
+func (t *IntervalTree) clone(mapping func(*Interval) *Interval) *IntervalTree {
+	c := &IntervalTree{
+		tree:      copyTree(t.nodes(), mapping),
+		cover:     runsOf(t.cover.runs()),
+		size:      t.size,
+		nextSeq:   t.nextSeq,
+		keyFunc:   t.keyFunc,
+		equal:     t.equal,
+		tie:       t.tie,
+		open:      t.open,
+		swap:      t.swap,
+		checked:   t.checked,
+		mutations: t.mutations,
+		rebuildAt: t.rebuildAt,
+		owner:     new(owner),
+	}
+	points := t.pointsIn(math.MinInt, math.MaxInt)
+	copied := make([]Point, len(points))
+	for i, p := range points {
+		copied[i] = Point{p.x, mapAll(p.ptrs, mapping)}
+	}
+	c.bst = newPointTree(copied)
+	if t.seq != nil {
+		c.seq = make(map[*Interval]uint64, len(t.seq))
+		for in, seq := range t.seq {
+			c.seq[mapping(in)] = seq
+		}
+	}
+	if t.counts != nil {
+		c.counts = make(map[*Interval]int, len(t.counts))
+		for in, count := range t.counts {
+			c.counts[mapping(in)] = count
+		}
+	}
+	if t.keys != nil {
+		c.keys = make(map[interface{}][]*Interval, len(t.keys))
+		for key, list := range t.keys {
+			c.keys[key] = mapAll(list, mapping)
+		}
+	}
+	return c
+}
+
+// copyTree copies the tree with new elements, holding the intervals given by the mapping
+func copyTree(tree *nodeTree, mapping func(*Interval) *Interval) *nodeTree {
+	return &nodeTree{top: copyNode(tree.top, mapping)}
+}
+
+// copyNode copies the subtree of the element
+func copyNode(e *elt, mapping func(*Interval) *Interval) *elt {
+	if e == nil {
+		return nil
+	}
+	c := &elt{leftSorted: mapAll(e.leftSorted, mapping), byEnd: slices.Clone(e.byEnd), xMid: e.xMid}
+	c.lo, c.hi = e.lo, e.hi
+	c.left, c.right = copyNode(e.left, mapping), copyNode(e.right, mapping)
+	return c
+}
+
+// mapAll returns a new list with the intervals given by the mapping, in the same order
+func mapAll(list []*Interval, mapping func(*Interval) *Interval) []*Interval {
+	res := make([]*Interval, len(list))
+	for i, in := range list {
+		res[i] = mapping(in)
+	}
+	return res
+}
@@ -0,0 +1,104 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Clone(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 1000, 500, 50)
+	for _, deep := range []bool{false, true} {
+		original := MustNewIntervalTree(intervals, WithSequenceNumbers())
+		before := make([][]*Interval, 500)
+		for x := range before {
+			before[x] = original.Containing(x)
+		}
+		var clone *IntervalTree
+		if deep {
+			clone = original.CloneDeep()
+		} else {
+			clone = original.Clone()
+		}
+		if !deep && !sameStructure(original, clone) {
+			t.Fatalf("A SHALLOW CLONE MUST HAVE THE SAME STRUCTURE")
+		}
+		checkStructure(t, clone)
+		stored := clone.All()
+		if deep {
+			for _, in := range stored {
+				if original.holds(in) {
+					t.Fatalf("A DEEP CLONE MUST NOT SHARE %s", in)
+				}
+			}
+		}
+		if seq, ok := clone.SeqOf(stored[0]); !ok || seq >= uint64(len(intervals)) {
+			t.Fatalf("THE CLONE MUST KEEP THE SEQUENCE NUMBERS, GOT %d (%t)", seq, ok)
+		}
+		// mutate the clone heavily
+		for _, in := range stored[:500] {
+			if !clone.Delete(in) {
+				t.Fatalf("CANNOT DELETE %s FROM THE CLONE", in)
+			}
+		}
+		added := randomIntervals(rnd, 500, 500, 50)
+		for _, in := range added {
+			_ = clone.Insert(in)
+		}
+		checkStructure(t, clone)
+		checkStructure(t, original)
+		if original.Len() != len(intervals) {
+			t.Fatalf("EXPECTING THE ORIGINAL TO KEEP %d INTERVALS, GOT %d", len(intervals), original.Len())
+		}
+		for x := range before {
+			got := original.Containing(x)
+			if len(got) != len(before[x]) {
+				t.Fatalf("CONTAINING(%d) OF THE ORIGINAL CHANGED AFTER MUTATING THE CLONE", x)
+			}
+			for i := range got {
+				if got[i] != before[x][i] {
+					t.Fatalf("CONTAINING(%d) OF THE ORIGINAL CHANGED AFTER MUTATING THE CLONE", x)
+				}
+			}
+		}
+		checkQueries(t, rnd, clone, append(stored[500:], added...), 500)
+	}
+}
+
+func TestIntervalTree_Freeze(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomIntervals(rnd, 1+rnd.Intn(200), 1000, 50)
+		expected := make([]*Interval, len(intervals)) // copies to check the queries against
+		for k, in := range intervals {
+			expected[k] = &Interval{Start: in.Start, End: in.End}
+		}
+		copied := MustNewIntervalTree(intervals, WithCopiedIntervals(), WithSequenceNumbers())
+		frozen := MustNewIntervalTree(intervals, WithSequenceNumbers())
+		var view NodeView
+		frozen.WalkViews(func(v NodeView) bool { view = v; return false })
+		frozen.Freeze()
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("FREEZE MUST INVALIDATE THE VIEWS")
+				}
+			}()
+			view.Len()
+		}()
+		// corrupting the input does not affect the trees
+		for _, in := range intervals {
+			in.Start, in.End = in.End+1000, in.End+2000
+		}
+		for _, tree := range []*IntervalTree{copied, frozen} {
+			checkStructure(t, tree)
+			checkQueries(t, rnd, tree, expected, 1000)
+			for k, in := range tree.AllSorted(BySequence) {
+				if in == intervals[k] {
+					t.Fatalf("THE TREE MUST RETURN ITS OWN COPIES")
+				}
+			}
+		}
+	}
+}
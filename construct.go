@@ -0,0 +1,117 @@
+package intervaltree
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+)
+
+// -----------------------------------------------------
+// 				CONVENIENCE CONSTRUCTORS
+// -----------------------------------------------------
+
+// Triple holds the endpoints and the payload of an interval to build, see NewIntervalTreeFromTriples
+type Triple struct {
+	Start, End int
+	Payload    interface{}
+}
+
+// NewIntervalTreeFromPairs creates a new interval tree with an interval without payload for every [Start, End]
+// pair, see NewIntervalTree. The intervals are allocated at once in a single backing slice.
+func NewIntervalTreeFromPairs(pairs [][2]int, opts ...Option) (*IntervalTree, error) {
+	backing := make([]Interval, len(pairs))
+	for i, pair := range pairs {
+		backing[i] = Interval{Start: pair[0], End: pair[1]}
+	}
+	return NewIntervalTree(pointersTo(backing), opts...)
+}
+
+// NewIntervalTreeFromTriples creates a new interval tree with an interval for every triple, see NewIntervalTree.
+// The intervals are allocated at once in a single backing slice and are the ones returned by the queries.
+func NewIntervalTreeFromTriples(triples []Triple, opts ...Option) (*IntervalTree, error) {
+	backing := make([]Interval, len(triples))
+	for i, triple := range triples {
+		backing[i] = Interval{Start: triple.Start, End: triple.End, Payload: triple.Payload}
+	}
+	return NewIntervalTree(pointersTo(backing), opts...)
+}
+
+// NewIntervalTreeFromValues creates a new interval tree holding a copy of the intervals, see NewIntervalTree. The
+// copies are made at once in a single backing slice owned by the tree and are the ones returned by the queries:
+// later changes to the slice given in parameter are not reflected in the tree.
+func NewIntervalTreeFromValues(intervals []Interval, opts ...Option) (*IntervalTree, error) {
+	backing := make([]Interval, len(intervals))
+	copy(backing, intervals)
+	return NewIntervalTree(pointersTo(backing), opts...)
+}
+
+// NewIntervalTreeFromSorted creates a new interval tree with the intervals given in parameter, which must be sorted
+// by Start, see NewIntervalTree. The order is verified, failing with ErrUnsorted, and spares sorting the endpoints at
+// every level of the tree: they are merged at once with the Ends sorted apart.
+// Build complexity: O(n log n), n = len(intervals)
+func NewIntervalTreeFromSorted(intervals []*Interval, opts ...Option) (*IntervalTree, error) {
+	return newIntervalTree(intervals, newConfig(opts), &scratch{sorted: true})
+}
+
+// NewIntervalTreeFromMap creates a new interval tree with an interval for every entry of the map, from the
+// [Start, End] value and with the key as Payload, indexed by key so FindByKey works, see NewIntervalTreeWithKeyFunc.
+// The intervals are given to the tree sorted by endpoints then by the printed key, so that the iteration order of the
+// map does not change the result.
+func NewIntervalTreeFromMap[K comparable](m map[K][2]int, opts ...Option) (*IntervalTree, error) {
+	backing := make([]Interval, 0, len(m))
+	for key, ends := range m {
+		backing = append(backing, Interval{Start: ends[0], End: ends[1], Payload: key})
+	}
+	sort.Slice(
+		backing, func(i, j int) bool {
+			a, b := &backing[i], &backing[j]
+			if a.Start != b.Start || a.End != b.End {
+				return a.lessStart(b)
+			}
+			return fmt.Sprint(a.Payload) < fmt.Sprint(b.Payload)
+		},
+	)
+	return NewIntervalTreeWithKeyFunc(pointersTo(backing), func(in *Interval) interface{} { return in.Payload }, opts...)
+}
+
+// NewIntervalTreeFromSeq creates a new interval tree with all the intervals of the sequence, see NewIntervalTree.
+// The sequence is consumed fully into a buffer growing geometrically before the tree is built.
+func NewIntervalTreeFromSeq(seq iter.Seq[*Interval], opts ...Option) (*IntervalTree, error) {
+	var intervals []*Interval
+	for in := range seq {
+		intervals = append(intervals, in)
+	}
+	return NewIntervalTree(intervals, opts...)
+}
+
+// NewIntervalTreeFromSeq2 is like NewIntervalTreeFromSeq for a source that can fail: it stops at the first error
+// of the sequence, returned wrapped with no tree.
+func NewIntervalTreeFromSeq2(seq iter.Seq2[*Interval, error], opts ...Option) (*IntervalTree, error) {
+	var intervals []*Interval
+	for in, err := range seq {
+		if err != nil {
+			return nil, fmt.Errorf("intervaltree: source failed after %d intervals: %w", len(intervals), err)
+		}
+		intervals = append(intervals, in)
+	}
+	return NewIntervalTree(intervals, opts...)
+}
+
+// NewIntervalTreeFromChan creates a new interval tree with all the intervals received on the channel, see
+// NewIntervalTreeFromSeq. It blocks until the channel is closed.
+func NewIntervalTreeFromChan(ch <-chan *Interval, opts ...Option) (*IntervalTree, error) {
+	var intervals []*Interval
+	for in := range ch {
+		intervals = append(intervals, in)
+	}
+	return NewIntervalTree(intervals, opts...)
+}
+
+// pointersTo returns a pointer to every interval of the backing slice, in the same order
+func pointersTo(backing []Interval) []*Interval {
+	intervals := make([]*Interval, len(backing))
+	for i := range backing {
+		intervals[i] = &backing[i]
+	}
+	return intervals
+}
@@ -0,0 +1,337 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestNewIntervalTreeFromPairs(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(200), 1000, 50)
+		pairs := make([][2]int, len(intervals))
+		triples := make([]Triple, len(intervals))
+		for k, in := range intervals {
+			in.Payload = k
+			pairs[k] = [2]int{in.Start, in.End}
+			triples[k] = Triple{Start: in.Start, End: in.End, Payload: k}
+		}
+		fromPairs, err := NewIntervalTreeFromPairs(pairs)
+		if err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		checkStructure(t, fromPairs)
+		checkQueries(t, rnd, fromPairs, intervals, 1000)
+		fromTriples, err := NewIntervalTreeFromTriples(triples, WithSequenceNumbers())
+		if err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		checkStructure(t, fromTriples)
+		checkQueries(t, rnd, fromTriples, intervals, 1000)
+		// the payloads round-trip through the allocated intervals
+		for k, in := range fromTriples.AllSorted(BySequence) {
+			if in.Payload != k || in.Start != triples[k].Start || in.End != triples[k].End {
+				t.Fatalf("EXPECTING %v, GOT %s WITH PAYLOAD %v", triples[k], in, in.Payload)
+			}
+		}
+	}
+
+	if _, err := NewIntervalTreeFromPairs([][2]int{{0, 1}, {3, 2}}); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING %v, GOT %v", ErrReversedInterval, err)
+	}
+	if _, err := NewIntervalTreeFromTriples([]Triple{{Start: 3, End: 2}}); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING %v, GOT %v", ErrReversedInterval, err)
+	}
+	if tree, err := NewIntervalTreeFromPairs(nil); err != nil || !tree.IsEmpty() {
+		t.Fatalf("EXPECTING AN EMPTY TREE, GOT %v", err)
+	}
+}
+
+func TestNewIntervalTreeFromValues(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomIntervals(rnd, 1+rnd.Intn(200), 1000, 50)
+		values := make([]Interval, len(intervals))
+		for k, in := range intervals {
+			values[k] = *in
+		}
+		tree, err := NewIntervalTreeFromValues(values)
+		if err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, intervals, 1000)
+		// the tree owns its copies
+		for _, in := range tree.All() {
+			if in == &values[0] {
+				t.Fatalf("THE TREE MUST NOT POINT INTO THE CALLER'S SLICE")
+			}
+		}
+		for k := range values {
+			values[k].Start, values[k].End = -100, -100
+		}
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, intervals, 1000)
+	}
+	if _, err := NewIntervalTreeFromValues([]Interval{{Start: 3, End: 2}}); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING %v, GOT %v", ErrReversedInterval, err)
+	}
+}
+
+func TestNewIntervalTreeFromSorted(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(300), 1000, 50)
+		sort.Slice(intervals, func(a, b int) bool { return intervals[a].Start < intervals[b].Start })
+		tree, err := NewIntervalTreeFromSorted(intervals, WithSequenceNumbers())
+		if err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, intervals, 1000)
+		if !sameStructure(tree, MustNewIntervalTree(intervals)) {
+			t.Fatalf("EXPECTING THE STRUCTURE BUILT BY NEWINTERVALTREE")
+		}
+		for k, in := range tree.AllSorted(BySequence) {
+			if in != intervals[k] {
+				t.Fatalf("EXPECTING %s AT SEQUENCE %d, GOT %s", intervals[k], k, in)
+			}
+		}
+		if len(intervals) > 1 && intervals[0].Start != intervals[len(intervals)-1].Start {
+			intervals[0], intervals[len(intervals)-1] = intervals[len(intervals)-1], intervals[0]
+			if _, err := NewIntervalTreeFromSorted(intervals); !errors.Is(err, ErrUnsorted) {
+				t.Fatalf("EXPECTING %v, GOT %v", ErrUnsorted, err)
+			}
+		}
+	}
+	if _, err := NewIntervalTreeFromSorted([]*Interval{{Start: 0, End: 1}, {Start: 3, End: 2}}); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING %v, GOT %v", ErrReversedInterval, err)
+	}
+}
+
+func benchmarkSortedIntervals() []*Interval {
+	rnd := rand.New(rand.NewSource(1))
+	intervals := randomIntervals(rnd, 1_000_000, 100_000_000, 10_000)
+	sort.Slice(intervals, func(a, b int) bool { return intervals[a].Start < intervals[b].Start })
+	return intervals
+}
+
+func BenchmarkNewIntervalTreeFromSorted(b *testing.B) {
+	intervals := benchmarkSortedIntervals()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = NewIntervalTreeFromSorted(intervals)
+	}
+}
+
+func BenchmarkNewIntervalTreeFromSorted_Unsorted(b *testing.B) {
+	intervals := benchmarkSortedIntervals()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = NewIntervalTree(intervals)
+	}
+}
+
+func TestNewIntervalTreeFromMap(t *testing.T) {
+	m := map[string][2]int{"a": {0, 10}, "b": {5, 15}, "c": {0, 10}, "d": {0, 10}, "e": {20, 20}, "f": {12, 30}}
+	var first []*Interval
+	for i := 0; i < 20; i++ {
+		tree, err := NewIntervalTreeFromMap(m, WithSequenceNumbers())
+		if err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		checkStructure(t, tree)
+		for key, ends := range m {
+			found := tree.FindByKey(key)
+			if len(found) != 1 || found[0].Start != ends[0] || found[0].End != ends[1] || found[0].Payload != key {
+				t.Fatalf("KEY %s: EXPECTING %v, GOT %v", key, ends, found)
+			}
+		}
+		if got := len(tree.Find(0, 10)); got != 3 {
+			t.Fatalf("EXPECTING THE 3 DUPLICATE RANGES, GOT %d", got)
+		}
+		// the iteration order of the map does not matter
+		all := tree.AllSorted(BySequence)
+		if first == nil {
+			first = all
+		}
+		for k, in := range all {
+			if *in != *first[k] {
+				t.Fatalf("EXPECTING %s (%v) AT SEQUENCE %d, GOT %s (%v)", first[k], first[k].Payload, k, in, in.Payload)
+			}
+		}
+		for x := -1; x < 32; x++ {
+			got := tree.ContainingWith(x, SortBy(BySequence))
+			want := first[:0:0]
+			for _, in := range first {
+				if in.Start <= x && x <= in.End {
+					want = append(want, in)
+				}
+			}
+			if len(got) != len(want) {
+				t.Fatalf("CONTAINING(%d): EXPECTING %v, GOT %v", x, want, got)
+			}
+			for k := range got {
+				if *got[k] != *want[k] {
+					t.Fatalf("CONTAINING(%d): EXPECTING %v, GOT %v", x, want, got)
+				}
+			}
+		}
+	}
+	if _, err := NewIntervalTreeFromMap(map[int][2]int{1: {3, 2}}); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING %v, GOT %v", ErrReversedInterval, err)
+	}
+}
+
+func TestNewIntervalTreeFromSeq(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 200_000, 10_000_000, 1_000)
+	generate := func(yield func(*Interval) bool) {
+		for _, in := range intervals {
+			if !yield(in) {
+				return
+			}
+		}
+	}
+	fromSlice := MustNewIntervalTree(intervals)
+	fromSeq, err := NewIntervalTreeFromSeq(generate)
+	if err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	fromSeq2, err := NewIntervalTreeFromSeq2(
+		func(yield func(*Interval, error) bool) {
+			for in := range generate {
+				if !yield(in, nil) {
+					return
+				}
+			}
+		},
+	)
+	if err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	ch := make(chan *Interval, 64)
+	go func() {
+		defer close(ch)
+		for in := range generate {
+			ch <- in
+		}
+	}()
+	fromChan, err := NewIntervalTreeFromChan(ch)
+	if err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	for _, tree := range []*IntervalTree{fromSeq, fromSeq2, fromChan} {
+		if !sameStructure(fromSlice, tree) {
+			t.Fatalf("EXPECTING THE STRUCTURE BUILT FROM THE SLICE")
+		}
+		for q := 0; q < 200; q++ {
+			x := rnd.Intn(10_000_000)
+			if got, want := len(tree.Containing(x)), len(fromSlice.Containing(x)); got != want {
+				t.Fatalf("CONTAINING(%d): EXPECTING %d INTERVALS, GOT %d", x, want, got)
+			}
+		}
+	}
+
+	failure := errors.New("parse error")
+	_, err = NewIntervalTreeFromSeq2(
+		func(yield func(*Interval, error) bool) {
+			if yield(&Interval{Start: 0, End: 1}, nil) {
+				yield(nil, failure)
+			}
+		},
+	)
+	if !errors.Is(err, failure) {
+		t.Fatalf("EXPECTING %v, GOT %v", failure, err)
+	}
+	if _, err := NewIntervalTreeFromSeq(func(yield func(*Interval) bool) { yield(&Interval{Start: 1, End: 0}) }); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING %v, GOT %v", ErrReversedInterval, err)
+	}
+}
+
+// paste hangs the subtree under the iterator, which must be at the bottom of the tree
+func paste(itr *iterator, subtree *nodeTree) {
+	itr.link(subtree.top)
+}
+
+// fromIntervalsPerLevel is the construction sorting the endpoints again at every level of the recursion, the
+// reference of the structure fromIntervals must build
+func fromIntervalsPerLevel(intervals []*Interval, tie func(a, b *Interval) bool, open int) *nodeTree {
+	tree := &nodeTree{}
+	if len(intervals) == 0 {
+		return tree
+	}
+	points := make([]int, 0, 2*len(intervals))
+	for _, in := range intervals {
+		points = append(points, in.first(), lastPoint(in, open))
+	}
+	sort.Ints(points)
+	xMid := points[len(intervals)]
+	var left, right, mid []*Interval
+	for _, in := range intervals {
+		switch {
+		case lastPoint(in, open) < xMid:
+			left = append(left, in)
+		case in.first() > xMid:
+			right = append(right, in)
+		default:
+			mid = append(mid, in)
+		}
+	}
+	itr := tree.root()
+	itr.insert(newElt(mid, xMid, tie))
+	paste(itr.left(), fromIntervalsPerLevel(left, tie, open))
+	paste(itr.right(), fromIntervalsPerLevel(right, tie, open))
+	return tree
+}
+
+func TestFromIntervals_SameStructure(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	byPayload := func(a, b *Interval) bool { return a.Payload.(int) < b.Payload.(int) }
+	for i := 0; i < 200; i++ {
+		// few coordinates give many intervals with the same endpoints
+		intervals, open := randomFlagged(rnd, rnd.Intn(500), 1+rnd.Intn(1000), 1+rnd.Intn(50)), 0
+		if i%3 == 0 {
+			intervals, open = randomHalfOpen(rnd, rnd.Intn(500), 1+rnd.Intn(1000), 1+rnd.Intn(50)), 1
+		}
+		for k, in := range intervals {
+			in.Payload = rnd.Intn(len(intervals) - k + 1)
+		}
+		for _, tie := range []func(a, b *Interval) bool{nil, byPayload} {
+			want := &IntervalTree{tree: fromIntervalsPerLevel(intervals, tie, open)}
+			built, err := fromIntervals(intervals, tie, open)
+			if err != nil {
+				t.Fatalf("UNEXPECTED ERROR %v", err)
+			}
+			if !sameStructure(&IntervalTree{tree: built}, want) {
+				t.Fatalf("FROMINTERVALS MUST BUILD THE STRUCTURE OF THE SORT AT EVERY LEVEL")
+			}
+			opts := []Option{WithTieBreaker(tie), WithSmallThreshold(0)}
+			if open == 1 {
+				opts = append(opts, WithHalfOpenIntervals())
+			}
+			if !sameStructure(MustNewIntervalTree(intervals, opts...), want) {
+				t.Fatalf("NEWINTERVALTREE MUST BUILD THE STRUCTURE OF THE SORT AT EVERY LEVEL")
+			}
+		}
+	}
+}
+
+func BenchmarkFromIntervals(b *testing.B) {
+	intervals := randomIntervals(rand.New(rand.NewSource(1)), 1_000_000, 100_000_000, 10_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = fromIntervals(intervals, nil, 0)
+	}
+}
+
+func BenchmarkFromIntervals_PerLevel(b *testing.B) {
+	intervals := randomIntervals(rand.New(rand.NewSource(1)), 1_000_000, 100_000_000, 10_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = fromIntervalsPerLevel(intervals, nil, 0)
+	}
+}
@@ -0,0 +1,33 @@
+package intervaltree
+
+// -----------------------------------------------------
+// 				DEFENSIVE COPIES
+// -----------------------------------------------------
+
+// ContainingCopies returns copies of the intervals containing the value x, in the same order as Containing. The
+// result shares nothing with the IntervalTree, so modifying it cannot corrupt the tree. The payloads are copied
+// by copyPayload, or shared as is if it is nil
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
+func (t *IntervalTree) ContainingCopies(x int, copyPayload func(interface{}) interface{}) []Interval {
+	return copiesOf(t.Containing(x), copyPayload)
+}
+
+// IntersectingCopies returns copies of the intervals intersecting the Interval given in parameter, in the same order
+// as Intersecting. The result shares nothing with the IntervalTree, so modifying it cannot corrupt the tree. The
+// payloads are copied by copyPayload, or shared as is if it is nil
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
+func (t *IntervalTree) IntersectingCopies(interval *Interval, copyPayload func(interface{}) interface{}) []Interval {
+	return copiesOf(t.Intersecting(interval), copyPayload)
+}
+
+// copiesOf returns the intervals by value, allocated at once, with the payloads copied by copyPayload if not nil
+func copiesOf(intervals []*Interval, copyPayload func(interface{}) interface{}) []Interval {
+	res := make([]Interval, len(intervals))
+	for i, in := range intervals {
+		res[i] = *in
+		if copyPayload != nil {
+			res[i].Payload = copyPayload(in.Payload)
+		}
+	}
+	return res
+}
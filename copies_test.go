@@ -0,0 +1,58 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Copies(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 2000, 1000, 100)
+	for _, in := range intervals {
+		in.Payload = []int{in.Start}
+	}
+	deep := func(payload interface{}) interface{} {
+		return append([]int(nil), payload.([]int)...)
+	}
+	for _, tree := range treesOf(t, intervals) {
+		for q := 0; q < 50; q++ {
+			x := rnd.Intn(1100)
+			query := &Interval{Start: x, End: x + rnd.Intn(50)}
+			want := tree.Intersecting(query)
+			copies := tree.IntersectingCopies(query, nil)
+			if len(copies) != len(want) {
+				t.Fatalf("EXPECTING %d COPIES, GOT %d", len(want), len(copies))
+			}
+			for i := range copies {
+				if copies[i].bounds() != want[i].bounds() || &copies[i].Payload.([]int)[0] != &want[i].Payload.([]int)[0] {
+					t.Fatalf("%s MUST BE A SHALLOW COPY OF %s", &copies[i], want[i])
+				}
+				copies[i].Start, copies[i].End = -10, -5
+			}
+			for i, c := range tree.IntersectingCopies(query, deep) {
+				if &c.Payload.([]int)[0] == &want[i].Payload.([]int)[0] {
+					t.Fatalf("THE PAYLOAD OF %s MUST BE COPIED", &c)
+				}
+				c.Payload.([]int)[0] = -1
+			}
+			for i, c := range tree.ContainingCopies(x, deep) {
+				c.Payload.([]int)[0] = -1
+				if c.bounds() != tree.Containing(x)[i].bounds() {
+					t.Fatalf("CONTAININGCOPIES(%d) MUST FOLLOW THE ORDER OF CONTAINING", x)
+				}
+			}
+			// the tree is unaffected by the modifications of the copies
+			checkStructure(t, tree)
+			checkQueries(t, rnd, tree, intervals, 1100)
+			for _, in := range intervals {
+				if in.Payload.([]int)[0] != in.Start {
+					t.Fatalf("THE PAYLOAD OF %s WAS MODIFIED", in)
+				}
+			}
+		}
+		if got := tree.ContainingCopies(-1, nil); len(got) != 0 {
+			t.Fatalf("EXPECTING NO COPY, GOT %v", got)
+		}
+	}
+}
@@ -0,0 +1,563 @@
+package intervaltree
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sort"
+)
+
+// -----------------------------------------------------
+// 				MERGED COVERAGE
+// -----------------------------------------------------
+
+// TotalCoveredLength returns the number of coordinates covered by at least one interval of the IntervalTree,
+// saturated at math.MaxInt. The value is maintained while the tree is built so reading it is O(1)
+func (t *IntervalTree) TotalCoveredLength() int {
+	t = t.orEmpty()
+	return t.cover.length()
+}
+
+// coverage keeps the union of all the stored intervals as disjoint runs, in a treap ordered by Start.
+// Intervals are closed on the integer grid: [3, 5] covers the coordinates 3, 4 and 5, so [1, 3] and [4, 6] are
+// adjacent and belong to the same run. Two consecutive runs are therefore always separated by at least one
+// uncovered coordinate. The runs are closed for half-open intervals too, [3, 6) giving the run [3, 5].
+// The mutations split the treap around the runs they merge or cut and join it back, copying the nodes on the
+// way that are shared with a snapshot, as the nodeTree does, so the rest of the runs stays shared
+type coverage struct {
+	top   *runNode
+	count int    // number of runs, sorted by Start, disjoint and not adjacent
+	total uint64 // number of coordinates covered by the runs, modulo 2^64, see span
+	owner *owner // see nodeTree
+}
+
+// runNode is a run of the coverage. Its priority in the treap is a hash of its Start, so the shape of the treap
+// only depends on the runs it holds
+type runNode struct {
+	start, end  int
+	left, right *runNode
+	owner       *owner // see nodeTree
+}
+
+// CoveredLength returns the number of coordinates of the window covered by at least one interval, saturated at
+// math.MaxInt. Intervals are closed so the length of [3, 5] is 3, the one of the half-open [3, 5) being 2, as
+// Interval.Length counts them. The measure is read from the merged runs, so nested intervals are never
+// enumerated. A nil, reversed or empty window covers 0 coordinates, as Intersecting returns nothing for it, unless
+// the tree was built WithSwappedQueries which swaps a reversed one
+// Output sensitive: Complexity of O(ln r + k), r = number of merged runs and k = runs intersecting the window
+func (t *IntervalTree) CoveredLength(window *Interval) int {
+	t = t.orEmpty()
+	first, last, err := t.bounds(window)
+	if err != nil {
+		return 0
+	}
+	var total uint64
+	runs := t.cover.within(first, last)
+	for _, r := range runs {
+		total += span(r.Start, r.End)
+	}
+	return measure(total, len(runs) > 0)
+}
+
+// IsCovered tells if every coordinate of the window lies in at least one interval of the IntervalTree. Intervals
+// are closed on the integer grid, so the adjacent [1, 3] and [4, 6] together cover [1, 6], as the half-open
+// [1, 4) and [4, 7) cover [1, 7). An empty half-open window is covered, a nil or reversed one is not, unless the
+// tree was built WithSwappedQueries which swaps a reversed one, see CoveredLength.
+// Complexity: O(ln r), r = number of merged runs, as the window must fit in the single run containing its Start
+func (t *IntervalTree) IsCovered(window *Interval) bool {
+	t = t.orEmpty()
+	first, last, err := t.bounds(window)
+	if err != nil {
+		return errors.Is(err, ErrEmptyInterval)
+	}
+	r := t.cover.find(func(r *runNode) bool { return r.end >= first })
+	return r != nil && r.start <= first && r.end >= last
+}
+
+// Gaps returns the maximal sub-ranges of the window covered by no interval, sorted by Start. Closed intervals leave
+// no gap between [1, 3] and [4, 6], so every returned gap holds at least one coordinate. The gaps of half-open
+// intervals are half-open: [1, 4) and [6, 9) leave the gap [4, 6). The gaps do not hold the open sides of the
+// window nor of the intervals: (1, 4) and (5, 9] leave the gap [4, 5] in a closed tree. A nil, reversed or empty
+// window has no gap, unless the tree was built WithSwappedQueries which swaps a reversed one, see CoveredLength.
+// Output sensitive: Complexity of O(ln r + k), r = number of merged runs and k = runs intersecting the window
+func (t *IntervalTree) Gaps(window *Interval) []*Interval {
+	t = t.orEmpty()
+	var res []*Interval
+	next, last, err := t.bounds(window) // next is the first coordinate not yet known to be covered or reported
+	if err != nil {
+		return res
+	}
+	for _, r := range t.cover.within(next, last) {
+		if r.Start > next {
+			res = append(res, &Interval{Start: next, End: r.Start - 1 + t.open})
+		}
+		if r.End == last {
+			return res // also prevents r.End + 1 from overflowing
+		}
+		next = r.End + 1
+	}
+	return append(res, &Interval{Start: next, End: last + t.open})
+}
+
+// MergeOverlapping returns the union of all the intervals as a minimal list of new disjoint intervals sorted by
+// Start, with nil payloads. Overlapping and adjacent intervals are merged, so [1, 3] and [4, 6] give [1, 6], as
+// the half-open [1, 4) and [4, 7) give [1, 7).
+// Complexity: O(r), r = number of merged runs
+func (t *IntervalTree) MergeOverlapping() []*Interval {
+	t = t.orEmpty()
+	res := make([]*Interval, t.cover.count)
+	for i, r := range t.Compact() {
+		res[i] = &r
+	}
+	return res
+}
+
+// Compact returns the canonical form of the IntervalTree: the union of all the intervals as values sorted by Start,
+// disjoint and not adjacent, with nil payloads. Two trees covering the same coordinates have the same compact form
+// however their intervals are fragmented.
+// Complexity: O(r), r = number of merged runs
+func (t *IntervalTree) Compact() []Interval {
+	t = t.orEmpty()
+	res := t.cover.runs()
+	for i := range res {
+		res[i].End += t.open
+	}
+	return res
+}
+
+// EqualCoverage tells if the two trees cover exactly the same coordinates, i.e. have the same Compact form if they
+// hold the same kind of intervals. The merged runs are compared directly, stopping at the first difference.
+// Complexity: O(r), r = number of merged runs
+func EqualCoverage(a, b *IntervalTree) bool {
+	a, b = a.orEmpty(), b.orEmpty()
+	return a.cover.equal(b.cover)
+}
+
+// Coalesce returns the union of all the intervals as MergeOverlapping does, every new interval carrying the payloads
+// of the intervals it merges combined pairwise by merge, in ascending order of Start then End so the result is
+// deterministic. A nil merge keeps the payload of the first interval of every run. A new interval has the open
+// sides of the intervals it starts and ends with.
+// Complexity: O(n log n), n = len(intervals in struct)
+func (t *IntervalTree) Coalesce(merge func(a, b interface{}) interface{}) []*Interval {
+	t = t.orEmpty()
+	if merge == nil {
+		merge = func(a, _ interface{}) interface{} { return a }
+	}
+	var res []*Interval
+	for _, in := range t.AllSorted(ByStart) {
+		last := len(res) - 1
+		if last >= 0 && touches(t.last(res[last]), in.first()) {
+			if t.last(in) > t.last(res[last]) {
+				res[last].End, res[last].EndOpen = in.End, in.EndOpen
+			}
+			res[last].Payload = merge(res[last].Payload, in.Payload)
+			continue
+		}
+		res = append(res, in.copyWith(in.Payload))
+	}
+	return res
+}
+
+// CoalesceTree returns a new IntervalTree holding the intervals given by Coalesce
+// Complexity: O(n log n), n = len(intervals in struct)
+func (t *IntervalTree) CoalesceTree(merge func(a, b interface{}) interface{}) *IntervalTree {
+	t = t.orEmpty()
+	return MustNewIntervalTree(t.Coalesce(merge), t.mode()) // cannot fail, the runs are valid
+}
+
+// MinStart returns the smallest Start of the stored intervals, false if the IntervalTree is empty. It is the first
+// covered coordinate, Start + 1 for an interval starting the tree with an open side
+// Complexity: O(ln r), r = number of merged runs, read from the first one
+func (t *IntervalTree) MinStart() (int, bool) {
+	t = t.orEmpty()
+	if t.cover.count == 0 {
+		return 0, false
+	}
+	return t.cover.top.first().start, true
+}
+
+// MaxEnd returns the biggest End of the stored intervals, false if the IntervalTree is empty. It is the last
+// covered coordinate, End - 1 for a closed tree ending with an open side
+// Complexity: O(ln r), r = number of merged runs, read from the last one
+func (t *IntervalTree) MaxEnd() (int, bool) {
+	t = t.orEmpty()
+	if t.cover.count == 0 {
+		return 0, false
+	}
+	return t.cover.top.last().end + t.open, true
+}
+
+// Span returns a new interval from MinStart to MaxEnd, false if the IntervalTree is empty
+func (t *IntervalTree) Span() (*Interval, bool) {
+	start, ok := t.MinStart()
+	end, _ := t.MaxEnd()
+	if !ok {
+		return nil, false
+	}
+	return &Interval{Start: start, End: end}, true
+}
+
+// newCoverage builds the merged coverage of the intervals given in parameter, open being subtracted from End to get
+// the last point of an interval
+// Build complexity: O(n log n), n = len(intervals) cause of sorting the intervals by Start
+func newCoverage(intervals []*Interval, open int) *coverage {
+	sorted := make([]Interval, len(intervals))
+	for i, in := range intervals {
+		sorted[i] = Interval{Start: in.first(), End: lastPoint(in, open)}
+	}
+	sort.Slice(
+		sorted, func(i, j int) bool {
+			return sorted[i].lessStart(&sorted[j])
+		},
+	)
+	var runs []Interval
+	for _, in := range sorted {
+		runs = appendRun(runs, in)
+	}
+	return runsOf(runs)
+}
+
+// coverageOf builds the merged coverage of the intervals from their endpoints sorted by coordinate, with a sweep
+// counting the intervals open at every coordinate
+// Build complexity: O(n), n = len(ends) / 2
+func coverageOf(ends []endpoint) *coverage {
+	var runs []Interval
+	depth := 0
+	for i := 0; i < len(ends); {
+		x, starts, stops := ends[i].x, 0, 0
+		for ; i < len(ends) && ends[i].x == x; i++ {
+			if ends[i].start {
+				starts++
+			} else {
+				stops++
+			}
+		}
+		// a run starting right after the previous one carries on with it
+		if last := len(runs) - 1; depth == 0 && starts > 0 && (last < 0 || !touches(runs[last].End, x)) {
+			runs = append(runs, Interval{Start: x})
+		}
+		if depth += starts - stops; depth == 0 {
+			runs[len(runs)-1].End = x
+		}
+	}
+	return runsOf(runs)
+}
+
+// runsOf builds the coverage holding the sorted runs, disjoint and not adjacent. The treap is built from left to
+// right keeping the stack of the nodes on its right spine, a node going under the last one of higher priority
+// Build complexity: O(r), r = len(runs)
+func runsOf(runs []Interval) *coverage {
+	c := &coverage{count: len(runs)}
+	var spine []*runNode
+	for _, r := range runs {
+		n := &runNode{start: r.Start, end: r.End}
+		c.total += span(r.Start, r.End)
+		var below *runNode
+		for len(spine) > 0 && spine[len(spine)-1].priority() < n.priority() {
+			below, spine = spine[len(spine)-1], spine[:len(spine)-1]
+		}
+		n.left = below
+		if len(spine) > 0 {
+			spine[len(spine)-1].right = n
+		}
+		spine = append(spine, n)
+	}
+	if len(spine) > 0 {
+		c.top = spine[0]
+	}
+	return c
+}
+
+// priority returns the priority of the run in the treap, a mix of the bits of its Start
+func (r *runNode) priority() uint64 {
+	z := uint64(r.start) + 0x9e3779b97f4a7c15
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+// runs returns the runs of the coverage, sorted by Start, as closed intervals
+// Complexity: O(r), r = number of runs
+func (c *coverage) runs() []Interval {
+	res := make([]Interval, 0, c.count)
+	c.each(
+		func(*runNode) bool { return true }, func(r *runNode) bool {
+			res = append(res, Interval{Start: r.start, End: r.end})
+			return true
+		},
+	)
+	return res
+}
+
+// find returns the first run for which from is true, nil if there is none. from must be false on the first runs
+// and true on the others
+// Complexity: O(ln r), r = number of runs
+func (c *coverage) find(from func(r *runNode) bool) *runNode {
+	var found *runNode
+	for n := c.top; n != nil; {
+		if from(n) {
+			found, n = n, n.left
+		} else {
+			n = n.right
+		}
+	}
+	return found
+}
+
+// each calls fn on the runs from the first one for which from is true, see find, in ascending order until fn
+// returns false. The in-order traversal keeps an explicit stack
+// Output sensitive: Complexity of O(ln r + k), r = number of runs and k = runs visited
+func (c *coverage) each(from, fn func(r *runNode) bool) {
+	var stack []*runNode
+	for n := c.top; n != nil; {
+		if from(n) {
+			stack = append(stack, n)
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fn(n) {
+			return
+		}
+		for m := n.right; m != nil; m = m.left {
+			stack = append(stack, m)
+		}
+	}
+}
+
+// first returns the first run of the subtree
+func (r *runNode) first() *runNode {
+	for r.left != nil {
+		r = r.left
+	}
+	return r
+}
+
+// last returns the last run of the subtree
+func (r *runNode) last() *runNode {
+	for r.right != nil {
+		r = r.right
+	}
+	return r
+}
+
+// all calls fn on every run of the subtree, in any order
+func (r *runNode) all(fn func(r *runNode)) {
+	for ; r != nil; r = r.right {
+		fn(r)
+		r.left.all(fn)
+	}
+}
+
+// own returns the run if the coverage may modify it in place, else a copy stamped with its owner
+func (c *coverage) own(r *runNode) *runNode {
+	if r.owner == c.owner {
+		return r
+	}
+	copied := *r
+	copied.owner = c.owner
+	return &copied
+}
+
+// split cuts the subtree into the runs before the first one for which from is true, see find, and the others.
+// The nodes on the way are owned first, the other ones are shared by both parts
+// Complexity: O(ln r), r = number of runs in the subtree
+func (c *coverage) split(r *runNode, from func(r *runNode) bool) (before, after *runNode) {
+	if r == nil {
+		return nil, nil
+	}
+	r = c.own(r)
+	if from(r) {
+		before, r.left = c.split(r.left, from)
+		return before, r
+	}
+	r.right, after = c.split(r.right, from)
+	return r, after
+}
+
+// join returns the treap holding the runs of a followed by the ones of b, all of them being before
+// Complexity: O(ln r), r = number of runs in both subtrees
+func (c *coverage) join(a, b *runNode) *runNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.priority() > b.priority() {
+		a = c.own(a)
+		a.right = c.join(a.right, b)
+		return a
+	}
+	b = c.own(b)
+	b.left = c.join(a, b.left)
+	return b
+}
+
+// splice replaces the runs between before and after by the sorted runs given, and returns the number of runs and
+// of coordinates that were between them
+func (c *coverage) splice(before, between, after *runNode, runs []Interval) (count int, spanned uint64) {
+	between.all(
+		func(r *runNode) {
+			count++
+			spanned += span(r.start, r.end)
+		},
+	)
+	for _, r := range runs {
+		before = c.join(before, &runNode{start: r.Start, end: r.End, owner: c.owner})
+		c.total += span(r.Start, r.End)
+	}
+	c.top = c.join(before, after)
+	c.count += len(runs) - count
+	c.total -= spanned
+	return count, spanned
+}
+
+// length returns the number of covered coordinates, saturated at math.MaxInt
+// Complexity: O(1)
+func (c *coverage) length() int {
+	return measure(c.total, c.count > 0)
+}
+
+// equal tells if the two coverages have the same runs
+// Complexity: O(r), r = number of runs
+func (c *coverage) equal(other *coverage) bool {
+	return c.count == other.count && c.total == other.total && slices.Equal(c.runs(), other.runs())
+}
+
+// within returns the runs intersecting [start, end], clipped to it
+// Output sensitive: Complexity of O(ln r + k), r = number of runs and k = returned runs
+func (c *coverage) within(start, end int) []Interval {
+	var res []Interval
+	c.each(
+		func(r *runNode) bool { return r.end >= start }, func(r *runNode) bool {
+			if r.start > end {
+				return false
+			}
+			res = append(res, Interval{Start: maxInt(r.start, start), End: minInt(r.end, end)})
+			return true
+		},
+	)
+	return res
+}
+
+// insert adds [start, end] to the coverage and returns the number of coordinates that were not covered before
+// Complexity: O(log r + m), r = number of runs and m = number of runs merged by the insertion
+func (c *coverage) insert(start, end int) uint64 {
+	// runs before the first one ending at or right before start, and from the first one starting after end + 1
+	before, rest := c.split(c.top, func(r *runNode) bool { return touches(r.end, start) })
+	touched, after := c.split(rest, func(r *runNode) bool { return !touches(end, r.start) })
+	if touched == nil {
+		// nothing touched, the whole interval is new coverage
+		c.splice(before, nil, after, []Interval{{Start: start, End: end}})
+		return span(start, end)
+	}
+	merged := Interval{Start: minInt(start, touched.first().start), End: maxInt(end, touched.last().end)}
+	_, spanned := c.splice(before, touched, after, []Interval{merged})
+	return span(merged.Start, merged.End) - spanned
+}
+
+// remove withdraws [start, end] from the coverage and returns the number of coordinates no longer covered.
+// remaining must hold every interval still stored that intersects [start, end]: the coverage inside [start, end]
+// becomes their union, the coverage outside is left untouched. open is subtracted from the End of the remaining
+// intervals to get their last point.
+// Complexity: O(log r + m + k log k), r = number of runs, m = runs intersecting [start, end] and k = len(remaining)
+func (c *coverage) remove(start, end int, remaining []*Interval, open int) uint64 {
+	// runs intersecting [start, end]
+	before, rest := c.split(c.top, func(r *runNode) bool { return r.end >= start })
+	cut, after := c.split(rest, func(r *runNode) bool { return r.start > end })
+	if cut == nil {
+		c.top = c.join(before, after)
+		return 0 // nothing covered in [start, end]
+	}
+	// pieces of the runs that stay covered, in ascending order
+	var pieces []Interval
+	if first := cut.first(); first.start < start {
+		pieces = append(pieces, Interval{Start: first.start, End: start - 1})
+	}
+	for _, r := range newCoverage(clipAll(remaining, start, end, open), 0).runs() {
+		pieces = appendRun(pieces, r)
+	}
+	if last := cut.last(); last.end > end {
+		pieces = appendRun(pieces, Interval{Start: end + 1, End: last.end})
+	}
+	var kept uint64
+	for _, p := range pieces {
+		kept += span(p.Start, p.End)
+	}
+	_, spanned := c.splice(before, cut, after, pieces)
+	return spanned - kept
+}
+
+// appendRun appends r to the sorted runs, merging it with the last run when they touch
+func appendRun(runs []Interval, r Interval) []Interval {
+	last := len(runs) - 1
+	if last >= 0 && touches(runs[last].End, r.Start) {
+		runs[last].End = maxInt(runs[last].End, r.End)
+		return runs
+	}
+	return append(runs, r)
+}
+
+// clipAll returns closed copies of the intervals intersecting [start, end], clipped to it, open being subtracted
+// from End to get the last point of an interval
+func clipAll(intervals []*Interval, start, end, open int) []*Interval {
+	var res []*Interval
+	for _, in := range intervals {
+		if lastPoint(in, open) < start || in.first() > end {
+			continue
+		}
+		res = append(res, &Interval{Start: maxInt(in.first(), start), End: minInt(lastPoint(in, open), end)})
+	}
+	return res
+}
+
+// touches tells if a run ending at end and a run starting at start overlap or are adjacent
+// PRE: the first run does not start after start
+func touches(end, start int) bool {
+	return end >= start || (start != math.MinInt && end == start-1)
+}
+
+// span returns the number of coordinates in [start, end] modulo 2^64, 0 for the full int range. The counts of the
+// coverage wrap around the same way so adding and subtracting spans stays exact, see measure
+// PRE: start <= end
+func span(start, end int) uint64 {
+	return uint64(end) - uint64(start) + 1
+}
+
+// measure returns the number of coordinates n counted with span as an int saturated at math.MaxInt, covered telling
+// if at least one coordinate is counted: a count of 0 is then the 2^64 coordinates of the full int range
+func measure(n uint64, covered bool) int {
+	if n == 0 && covered {
+		return math.MaxInt
+	}
+	return saturated(n)
+}
+
+// saturated returns the count n as an int, math.MaxInt if it does not fit
+func saturated(n uint64) int {
+	if n > math.MaxInt {
+		return math.MaxInt
+	}
+	return int(n)
+}
+
+// minInt returns the smallest of a and b
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt returns the biggest of a and b
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,400 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// bruteCoveredLength counts the coordinates covered by at least one interval, one by one
+func bruteCoveredLength(intervals []*Interval) int {
+	return len(bruteCover(intervals))
+}
+
+// randomIntervals generates n intervals with bounds in [0, maxCoord]
+func randomIntervals(rnd *rand.Rand, n, maxCoord, maxLength int) []*Interval {
+	intervals := make([]*Interval, n)
+	for i := range intervals {
+		start := rnd.Intn(maxCoord)
+		intervals[i] = &Interval{Start: start, End: start + rnd.Intn(maxLength)}
+	}
+	return intervals
+}
+
+func TestIntervalTree_TotalCoveredLength(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(50), 500, 40)
+		tree := MustNewIntervalTree(intervals)
+		if got, want := tree.TotalCoveredLength(), bruteCoveredLength(intervals); got != want {
+			t.Fatalf("EXPECTING %d COVERED, GOT %d", want, got)
+		}
+	}
+	// adjacent closed intervals form a single run
+	tree := MustNewIntervalTree([]*Interval{{Start: 1, End: 3}, {Start: 4, End: 6}, {Start: 8, End: 8}})
+	if tree.TotalCoveredLength() != 7 || tree.cover.count != 2 {
+		t.Fatalf("EXPECTING 7 COVERED IN 2 RUNS, GOT %d IN %v", tree.TotalCoveredLength(), tree.cover.runs())
+	}
+}
+
+func TestCoverage_Incremental(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		stored := randomIntervals(rnd, rnd.Intn(20), 300, 30)
+		c := newCoverage(stored, 0)
+		for op := 0; op < 200; op++ {
+			if len(stored) == 0 || rnd.Intn(2) == 0 {
+				in := randomIntervals(rnd, 1, 300, 30)[0]
+				before := c.total
+				gained := c.insert(in.Start, in.End)
+				stored = append(stored, in)
+				if c.total != before+gained {
+					t.Fatalf("INSERT GAINED %d BUT TOTAL MOVED FROM %d TO %d", gained, before, c.total)
+				}
+			} else {
+				k := rnd.Intn(len(stored))
+				in := stored[k]
+				stored = append(stored[:k], stored[k+1:]...)
+				c.remove(in.Start, in.End, stored, 0)
+			}
+			fresh := newCoverage(stored, 0)
+			if !c.equal(fresh) || len(c.runs()) != c.count {
+				t.Fatalf("INCREMENTAL %v (%d) DIFFERS FROM RECOMPUTED %v (%d)", c.runs(), c.total, fresh.runs(), fresh.total)
+			}
+			if want := bruteCoveredLength(stored); c.length() != want {
+				t.Fatalf("EXPECTING %d COVERED, GOT %d", want, c.length())
+			}
+		}
+	}
+}
+
+func TestIntervalTree_CoveredLength(t *testing.T) {
+	// deeply nested stack: [i, 1000 - i] for i in [0, 499] covers [0, 1000]
+	var nested []*Interval
+	for i := 0; i < 500; i++ {
+		nested = append(nested, &Interval{Start: i, End: 1000 - i})
+	}
+	tree := MustNewIntervalTree(nested)
+	if got := tree.CoveredLength(&Interval{Start: -50, End: 2000}); got != 1001 {
+		t.Fatalf("NESTED: EXPECTING 1001, GOT %d", got)
+	}
+	if got := tree.CoveredLength(&Interval{Start: 3, End: 5}); got != 3 {
+		t.Fatalf("CLOSED SEMANTICS: EXPECTING 3, GOT %d", got)
+	}
+	// thousands of disjoint unit intervals: [2i, 2i]
+	var disjoint []*Interval
+	for i := 0; i < 5000; i++ {
+		disjoint = append(disjoint, &Interval{Start: 2 * i, End: 2 * i})
+	}
+	tree = MustNewIntervalTree(disjoint)
+	if got := tree.CoveredLength(&Interval{Start: 0, End: 9999}); got != 5000 {
+		t.Fatalf("DISJOINT: EXPECTING 5000, GOT %d", got)
+	}
+	if got := tree.CoveredLength(&Interval{Start: 1, End: 9}); got != 4 {
+		t.Fatalf("DISJOINT WINDOW: EXPECTING 4, GOT %d", got)
+	}
+	if got := MustNewIntervalTree(nil).CoveredLength(&Interval{Start: 0, End: 10}); got != 0 {
+		t.Fatalf("EMPTY: EXPECTING 0, GOT %d", got)
+	}
+	// the window is normalized as an Intersecting query
+	single := []*Interval{{Start: 0, End: 20}}
+	tree = MustNewIntervalTree(single)
+	if tree.CoveredLength(nil) != 0 || tree.CoveredLength(&Interval{Start: 10, End: 5}) != 0 {
+		t.Fatalf("A NIL OR REVERSED WINDOW MUST COVER 0")
+	}
+	if got := tree.CoveredLength(&Interval{Start: 5, End: 5, EndOpen: true}); got != 0 {
+		t.Fatalf("AN EMPTY WINDOW MUST COVER 0, GOT %d", got)
+	}
+	if got := MustNewIntervalTree(single, WithSwappedQueries()).CoveredLength(&Interval{Start: 10, End: 5}); got != 6 {
+		t.Fatalf("A SWAPPED WINDOW MUST COVER [5, 10]: EXPECTING 6, GOT %d", got)
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(50), 500, 40)
+		start := rnd.Intn(600) - 50
+		window := &Interval{Start: start, End: start + rnd.Intn(200)}
+		want := bruteCoveredLength(clipAll(intervals, window.Start, window.End, 0))
+		if got := MustNewIntervalTree(intervals).CoveredLength(window); got != want {
+			t.Fatalf("EXPECTING %d COVERED IN %s, GOT %d", want, window, got)
+		}
+	}
+}
+
+func TestIntervalTree_IsCovered(t *testing.T) {
+	chain := MustNewIntervalTree(
+		[]*Interval{{Start: 0, End: 3}, {Start: 4, End: 7}, {Start: 7, End: 9}, {Start: 10, End: 10}, {Start: 11, End: 20}},
+	)
+	split := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 20, End: 30}})
+	swapped := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 20, End: 30}}, WithSwappedQueries())
+	tests := []struct {
+		name   string
+		tree   *IntervalTree
+		window *Interval
+		want   bool
+	}{
+		{"TOUCHING CHAIN", chain, &Interval{Start: 0, End: 20}, true},
+		{"INSIDE CHAIN", chain, &Interval{Start: 3, End: 11}, true},
+		{"BEYOND CHAIN END", chain, &Interval{Start: 15, End: 21}, false},
+		{"BEFORE CHAIN START", chain, &Interval{Start: -1, End: 5}, false},
+		{"ONE UNIT HOLE", MustNewIntervalTree([]*Interval{{Start: 0, End: 4}, {Start: 6, End: 10}}), &Interval{Start: 0, End: 10}, false},
+		{"AROUND HOLE", MustNewIntervalTree([]*Interval{{Start: 0, End: 4}, {Start: 6, End: 10}}), &Interval{Start: 6, End: 10}, true},
+		{"SINGLE POINT", MustNewIntervalTree([]*Interval{{Start: 5, End: 5}}), &Interval{Start: 5, End: 5}, true},
+		{"EMPTY TREE", MustNewIntervalTree(nil), &Interval{Start: 0, End: 0}, false},
+		{"NIL WINDOW", chain, nil, false},
+		{"NIL WINDOW OF EMPTY TREE", MustNewIntervalTree(nil), nil, false},
+		{"REVERSED WINDOW", split, &Interval{Start: 25, End: 3}, false},
+		{"REVERSED COVERED WINDOW", split, &Interval{Start: 5, End: 1}, false},
+		{"SWAPPED WINDOW", swapped, &Interval{Start: 25, End: 3}, false},
+		{"SWAPPED COVERED WINDOW", swapped, &Interval{Start: 5, End: 1}, true},
+		{"EMPTY WINDOW", MustNewIntervalTree(nil, WithHalfOpenIntervals()), &Interval{Start: 4, End: 4}, true},
+	}
+	for _, test := range tests {
+		if got := test.tree.IsCovered(test.window); got != test.want {
+			t.Errorf("%s: EXPECTING %t FOR %s, GOT %t", test.name, test.want, test.window, got)
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 300; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(30), 300, 40)
+		start := rnd.Intn(300)
+		window := &Interval{Start: start, End: start + rnd.Intn(60)}
+		want := bruteCoveredLength(clipAll(intervals, window.Start, window.End, 0)) == window.End-window.Start+1
+		if got := MustNewIntervalTree(intervals).IsCovered(window); got != want {
+			t.Fatalf("EXPECTING %t FOR %s, GOT %t", want, window, got)
+		}
+	}
+}
+
+func TestIntervalTree_Gaps(t *testing.T) {
+	tree := MustNewIntervalTree([]*Interval{{Start: 0, End: 3}, {Start: 4, End: 7}, {Start: 10, End: 12}, {Start: 20, End: 25}})
+	split := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 20, End: 30}})
+	swapped := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 20, End: 30}}, WithSwappedQueries())
+	tests := []struct {
+		name   string
+		tree   *IntervalTree
+		window *Interval
+		want   []Interval
+	}{
+		{"INSIDE COVERED", tree, &Interval{Start: 1, End: 22}, []Interval{{Start: 8, End: 9}, {Start: 13, End: 19}}},
+		{"AROUND", tree, &Interval{Start: -2, End: 27}, []Interval{{Start: -2, End: -1}, {Start: 8, End: 9}, {Start: 13, End: 19}, {Start: 26, End: 27}}},
+		{"ADJACENT LEAVE NO GAP", tree, &Interval{Start: 0, End: 7}, nil},
+		{"INSIDE GAP", tree, &Interval{Start: 14, End: 16}, []Interval{{Start: 14, End: 16}}},
+		{"EMPTY TREE", MustNewIntervalTree(nil), &Interval{Start: 3, End: 8}, []Interval{{Start: 3, End: 8}}},
+		{"NIL WINDOW", tree, nil, nil},
+		{"NIL WINDOW OF EMPTY TREE", MustNewIntervalTree(nil), nil, nil},
+		{"REVERSED WINDOW", split, &Interval{Start: 25, End: 3}, nil},
+		{"REVERSED WINDOW OF EMPTY TREE", MustNewIntervalTree(nil), &Interval{Start: 9, End: 1}, nil},
+		{"SWAPPED WINDOW", swapped, &Interval{Start: 25, End: 3}, []Interval{{Start: 6, End: 19}}},
+		{"STRAIGHT WINDOW", swapped, &Interval{Start: 3, End: 25}, []Interval{{Start: 6, End: 19}}},
+	}
+	for _, test := range tests {
+		got := test.tree.Gaps(test.window)
+		if len(got) != len(test.want) {
+			t.Fatalf("%s: EXPECTING %v, GOT %v", test.name, test.want, got)
+		}
+		for i := range got {
+			if *got[i] != test.want[i] {
+				t.Fatalf("%s: EXPECTING %v, GOT %v", test.name, test.want, got)
+			}
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 300; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(30), 300, 20)
+		tree := MustNewIntervalTree(intervals)
+		start := rnd.Intn(300)
+		window := &Interval{Start: start, End: start + rnd.Intn(80)}
+		gaps := tree.Gaps(window)
+		uncovered := 0
+		for k, gap := range gaps {
+			if k > 0 && gap.Start <= gaps[k-1].End+1 {
+				t.Fatalf("GAPS %v ARE NOT SORTED AND MAXIMAL", gaps)
+			}
+			if len(tree.Intersecting(gap)) != 0 {
+				t.Fatalf("GAP %s INTERSECTS STORED INTERVALS", gap)
+			}
+			uncovered += gap.End - gap.Start + 1
+		}
+		if uncovered+tree.CoveredLength(window) != window.End-window.Start+1 {
+			t.Fatalf("GAPS %v AND COVERAGE DO NOT ADD UP TO %s", gaps, window)
+		}
+	}
+}
+
+func TestIntervalTree_MergeOverlapping(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []*Interval
+		want      []Interval
+	}{
+		{"NESTED", []*Interval{{Start: 0, End: 10}, {Start: 2, End: 8}, {Start: 4, End: 6}}, []Interval{{Start: 0, End: 10}}},
+		{"TOUCHING CHAIN", []*Interval{{Start: 7, End: 9}, {Start: 0, End: 3}, {Start: 3, End: 6}, {Start: 12, End: 13}}, []Interval{{Start: 0, End: 9}, {Start: 12, End: 13}}},
+		{"DUPLICATES", []*Interval{{Start: 1, End: 2, Payload: "a"}, {Start: 1, End: 2, Payload: "b"}, {Start: 5, End: 5}}, []Interval{{Start: 1, End: 2}, {Start: 5, End: 5}}},
+		{"EMPTY", nil, nil},
+	}
+	for _, test := range tests {
+		tree := MustNewIntervalTree(test.intervals)
+		got := tree.MergeOverlapping()
+		if len(got) != len(test.want) {
+			t.Fatalf("%s: EXPECTING %v, GOT %v", test.name, test.want, got)
+		}
+		for i := range got {
+			if *got[i] != test.want[i] {
+				t.Fatalf("%s: EXPECTING %v, GOT %v", test.name, test.want, got)
+			}
+		}
+		// the result is a copy
+		for _, in := range got {
+			in.End += 100
+		}
+		if len(got) > 0 && tree.cover.runs()[0] != test.want[0] {
+			t.Fatalf("%s: MERGEOVERLAPPING EXPOSES THE INTERNAL RUNS", test.name)
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(40), 400, 20)
+		merged := MustNewIntervalTree(intervals).MergeOverlapping()
+		for k := 1; k < len(merged); k++ {
+			if merged[k].Start <= merged[k-1].End+1 {
+				t.Fatalf("%s AND %s OVERLAP OR TOUCH", merged[k-1], merged[k])
+			}
+		}
+		if !reflect.DeepEqual(bruteCover(merged), bruteCover(intervals)) {
+			t.Fatalf("MERGED INTERVALS %v DO NOT COVER THE SAME POINTS AS THE INPUT", merged)
+		}
+	}
+}
+
+func TestIntervalTree_Compact(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(40), 400, 20)
+		// the same coverage fragmented differently: every interval cut into random touching or overlapping pieces
+		var fragments []*Interval
+		for _, in := range intervals {
+			for start := in.Start; start <= in.End; {
+				end := minInt(in.End, start+rnd.Intn(5))
+				fragments = append(fragments, &Interval{Start: start, End: end})
+				next := end + 1
+				if end > start && rnd.Intn(2) == 0 {
+					next = end // overlapping pieces
+				}
+				start = next
+			}
+		}
+		a, b := MustNewIntervalTree(intervals), MustNewIntervalTree(fragments)
+		if !EqualCoverage(a, b) || !reflect.DeepEqual(a.Compact(), b.Compact()) {
+			t.Fatalf("%v AND %v HAVE THE SAME COVERAGE", intervals, fragments)
+		}
+		compact := a.Compact()
+		for k := 1; k < len(compact); k++ {
+			if compact[k].Start <= compact[k-1].End+1 {
+				t.Fatalf("%v AND %v OVERLAP OR TOUCH", compact[k-1], compact[k])
+			}
+		}
+		var merged []*Interval
+		for k := range compact {
+			merged = append(merged, &compact[k])
+		}
+		if !reflect.DeepEqual(bruteCover(merged), bruteCover(intervals)) {
+			t.Fatalf("COMPACT FORM %v DOES NOT COVER THE SAME POINTS AS THE INPUT", compact)
+		}
+		// one more coordinate makes them differ
+		x := rnd.Intn(500)
+		if err := b.Insert(&Interval{Start: x, End: x}); err != nil {
+			t.Fatalf("INSERT: %v", err)
+		}
+		if EqualCoverage(a, b) == !bruteCover(merged)[x] {
+			t.Fatalf("EQUALCOVERAGE AFTER COVERING %d: EXPECTING %t", x, bruteCover(merged)[x])
+		}
+	}
+}
+
+func TestIntervalTree_Coalesce(t *testing.T) {
+	concat := func(a, b interface{}) interface{} { return a.(string) + b.(string) }
+	tests := []struct {
+		name      string
+		intervals []*Interval
+		merge     func(a, b interface{}) interface{}
+		want      []Interval
+	}{
+		{"IDENTICAL", []*Interval{{Start: 1, End: 2, Payload: "a"}, {Start: 1, End: 2, Payload: "b"}}, concat, []Interval{{Start: 1, End: 2, Payload: "ab"}}},
+		{"NESTED", []*Interval{{Start: 2, End: 8, Payload: "b"}, {Start: 0, End: 10, Payload: "a"}, {Start: 4, End: 6, Payload: "c"}}, concat, []Interval{{Start: 0, End: 10, Payload: "abc"}}},
+		{"TOUCHING", []*Interval{{Start: 4, End: 6, Payload: "b"}, {Start: 0, End: 3, Payload: "a"}, {Start: 8, End: 9, Payload: "c"}}, concat, []Interval{{Start: 0, End: 6, Payload: "ab"}, {Start: 8, End: 9, Payload: "c"}}},
+		{"SAME START", []*Interval{{Start: 0, End: 5, Payload: "b"}, {Start: 0, End: 1, Payload: "a"}}, concat, []Interval{{Start: 0, End: 5, Payload: "ab"}}},
+		{"NIL MERGE", []*Interval{{Start: 3, End: 5, Payload: "b"}, {Start: 0, End: 4, Payload: "a"}}, nil, []Interval{{Start: 0, End: 5, Payload: "a"}}},
+		{"EMPTY", nil, concat, nil},
+	}
+	for _, test := range tests {
+		tree := MustNewIntervalTree(test.intervals)
+		got := tree.Coalesce(test.merge)
+		if len(got) != len(test.want) {
+			t.Fatalf("%s: EXPECTING %v, GOT %v", test.name, test.want, got)
+		}
+		for i := range got {
+			if *got[i] != test.want[i] {
+				t.Fatalf("%s: EXPECTING %v (%v), GOT %v (%v)", test.name, test.want[i], test.want[i].Payload, got[i], got[i].Payload)
+			}
+		}
+		coalesced := tree.CoalesceTree(test.merge)
+		checkStructure(t, coalesced)
+		if coalesced.Len() != len(test.want) {
+			t.Fatalf("%s: EXPECTING %d INTERVALS, GOT %d", test.name, len(test.want), coalesced.Len())
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 500, 2000, 20)
+	for _, in := range intervals {
+		in.Payload = 1
+	}
+	tree := MustNewIntervalTree(intervals)
+	coalesced := tree.Coalesce(func(a, b interface{}) interface{} { return a.(int) + b.(int) })
+	merged := tree.MergeOverlapping()
+	total := 0
+	for i, in := range coalesced {
+		if in.Start != merged[i].Start || in.End != merged[i].End {
+			t.Fatalf("EXPECTING THE RUNS OF MERGEOVERLAPPING, GOT %s INSTEAD OF %s", in, merged[i])
+		}
+		total += in.Payload.(int)
+	}
+	if total != len(intervals) {
+		t.Fatalf("EXPECTING %d MERGED PAYLOADS, GOT %d", len(intervals), total)
+	}
+}
+
+// bruteCover returns the set of coordinates covered by the intervals
+func bruteCover(intervals []*Interval) map[int]bool {
+	covered := make(map[int]bool)
+	for _, in := range intervals {
+		for x := in.Start; x <= in.End; x++ {
+			covered[x] = true
+		}
+	}
+	return covered
+}
+
+func TestIntervalTree_Span(t *testing.T) {
+	tree := MustNewIntervalTree([]*Interval{{Start: -50, End: -20}, {Start: -30, End: -25}, {Start: -10, End: -3}})
+	if start, ok := tree.MinStart(); !ok || start != -50 {
+		t.Fatalf("EXPECTING MINSTART -50, GOT %d (%t)", start, ok)
+	}
+	if end, ok := tree.MaxEnd(); !ok || end != -3 {
+		t.Fatalf("EXPECTING MAXEND -3, GOT %d (%t)", end, ok)
+	}
+	if span, ok := MustNewIntervalTree([]*Interval{{Start: 7, End: 7}}).Span(); !ok || *span != (Interval{Start: 7, End: 7}) {
+		t.Fatalf("EXPECTING SPAN [ 7 - 7 ], GOT %v (%t)", span, ok)
+	}
+	empty := MustNewIntervalTree(nil)
+	_, okStart := empty.MinStart()
+	_, okEnd := empty.MaxEnd()
+	if span, ok := empty.Span(); ok || okStart || okEnd || span != nil {
+		t.Fatalf("AN EMPTY TREE HAS NO BOUNDS")
+	}
+}
@@ -0,0 +1,113 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// tiedElt returns a node at xMid 50 holding n intervals sharing a few endpoints around xMid, with random flags, so
+// that most cutoffs fall in the middle of a run of ties
+func tiedElt(rnd *rand.Rand, n, open int) *elt {
+	starts, ends := []int{10, 20, 30, 49, 50}, []int{50 + open, 51 + open, 60, 70, 90}
+	intervals := make([]*Interval, n)
+	for i := range intervals {
+		in := &Interval{Start: starts[rnd.Intn(len(starts))], End: ends[rnd.Intn(len(ends))]}
+		in.StartOpen, in.EndOpen = in.Start < 50 && rnd.Intn(2) == 0, in.End > 50+open && rnd.Intn(2) == 0
+		intervals[i] = in
+	}
+	return newElt(intervals, 50, nil)
+}
+
+func TestElt_Cutoffs(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		open := i % 2
+		e := tiedElt(rnd, rnd.Intn(300), open)
+		for x := 0; x <= 100; x++ {
+			want := 0
+			e.stab(
+				x, open, func(in *Interval) bool {
+					want++
+					return true
+				},
+			)
+			byEnd, n := e.containing(x, open)
+			if n != want || byEnd != (x > e.xMid) {
+				t.Fatalf("%d: EXPECTING THE CUTOFF %d OF STAB, GOT %d (BY END: %v)", x, want, n, byEnd)
+			}
+			for end := x; end <= 100; end += 1 + rnd.Intn(10) {
+				want := 0
+				for _, in := range e.leftSorted {
+					if in.first() > x && in.first() <= end {
+						want++
+					}
+				}
+				if from, to := e.starting(x, end); to-from != want {
+					t.Fatalf("(%d, %d]: EXPECTING %d INTERVALS STARTING IN IT, GOT %d", x, end, want, to-from)
+				}
+			}
+		}
+	}
+}
+
+func TestIntervalTree_CountingQueries(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		// few distinct endpoints, so that the nodes hold long runs of ties
+		intervals, mode := randomFlagged(rnd, rnd.Intn(2000), 100, 1+rnd.Intn(20)), []Option(nil)
+		if i%2 == 1 {
+			intervals, mode = randomHalfOpen(rnd, rnd.Intn(2000), 100, 1+rnd.Intn(20)), []Option{WithHalfOpenIntervals()}
+		}
+		for _, tree := range append(treesOf(t, intervals, mode...), MustNewIntervalTree(intervals[:len(intervals)/100], mode...)) {
+			for x := -10; x < 130; x++ {
+				want := len(tree.Containing(x))
+				if got := tree.CountContaining(x); got != want {
+					t.Fatalf("%d: EXPECTING A COUNT OF %d, GOT %d", x, want, got)
+				}
+				if got := tree.AnyContaining(x); got != (want > 0) {
+					t.Fatalf("%d: EXPECTING ANY CONTAINING: %v, GOT %v", x, want > 0, got)
+				}
+				window := &Interval{Start: x, End: x + rnd.Intn(30)}
+				if got, want := tree.EstimateResultCount(window), len(tree.Intersecting(window)); got != want {
+					t.Fatalf("%s: EXPECTING AN ESTIMATE OF %d, GOT %d", window, want, got)
+				}
+			}
+		}
+	}
+	var nilTree *IntervalTree
+	if nilTree.AnyContaining(0) || MustNewIntervalTree(nil).AnyContaining(0) {
+		t.Fatalf("AN EMPTY TREE MUST CONTAIN NOTHING")
+	}
+}
+
+// benchmarkCountContaining counts the tens of thousands of intervals containing points of a large tree
+func benchmarkCountContaining(b *testing.B, count func(tree *IntervalTree, x int) int) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 1_000_000, 10_000_000, 1_000_000))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count(tree, 1_000_000+rnd.Intn(8_000_000))
+	}
+}
+
+func BenchmarkCountContaining(b *testing.B) {
+	benchmarkCountContaining(b, (*IntervalTree).CountContaining)
+}
+
+// BenchmarkCountContaining_Stab visits the intervals to count them, as CountContaining did
+func BenchmarkCountContaining_Stab(b *testing.B) {
+	benchmarkCountContaining(
+		b, func(tree *IntervalTree, x int) int {
+			total := 0
+			stab(
+				tree.nodes().root(), x, tree.open, func(*Interval) bool {
+					total++
+					return true
+				},
+			)
+			return total
+		},
+	)
+}
@@ -0,0 +1,90 @@
+package intervaltree
+
+// -----------------------------------------------------
+// 				DEDUPLICATION
+// -----------------------------------------------------
+
+// DeduplicateExact collapses the stored intervals having the same endpoints, and equal under the function given to
+// WithDeduplication if any, into a single representative: the first one in the order of the node holding them,
+// which is the input order for a tree built at once. It returns the number of removed intervals.
+// Complexity: O(n + r (ln n + k)), as DeleteWhere, n = len(intervals in struct) and r = removed intervals
+func (t *IntervalTree) DeduplicateExact() int {
+	t = t.orEmpty()
+	victims := make(map[*Interval]bool)
+	walk(
+		t.nodes(), func(e *elt) bool {
+			// the intervals with the same endpoints are next to each other in the list sorted by Start
+			for i := 0; i < len(e.leftSorted); {
+				j := i + 1
+				for j < len(e.leftSorted) && e.leftSorted[j].bounds() == e.leftSorted[i].bounds() {
+					j++
+				}
+				kept := deduplicate(e.leftSorted[i:j], t.equal)
+				for k, in := range e.leftSorted[i:j] {
+					if len(kept) > 0 && kept[0] == k {
+						kept = kept[1:]
+						continue
+					}
+					victims[in] = true
+				}
+				i = j
+			}
+			return true
+		},
+	)
+	if len(victims) == 0 {
+		return 0
+	}
+	return t.DeleteWhere(func(in *Interval) bool { return victims[in] })
+}
+
+// deduplicate returns in ascending order the positions of the intervals having no equal before them: same
+// endpoints and, if eq is not nil, equal under eq
+// Complexity: O(n g), n = len(intervals) and g = number of distinct intervals with the same endpoints
+func deduplicate(intervals []*Interval, eq func(a, b *Interval) bool) []int {
+	kept := make([]int, 0, len(intervals))
+	groups := make(map[bounds][]*Interval) // distinct intervals by endpoints
+	for i, in := range intervals {
+		key := in.bounds()
+		duplicate := false
+		for _, other := range groups[key] {
+			if eq == nil || eq(other, in) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			groups[key] = append(groups[key], in)
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// distinctPointers returns in ascending order the positions of the first occurrence of every *Interval, nil if none
+// is repeated. seen is cleared and used to record the intervals met, a new map being allocated if it is nil
+// Complexity: O(n), n = len(intervals)
+func distinctPointers(intervals []*Interval, seen map[*Interval]struct{}) []int {
+	if seen == nil {
+		seen = make(map[*Interval]struct{}, len(intervals))
+	}
+	clear(seen)
+	defer clear(seen)
+	var kept []int
+	for i, in := range intervals {
+		if _, ok := seen[in]; ok {
+			if kept == nil {
+				kept = make([]int, i, len(intervals))
+				for k := range kept {
+					kept[k] = k
+				}
+			}
+			continue
+		}
+		seen[in] = struct{}{}
+		if kept != nil {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,107 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Deduplication(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	// heavy duplication: few distinct endpoints and payloads
+	var intervals []*Interval
+	distinct := make(map[[2]int]bool)
+	distinctPayload := make(map[[3]int]bool)
+	for i := 0; i < 2000; i++ {
+		start := rnd.Intn(50)
+		in := &Interval{Start: start, End: start + rnd.Intn(10), Payload: rnd.Intn(3)}
+		intervals = append(intervals, in)
+		distinct[[2]int{in.Start, in.End}] = true
+		distinctPayload[[3]int{in.Start, in.End, in.Payload.(int)}] = true
+	}
+	samePayload := func(a, b *Interval) bool { return a.Payload == b.Payload }
+	tests := []struct {
+		name  string
+		eq    func(a, b *Interval) bool
+		count int
+	}{
+		{"ENDPOINTS", nil, len(distinct)},
+		{"PAYLOADS", samePayload, len(distinctPayload)},
+	}
+	for _, test := range tests {
+		tree := MustNewIntervalTree(intervals, WithDeduplication(test.eq), WithSequenceNumbers())
+		checkStructure(t, tree)
+		if tree.Len() != test.count {
+			t.Fatalf("%s: EXPECTING %d DISTINCT INTERVALS, GOT %d", test.name, test.count, tree.Len())
+		}
+		// the first occurrence is kept, with its position in the input
+		for _, in := range tree.All() {
+			seq, _ := tree.SeqOf(in)
+			for _, other := range intervals[:seq] {
+				if other.Start == in.Start && other.End == in.End && (test.eq == nil || test.eq(other, in)) {
+					t.Fatalf("%s: %s IS NOT THE FIRST OCCURRENCE", test.name, in)
+				}
+			}
+		}
+
+		// the same on a tree built with the duplicates
+		full := MustNewIntervalTree(intervals)
+		full.equal = test.eq
+		if removed := full.DeduplicateExact(); removed != len(intervals)-test.count {
+			t.Fatalf("%s: EXPECTING %d REMOVED INTERVALS, GOT %d", test.name, len(intervals)-test.count, removed)
+		}
+		checkStructure(t, full)
+		if full.DeduplicateExact() != 0 {
+			t.Fatalf("%s: A DEDUPLICATED TREE HAS NO DUPLICATE", test.name)
+		}
+		original := MustNewIntervalTree(intervals)
+		for x := -1; x < 61; x++ {
+			want := make(map[[3]int]bool)
+			for _, in := range original.Containing(x) {
+				if test.eq == nil {
+					want[[3]int{in.Start, in.End}] = true
+				} else {
+					want[[3]int{in.Start, in.End, in.Payload.(int)}] = true
+				}
+			}
+			if got := len(tree.Containing(x)); got != len(want) {
+				t.Fatalf("%s: CONTAINING(%d): EXPECTING %d REPRESENTATIVES, GOT %d", test.name, x, len(want), got)
+			}
+			if got := len(full.Intersecting(&Interval{Start: x, End: x})); got != len(want) {
+				t.Fatalf("%s: INTERSECTING(%d): EXPECTING %d REPRESENTATIVES, GOT %d", test.name, x, len(want), got)
+			}
+		}
+	}
+}
+
+func TestIntervalTree_WithEquality(t *testing.T) {
+	type record struct {
+		id    int
+		label string
+	}
+	sameID := func(a, b *Interval) bool { return a.Payload.(record).id == b.Payload.(record).id }
+	intervals := []*Interval{
+		{Start: 0, End: 10, Payload: record{1, "a"}},
+		{Start: 0, End: 10, Payload: record{2, "a"}},
+		{Start: 0, End: 10, Payload: record{1, "b"}}, // same id as the first: a true duplicate
+		{Start: 0, End: 11, Payload: record{1, "a"}}, // other endpoints
+		{Start: 5, End: 6, Payload: record{3, "c"}},
+		{Start: 5, End: 6, Payload: record{3, "d"}},
+		{Start: 5, End: 6, Payload: record{4, "c"}},
+		{Start: 5, End: 6, Payload: record{3, "e"}},
+	}
+	tree := MustNewIntervalTree(intervals, WithEquality(sameID))
+	checkStructure(t, tree)
+	want := []*Interval{intervals[0], intervals[1], intervals[3], intervals[4], intervals[6]}
+	if !sameIntervals(tree.All(), want) {
+		t.Fatalf("EXPECTING %v, GOT %v", want, tree.All())
+	}
+	// the equality is kept for the intervals inserted afterwards
+	_ = tree.Insert(&Interval{Start: 5, End: 6, Payload: record{4, "f"}})
+	if removed := tree.DeduplicateExact(); removed != 1 || tree.Len() != len(want) {
+		t.Fatalf("EXPECTING THE INSERTED DUPLICATE TO BE REMOVED, GOT %d REMOVED AND %d INTERVALS", removed, tree.Len())
+	}
+	if MustNewIntervalTree(intervals, WithEquality(nil)).Len() != len(intervals) {
+		t.Fatalf("A NIL EQUALITY MUST KEEP EVERY INTERVAL")
+	}
+}
@@ -0,0 +1,229 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fromEndpointsRecursive is fromEndpoints building every subtree by a recursive call, the reference of the
+// iterative construction
+func fromEndpointsRecursive(ends, buf []endpoint, mid *[]*Interval, tie func(a, b *Interval) bool, open int) *nodeTree {
+	tree := &nodeTree{}
+	if len(ends) == 0 {
+		return tree
+	}
+	xMid := ends[len(ends)/2].x
+	*mid = (*mid)[:0]
+	left, right := 0, 0
+	for _, end := range ends {
+		switch {
+		case lastPoint(end.in, open) < xMid:
+			left++
+		case end.in.first() > xMid:
+			right++
+		}
+	}
+	l, r := 0, len(buf)-right
+	for _, end := range ends {
+		switch {
+		case lastPoint(end.in, open) < xMid:
+			buf[l] = end
+			l++
+		case end.in.first() > xMid:
+			buf[r] = end
+			r++
+		case end.start:
+			*mid = append(*mid, end.in)
+		}
+	}
+	itr := tree.root()
+	itr.insert(newElt(*mid, xMid, tie))
+	paste(itr.left(), fromEndpointsRecursive(buf[:left], ends[:left], mid, tie, open))
+	paste(itr.right(), fromEndpointsRecursive(buf[len(buf)-right:], ends[len(ends)-right:], mid, tie, open))
+	return tree
+}
+
+// stabRecursive is stab following the path by a recursive call
+func stabRecursive(itr *iterator, x, open int, fn func(*Interval) bool) bool {
+	if itr.isBottom() {
+		return true
+	}
+	e := itr.consult()
+	if !e.stab(x, open, fn) {
+		return false
+	}
+	if x > e.xMid {
+		return stabRecursive(itr.right(), x, open, fn)
+	} else if x < e.xMid {
+		return stabRecursive(itr.left(), x, open, fn)
+	}
+	return true
+}
+
+// startingInRecursive is startingIn traversing the subtrees by recursive calls
+func startingInRecursive(itr *iterator, start, end int, fn func(*Interval) bool) bool {
+	if itr.isBottom() {
+		return true
+	}
+	e := itr.consult()
+	if e.xMid > start {
+		if !startingInRecursive(itr.left(), start, end, fn) {
+			return false
+		}
+		for _, in := range e.leftSorted {
+			if in.first() > end {
+				break
+			}
+			if in.first() > start && !fn(in) {
+				return false
+			}
+		}
+	}
+	if e.xMid < end {
+		return startingInRecursive(itr.right(), start, end, fn)
+	}
+	return true
+}
+
+// recursiveQueries returns the intervals containing x and intersecting the window starting at x found by the
+// recursive traversals
+func recursiveQueries(tree *IntervalTree, x int, window *Interval) (containing, intersecting []*Interval) {
+	collect := func(res *[]*Interval) func(*Interval) bool {
+		return func(in *Interval) bool {
+			*res = append(*res, in)
+			return true
+		}
+	}
+	root := tree.nodes().root()
+	stabRecursive(root, x, tree.open, collect(&containing))
+	intersecting = slices.Clone(containing)
+	startingInRecursive(root, x, tree.last(window), collect(&intersecting))
+	return containing, intersecting
+}
+
+// chainOf returns a tree storing the disjoint intervals in a chain of right children, one node per interval
+func chainOf(intervals []*Interval) *IntervalTree {
+	tree := &IntervalTree{
+		tree: &nodeTree{}, bst: buildBST(intervals, 0), cover: newCoverage(intervals, 0), size: len(intervals),
+	}
+	itr := tree.tree.root()
+	for _, in := range intervals {
+		itr.insert(newElt([]*Interval{in}, in.Start, nil))
+		itr = itr.right()
+	}
+	spans(tree.tree, 0)
+	return tree
+}
+
+func TestIntervalTree_Iterative(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		open := rnd.Intn(2)
+		intervals := randomFlagged(rnd, rnd.Intn(1000), 5000, 1+rnd.Intn(500))
+		opts := []Option{WithSmallThreshold(0)}
+		if open == 1 {
+			intervals = randomHalfOpen(rnd, rnd.Intn(1000), 5000, 1+rnd.Intn(500))
+			opts = append(opts, WithHalfOpenIntervals())
+		}
+		s := &scratch{}
+		ends, buf := s.endpoints(intervals, open)
+		want := &IntervalTree{tree: fromEndpointsRecursive(ends, buf, &s.mid, nil, open), open: open}
+		tree := MustNewIntervalTree(intervals, opts...)
+		if !sameStructure(tree, want) {
+			t.Fatalf("THE ITERATIVE CONSTRUCTION MUST BUILD THE STRUCTURE OF THE RECURSIVE ONE")
+		}
+		for q := 0; q < 100; q++ {
+			x := rnd.Intn(5200) - 100
+			window := &Interval{Start: x, End: x + 1 + rnd.Intn(300)}
+			containing, intersecting := recursiveQueries(tree, x, window)
+			if got := tree.Containing(x); !slices.Equal(got, containing) {
+				t.Fatalf("CONTAINING(%d): EXPECTING %v, GOT %v", x, containing, got)
+			}
+			if got := tree.Intersecting(window); !slices.Equal(got, intersecting) {
+				t.Fatalf("INTERSECTING %s: EXPECTING %v, GOT %v", window, intersecting, got)
+			}
+		}
+	}
+}
+
+func TestIntervalTree_DeepTree(t *testing.T) {
+	const n = 1_000_000
+	intervals := make([]*Interval, n)
+	for i := range intervals {
+		intervals[i] = &Interval{Start: 3 * i, End: 3*i + 1}
+	}
+	balanced := MustNewIntervalTree(intervals)
+	chain := chainOf(intervals)
+	if err := chain.Validate(); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	if chain.Height() != n {
+		t.Fatalf("EXPECTING A CHAIN OF %d NODES, GOT A HEIGHT OF %d", n, chain.Height())
+	}
+	for _, tree := range []*IntervalTree{balanced, chain} {
+		for _, i := range []int{0, 1, n / 2, n - 2, n - 1} {
+			if got := tree.Containing(3*i + 1); len(got) != 1 || got[0] != intervals[i] {
+				t.Fatalf("CONTAINING(%d): EXPECTING %s, GOT %v", 3*i+1, intervals[i], got)
+			}
+			if got := tree.CountContaining(3*i + 2); got != 0 {
+				t.Fatalf("COUNTCONTAINING(%d): EXPECTING 0, GOT %d", 3*i+2, got)
+			}
+		}
+		if got := tree.Intersecting(&Interval{Start: 1, End: 3*n - 3}); !slices.Equal(got, intervals) {
+			t.Fatalf("INTERSECTING MUST RETURN THE %d INTERVALS IN ORDER, GOT %d", n, len(got))
+		}
+		if got := tree.Intersecting(&Interval{Start: 3*n - 5, End: 3 * n}); !slices.Equal(got, intervals[n-2:]) {
+			t.Fatalf("EXPECTING THE LAST TWO INTERVALS, GOT %v", got)
+		}
+	}
+}
+
+// benchmarkConstruction builds the tree of 1M random intervals with build
+func benchmarkConstruction(b *testing.B, build func(ends, buf []endpoint, mid *[]*Interval) *nodeTree) {
+	intervals := randomIntervals(rand.New(rand.NewSource(1)), 1_000_000, 100_000_000, 10_000)
+	s := &scratch{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ends, buf := s.endpoints(intervals, 0)
+		_ = build(ends, buf, &s.mid)
+	}
+}
+
+func BenchmarkConstruction_Iterative(b *testing.B) {
+	benchmarkConstruction(
+		b, func(ends, buf []endpoint, mid *[]*Interval) *nodeTree {
+			return fromEndpoints(ends, buf, mid, newArena(len(ends)/2), nil, 0)
+		},
+	)
+}
+
+func BenchmarkConstruction_Recursive(b *testing.B) {
+	benchmarkConstruction(
+		b, func(ends, buf []endpoint, mid *[]*Interval) *nodeTree {
+			return fromEndpointsRecursive(ends, buf, mid, nil, 0)
+		},
+	)
+}
+
+// benchmarkDeepStab stabs a chain of 10k nodes at its last interval
+func benchmarkDeepStab(b *testing.B, stabbing func(itr *iterator, x, open int, fn func(*Interval) bool) bool) {
+	intervals := make([]*Interval, 10_000)
+	for i := range intervals {
+		intervals[i] = &Interval{Start: 2 * i, End: 2*i + 1}
+	}
+	root := chainOf(intervals).nodes().root()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stabbing(root, 2*len(intervals)-1, 0, func(*Interval) bool { return true })
+	}
+}
+
+func BenchmarkDeepStab_Iterative(b *testing.B) {
+	benchmarkDeepStab(b, stab)
+}
+
+func BenchmarkDeepStab_Recursive(b *testing.B) {
+	benchmarkDeepStab(b, stabRecursive)
+}
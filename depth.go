@@ -0,0 +1,84 @@
+package intervaltree
+
+import "math"
+
+// -----------------------------------------------------
+// 				COVERAGE DEPTH
+// -----------------------------------------------------
+
+// MaxOverlapPoint returns the smallest coordinate covered by the most intervals, with the number of intervals
+// covering it. An empty IntervalTree returns the sentinel x = math.MinInt with a depth of 0.
+// Complexity: O(p log p), p = number of distinct endpoints, as it sweeps the endpoints stored in the BST
+func (t *IntervalTree) MaxOverlapPoint() (x int, depth int) {
+	t = t.orEmpty()
+	x = math.MinInt
+	current := 0
+	for _, p := range t.pointsIn(math.MinInt, math.MaxInt) {
+		starts, ends := p.events(t.open)
+		// closed intervals: the ones ending at p still cover it
+		current += starts
+		if current > depth {
+			x, depth = p.x, current
+		}
+		current -= ends
+	}
+	return x, depth
+}
+
+// CoverageSegment is a maximal sub-range of a window in which every coordinate is covered by Depth intervals
+type CoverageSegment struct {
+	Start int
+	End   int
+	Depth int
+}
+
+// CoverageProfile returns the coverage depth step function over the window as a list of maximal segments of
+// constant depth. The segments are closed, sorted, and partition the window exactly: the first one starts at
+// window.Start, the last one ends at window.End, and each one starts right after the previous one ends. They are
+// half-open for half-open intervals, each one starting where the previous one ends. An open side of the window is
+// left out of the segments. A nil, reversed or empty window has no segment, unless the tree was built
+// WithSwappedQueries which swaps a reversed one, see CoveredLength.
+// Complexity: O(ln n · ln m + p log p), n = len(intervals in struct), m = number of intervals in a node and p =
+// number of endpoints inside the window
+func (t *IntervalTree) CoverageProfile(window *Interval) []CoverageSegment {
+	t = t.orEmpty()
+	t.heal()
+	t.checkIntegrity()
+	start, end, err := t.bounds(window)
+	if err != nil {
+		return nil
+	}
+	// depth changes, in ascending order of the coordinate from which they apply
+	type change struct{ x, delta int }
+	var changes []change
+	push := func(x, delta int) {
+		if delta == 0 {
+			return
+		}
+		if last := len(changes) - 1; last >= 0 && changes[last].x == x {
+			changes[last].delta += delta
+		} else {
+			changes = append(changes, change{x, delta})
+		}
+	}
+	for _, p := range t.pointsIn(start, end) {
+		starts, ends := p.events(t.open)
+		if p.x > start {
+			push(p.x, starts) // intervals starting at start are already in the initial depth
+		}
+		if p.x < end {
+			push(p.x+1, -ends) // closed intervals still cover their End
+		}
+	}
+
+	depth := t.stabCount(start) // the points list each stored interval once, as CountContaining without multiplicity
+	var res []CoverageSegment
+	for _, c := range changes {
+		if c.delta == 0 {
+			continue
+		}
+		res = append(res, CoverageSegment{Start: start, End: c.x - 1 + t.open, Depth: depth})
+		start, depth = c.x, depth+c.delta
+	}
+	return append(res, CoverageSegment{Start: start, End: end + t.open, Depth: depth})
+}
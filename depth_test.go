@@ -0,0 +1,116 @@
+package intervaltree
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+// bruteMaxOverlap returns the smallest coordinate covered by the most intervals by counting each coordinate
+func bruteMaxOverlap(intervals []*Interval, lower, upper int) (int, int) {
+	x, depth := math.MinInt, 0
+	for c := lower; c <= upper; c++ {
+		d := 0
+		for _, in := range intervals {
+			if in.Start <= c && c <= in.End {
+				d++
+			}
+		}
+		if d > depth {
+			x, depth = c, d
+		}
+	}
+	return x, depth
+}
+
+func TestIntervalTree_MaxOverlapPoint(t *testing.T) {
+	// heavily nested: [i, 100 - i] for i in [0, 49], the deepest point is the innermost interval start
+	var nested []*Interval
+	for i := 0; i < 50; i++ {
+		nested = append(nested, &Interval{Start: i, End: 100 - i})
+	}
+	if x, depth := MustNewIntervalTree(nested).MaxOverlapPoint(); x != 49 || depth != 50 {
+		t.Fatalf("NESTED: EXPECTING (49, 50), GOT (%d, %d)", x, depth)
+	}
+	// fully disjoint: the depth is 1 and the smallest coordinate wins
+	var disjoint []*Interval
+	for i := 10; i > 0; i-- {
+		disjoint = append(disjoint, &Interval{Start: i * 10, End: i*10 + 5})
+	}
+	if x, depth := MustNewIntervalTree(disjoint).MaxOverlapPoint(); x != 10 || depth != 1 {
+		t.Fatalf("DISJOINT: EXPECTING (10, 1), GOT (%d, %d)", x, depth)
+	}
+	// touching closed intervals overlap at the shared point
+	touching := []*Interval{{Start: 0, End: 5}, {Start: 5, End: 9}, {Start: 9, End: 9}, {Start: 9, End: 12}}
+	if x, depth := MustNewIntervalTree(touching).MaxOverlapPoint(); x != 9 || depth != 3 {
+		t.Fatalf("TOUCHING: EXPECTING (9, 3), GOT (%d, %d)", x, depth)
+	}
+	if x, depth := MustNewIntervalTree(nil).MaxOverlapPoint(); x != math.MinInt || depth != 0 {
+		t.Fatalf("EMPTY: EXPECTING (MinInt, 0), GOT (%d, %d)", x, depth)
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(40)+1, 200, 30)
+		wantX, wantDepth := bruteMaxOverlap(intervals, 0, 230)
+		if x, depth := MustNewIntervalTree(intervals).MaxOverlapPoint(); x != wantX || depth != wantDepth {
+			t.Fatalf("EXPECTING (%d, %d), GOT (%d, %d)", wantX, wantDepth, x, depth)
+		}
+	}
+}
+
+func TestIntervalTree_CoverageProfile(t *testing.T) {
+	tree := MustNewIntervalTree([]*Interval{{Start: 5, End: 9}, {Start: 5, End: 12}, {Start: 7, End: 9}, {Start: 13, End: 15}})
+	want := []CoverageSegment{
+		{Start: 0, End: 4, Depth: 0},
+		{Start: 5, End: 6, Depth: 2},
+		{Start: 7, End: 9, Depth: 3},
+		{Start: 10, End: 14, Depth: 1}, // [5, 12] hands over to the adjacent [13, 15] without a depth change
+	}
+	got := tree.CoverageProfile(&Interval{Start: 0, End: 14})
+	if len(got) != len(want) {
+		t.Fatalf("EXPECTING %v, GOT %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("EXPECTING %v, GOT %v", want, got)
+		}
+	}
+
+	// a nil or reversed window has no profile, unless the reversed one is swapped
+	split := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 20, End: 30}})
+	swapped := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 20, End: 30}}, WithSwappedQueries())
+	if split.CoverageProfile(nil) != nil || MustNewIntervalTree(nil).CoverageProfile(nil) != nil {
+		t.Fatalf("EXPECTING NO PROFILE FOR A NIL WINDOW")
+	}
+	if got := split.CoverageProfile(&Interval{Start: 25, End: 3}); got != nil {
+		t.Fatalf("EXPECTING NO PROFILE FOR A REVERSED WINDOW, GOT %v", got)
+	}
+	if got, want := swapped.CoverageProfile(&Interval{Start: 25, End: 3}), split.CoverageProfile(&Interval{Start: 3, End: 25}); !slices.Equal(got, want) || len(got) != 3 {
+		t.Fatalf("EXPECTING THE PROFILE %v OF THE SWAPPED WINDOW, GOT %v", want, got)
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 300; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(30), 100, 20)
+		tree := MustNewIntervalTree(intervals)
+		start := rnd.Intn(130) - 10
+		window := &Interval{Start: start, End: start + rnd.Intn(60)}
+		profile := tree.CoverageProfile(window)
+		if profile[0].Start != window.Start || profile[len(profile)-1].End != window.End {
+			t.Fatalf("PROFILE %v DOES NOT SPAN %v", profile, window)
+		}
+		for k, seg := range profile {
+			if k > 0 && (seg.Start != profile[k-1].End+1 || seg.Depth == profile[k-1].Depth) {
+				t.Fatalf("PROFILE %v IS NOT A PARTITION OF MAXIMAL SEGMENTS", profile)
+			}
+			for x := seg.Start; x <= seg.End; x++ {
+				if _, d := bruteMaxOverlap(intervals, x, x); d != seg.Depth {
+					t.Fatalf("DEPTH AT %d: EXPECTING %d, GOT %d IN %v", x, d, seg.Depth, profile)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package intervaltree
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// windows are the windows given to the methods by callAll, the first one holding points and the others none
+var windows = []*Interval{{Start: 0, End: 10}, nil, {Start: 10, End: 0}}
+
+// callAll calls every exported method of the tree built by newTree, each on a new tree, with arguments made of
+// zero values, a tree built by newTree and functions returning zero values, once for every window of windows if it
+// takes an *Interval. It consumes the returned iterators and channels, and calls check with the name of the method,
+// the window and the results
+func callAll(t *testing.T, newTree func() *IntervalTree, check func(name string, window *Interval, results []reflect.Value)) {
+	t.Helper()
+	typ := reflect.TypeOf(newTree())
+	for m := 0; m < typ.NumMethod(); m++ {
+		for w, window := range windows {
+			if w == 0 || takesInterval(typ.Method(m).Type) {
+				callMethod(t, typ.Method(m), newTree, window, check)
+			}
+		}
+	}
+}
+
+// takesInterval tells if the method has an *Interval parameter
+func takesInterval(method reflect.Type) bool {
+	for a := 1; a < method.NumIn(); a++ {
+		if method.In(a) == reflect.TypeOf(&Interval{}) {
+			return true
+		}
+	}
+	return false
+}
+
+// callMethod calls the method as callAll does, with the window given in parameter
+func callMethod(
+	t *testing.T, method reflect.Method, newTree func() *IntervalTree, window *Interval,
+	check func(name string, window *Interval, results []reflect.Value),
+) {
+	t.Helper()
+	{
+		args := []reflect.Value{reflect.ValueOf(newTree())}
+		for a := 1; a < method.Type.NumIn(); a++ {
+			in := method.Type.In(a)
+			if method.Type.IsVariadic() && a == method.Type.NumIn()-1 {
+				break
+			}
+			switch {
+			case in == reflect.TypeOf(&Interval{}):
+				args = append(args, reflect.ValueOf(window))
+			case in == reflect.TypeOf(&IntervalTree{}):
+				args = append(args, reflect.ValueOf(newTree()))
+			case in == reflect.TypeOf((*context.Context)(nil)).Elem():
+				args = append(args, reflect.ValueOf(context.Background()))
+			case in.Kind() == reflect.Func:
+				args = append(
+					args, reflect.MakeFunc(
+						in, func(params []reflect.Value) []reflect.Value {
+							results := make([]reflect.Value, in.NumOut())
+							for i := range results {
+								results[i] = reflect.Zero(in.Out(i))
+							}
+							return results
+						},
+					),
+				)
+			case in.Kind() == reflect.Int:
+				args = append(args, reflect.ValueOf(1).Convert(in))
+			default:
+				args = append(args, reflect.Zero(in))
+			}
+		}
+		var results []reflect.Value
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("%s PANICS: %v", method.Name, r)
+				}
+			}()
+			results = method.Func.Call(args)
+			for _, r := range results {
+				switch {
+				case r.Kind() == reflect.Func && r.Type().NumIn() == 1: // iter.Seq
+					r.Call(
+						[]reflect.Value{
+							reflect.MakeFunc(
+								r.Type().In(0), func([]reflect.Value) []reflect.Value {
+									t.Fatalf("%s: THE ITERATOR MUST BE EMPTY", method.Name)
+									return nil
+								},
+							),
+						},
+					)
+				case r.Kind() == reflect.Chan:
+					if _, ok := r.Recv(); ok {
+						t.Fatalf("%s: THE CHANNEL MUST BE EMPTY", method.Name)
+					}
+				}
+			}
+		}()
+		check(method.Name, window, results)
+	}
+}
+
+// rejects tells if the error is the one a method may return for a window of windows holding no point
+func rejects(window *Interval, err error) bool {
+	return window != windows[0] && (errors.Is(err, ErrNilInterval) || errors.Is(err, ErrReversedInterval))
+}
+
+// emptyResult tells if the value is an empty result: a nil or empty slice, index or map, a zero number, false
+func emptyResult(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr:
+		if idx, ok := v.Interface().(IntervalIndex); ok && !v.IsNil() {
+			return idx.Len() == 0
+		}
+		return v.IsNil()
+	case reflect.Int, reflect.Uint64, reflect.Bool:
+		return v.IsZero()
+	}
+	return true
+}
+
+func TestIntervalTree_EmptyAndNil(t *testing.T) {
+	// methods whose results are not empty on an empty tree
+	filled := map[string]bool{"WithInterval": true, "Stats": true, "CoverageProfile": true, "Gaps": true, "IsEmpty": true, "MaxOverlapPoint": true}
+	for _, opts := range [][]Option{nil, {WithSmallThreshold(0)}, {WithHalfOpenIntervals()}, {WithMultiplicity()}, {WithSwappedQueries()}} {
+		swap := MustNewIntervalTree(nil, opts...).swap
+		callAll(
+			t, func() *IntervalTree { return MustNewIntervalTree(nil, opts...) },
+			func(name string, window *Interval, results []reflect.Value) {
+				for _, r := range results {
+					if err, ok := r.Interface().(error); ok && err != nil && !errors.Is(err, ErrNotStored) && !rejects(window, err) {
+						t.Fatalf("%s %v: UNEXPECTED ERROR %v", name, window, err)
+					}
+					// a swapped window holds the points of the first one
+					if (!filled[name] || window == nil || window != windows[0] && !swap) && !emptyResult(r) {
+						t.Fatalf("%s %v: EXPECTING AN EMPTY RESULT, GOT %v", name, window, r)
+					}
+				}
+			},
+		)
+	}
+
+	// the tree is not modified by a nil tree, so the methods adding intervals fail
+	failing := map[string]bool{"Insert": true, "ExtendWith": true}
+	filled["WithInterval"] = false
+	callAll(
+		t, func() *IntervalTree {
+			var tree *IntervalTree
+			return tree
+		},
+		func(name string, window *Interval, results []reflect.Value) {
+			for _, r := range results {
+				err, ok := r.Interface().(error)
+				if failing[name] != (ok && errors.Is(err, ErrNilTree)) && r.Type() == reflect.TypeOf((*error)(nil)).Elem() && !rejects(window, err) {
+					t.Fatalf("%s %v: UNEXPECTED ERROR %v", name, window, err)
+				}
+				if (name != "WithInterval" && !filled[name] || window != windows[0]) && !emptyResult(r) {
+					t.Fatalf("%s %v: EXPECTING AN EMPTY RESULT, GOT %v", name, window, r)
+				}
+			}
+		},
+	)
+	var tree *IntervalTree
+	if tree.Len() != 0 || !tree.IsEmpty() || len(tree.Containing(3)) != 0 || slices.Collect(tree.AllSeq()) != nil {
+		t.Fatalf("A NIL TREE MUST BE EMPTY")
+	}
+	if got, err := tree.WithInterval(&Interval{Start: 1, End: 2}); err != nil || got.Len() != 1 {
+		t.Fatalf("EXPECTING A NEW TREE OF 1 INTERVAL, GOT %v (%v)", got, err)
+	}
+	if Merge(nil, tree, MustNewIntervalTree([]*Interval{{Start: 1, End: 2}})).Len() != 1 {
+		t.Fatalf("NIL TREES MUST MERGE AS EMPTY ONES")
+	}
+	if !EqualCoverage(nil, MustNewIntervalTree(nil)) || UnionTree(nil, nil, EqualEndpoints).Len() != 0 {
+		t.Fatalf("NIL TREES MUST BE EMPTY")
+	}
+	OverlapJoin(nil, tree, func(x, y *Interval) bool { t.Fatalf("NOTHING TO JOIN"); return false })
+	func() {
+		defer func() {
+			if err, ok := recover().(error); !ok || !errors.Is(err, ErrNilTree) {
+				t.Fatalf("MERGING INTO A NIL TREE MUST PANIC WITH ErrNilTree, GOT %v", err)
+			}
+		}()
+		tree.MergeInPlace(MustNewIntervalTree([]*Interval{{Start: 1, End: 2}}))
+	}()
+}
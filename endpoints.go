@@ -0,0 +1,97 @@
+package intervaltree
+
+import (
+	"math"
+	"slices"
+)
+
+// -----------------------------------------------------
+// 				ENDPOINT QUERIES
+// -----------------------------------------------------
+
+// Boundaries returns all the distinct Start and End values of the IntervalTree in ascending order
+// Complexity: O(n log n), n = number of stored intervals
+func (t *IntervalTree) Boundaries() []int {
+	t = t.orEmpty()
+	return t.boundaries(math.MinInt, math.MaxInt)
+}
+
+// BoundariesIn returns the distinct Start and End values lying inside the window, in ascending order. A nil,
+// reversed or empty window holds none, unless the tree was built WithSwappedQueries which swaps a reversed one,
+// see CoveredLength.
+// Output sensitive: Complexity of O(ln p + k log k), p = number of distinct endpoints and k = intervals with an
+// endpoint inside the window
+func (t *IntervalTree) BoundariesIn(window *Interval) []int {
+	t = t.orEmpty()
+	first, last, err := t.bounds(window)
+	if err != nil {
+		return nil
+	}
+	return t.boundaries(first, last)
+}
+
+// boundaries returns the distinct Start and End values in [lo, hi], in ascending order. The BST holds the first and
+// last points of the intervals, so an endpoint excluded from its interval is found next to its point
+func (t *IntervalTree) boundaries(lo, hi int) []int {
+	var res []int
+	for _, p := range t.pointsIn(maxInt(lo, math.MinInt+1)-1, minInt(hi, math.MaxInt-1)+1) {
+		for _, in := range p.ptrs {
+			if in.first() == p.x && in.Start >= lo && in.Start <= hi {
+				res = append(res, in.Start)
+			}
+			if t.last(in) == p.x && in.End >= lo && in.End <= hi {
+				res = append(res, in.End)
+			}
+		}
+	}
+	slices.Sort(res)
+	return slices.Compact(res)
+}
+
+// EndingBefore returns all intervals entirely on the left of x: End < x
+// Output sensitive: Complexity of O(ln p + k log k), p = number of distinct endpoints and k = visited endpoints
+func (t *IntervalTree) EndingBefore(x int) []*Interval {
+	if x == math.MinInt {
+		return nil
+	}
+	return t.EndingAt(x - 1)
+}
+
+// EndingAt returns all intervals ending at or before x: End <= x
+// Output sensitive: Complexity of O(ln p + k log k), p = number of distinct endpoints and k = visited endpoints
+func (t *IntervalTree) EndingAt(x int) []*Interval {
+	t = t.orEmpty()
+	var res []*Interval
+	for _, p := range t.pointsIn(math.MinInt, x) {
+		for _, in := range p.ending(t.open) {
+			if in.End <= x { // an open End is right after the last point
+				res = append(res, in)
+			}
+		}
+	}
+	return res
+}
+
+// StartingAfter returns all intervals entirely on the right of x: Start > x
+// Output sensitive: Complexity of O(ln p + k log k), p = number of distinct endpoints and k = visited endpoints
+func (t *IntervalTree) StartingAfter(x int) []*Interval {
+	if x == math.MaxInt {
+		return nil
+	}
+	return t.StartingAt(x + 1)
+}
+
+// StartingAt returns all intervals starting at or after x: Start >= x
+// Output sensitive: Complexity of O(ln p + k log k), p = number of distinct endpoints and k = visited endpoints
+func (t *IntervalTree) StartingAt(x int) []*Interval {
+	t = t.orEmpty()
+	var res []*Interval
+	for _, p := range t.pointsIn(x, math.MaxInt) {
+		for _, in := range p.starting(t.open) {
+			if in.Start >= x { // an open Start is right before the first point
+				res = append(res, in)
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,96 @@
+package intervaltree
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Boundaries(t *testing.T) {
+	var intervals []*Interval
+	for i := 0; i < 100; i++ {
+		// many intervals sharing the endpoints 0, 10 and 20
+		intervals = append(intervals, &Interval{Start: 0, End: 10}, &Interval{Start: 10, End: 20}, &Interval{Start: 5, End: 5})
+	}
+	intervals = append(intervals, &Interval{Start: -3, End: 20})
+	tree := MustNewIntervalTree(intervals)
+	if got, want := tree.Boundaries(), []int{-3, 0, 5, 10, 20}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("EXPECTING %v, GOT %v", want, got)
+	}
+	if got, want := tree.BoundariesIn(&Interval{Start: 0, End: 10}), []int{0, 5, 10}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("EXPECTING %v, GOT %v", want, got)
+	}
+	if got := tree.BoundariesIn(&Interval{Start: 11, End: 19}); len(got) != 0 {
+		t.Fatalf("EXPECTING NO BOUNDARY, GOT %v", got)
+	}
+	if got := MustNewIntervalTree(nil).Boundaries(); len(got) != 0 {
+		t.Fatalf("EMPTY: EXPECTING NO BOUNDARY, GOT %v", got)
+	}
+	// a nil or reversed window holds no boundary, unless the reversed one is swapped
+	split := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 20, End: 30}})
+	swapped := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 20, End: 30}}, WithSwappedQueries())
+	if split.BoundariesIn(nil) != nil || MustNewIntervalTree(nil).BoundariesIn(nil) != nil {
+		t.Fatalf("EXPECTING NO BOUNDARY IN A NIL WINDOW")
+	}
+	if got := split.BoundariesIn(&Interval{Start: 25, End: 3}); got != nil {
+		t.Fatalf("EXPECTING NO BOUNDARY IN A REVERSED WINDOW, GOT %v", got)
+	}
+	if got, want := swapped.BoundariesIn(&Interval{Start: 25, End: 3}), []int{5, 20}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("EXPECTING THE BOUNDARIES %v OF THE SWAPPED WINDOW, GOT %v", want, got)
+	}
+}
+
+func TestIntervalTree_EndingBeforeStartingAfter(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(300), 1000, 50)
+		intervals = append(intervals, &Interval{Start: 40, End: 40}, &Interval{Start: 40, End: 40})
+		tree := MustNewIntervalTree(intervals)
+		x := rnd.Intn(1100)
+		if x%2 == 1 {
+			x = 40 // single point intervals at x
+		}
+		before, containing, after := tree.EndingBefore(x), tree.Containing(x), tree.StartingAfter(x)
+		seen := make(map[*Interval]bool)
+		for _, in := range before {
+			if in.End >= x || seen[in] {
+				t.Fatalf("%s RETURNED BY ENDINGBEFORE(%d)", in, x)
+			}
+			seen[in] = true
+		}
+		for _, in := range after {
+			if in.Start <= x || seen[in] {
+				t.Fatalf("%s RETURNED BY STARTINGAFTER(%d)", in, x)
+			}
+			seen[in] = true
+		}
+		for _, in := range containing {
+			if seen[in] {
+				t.Fatalf("%s RETURNED TWICE", in)
+			}
+			seen[in] = true
+		}
+		if len(seen) != len(intervals) {
+			t.Fatalf("THE THREE QUERIES RETURNED %d INTERVALS OUT OF %d", len(seen), len(intervals))
+		}
+
+		atMost, atLeast := 0, 0
+		for _, in := range intervals {
+			if in.End <= x {
+				atMost++
+			}
+			if in.Start >= x {
+				atLeast++
+			}
+		}
+		if len(tree.EndingAt(x)) != atMost || len(tree.StartingAt(x)) != atLeast {
+			t.Fatalf("INCLUSIVE VARIANTS: EXPECTING %d AND %d, GOT %d AND %d", atMost, atLeast, len(tree.EndingAt(x)), len(tree.StartingAt(x)))
+		}
+	}
+	tree := MustNewIntervalTree([]*Interval{{Start: math.MinInt, End: math.MinInt}, {Start: math.MaxInt, End: math.MaxInt}})
+	if len(tree.EndingBefore(math.MinInt)) != 0 || len(tree.StartingAfter(math.MaxInt)) != 0 {
+		t.Fatalf("NOTHING IS BEFORE MININT OR AFTER MAXINT")
+	}
+}
@@ -0,0 +1,108 @@
+package intervaltree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// -----------------------------------------------------
+// 				ERRORS
+// -----------------------------------------------------
+
+var (
+	// ErrNilInterval is returned when a nil *Interval is given where an interval is required
+	ErrNilInterval = errors.New("intervaltree: nil interval")
+	// ErrReversedInterval is returned when an interval has Start > End
+	ErrReversedInterval = errors.New("intervaltree: interval with Start > End")
+	// ErrNotStored is returned when an operation targets an interval the IntervalTree does not hold
+	ErrNotStored = errors.New("intervaltree: interval not stored in the tree")
+	// ErrOverflow is returned when a transformation would move a coordinate out of the int range
+	ErrOverflow = errors.New("intervaltree: coordinate overflow")
+	// ErrInvalidScale is returned when a scale factor has a zero denominator
+	ErrInvalidScale = errors.New("intervaltree: invalid scale factor")
+	// ErrSharedIntervals was returned when an operation would modify intervals shared with a snapshot.
+	//
+	// Deprecated: the trees copy the intervals they modify after a Snapshot, so nothing returns it
+	ErrSharedIntervals = errors.New("intervaltree: intervals shared with a snapshot")
+	// ErrUnsorted is returned when intervals expected sorted by Start are not
+	ErrUnsorted = errors.New("intervaltree: intervals not sorted by Start")
+	// ErrBrokenInvariant is returned when the intervals cannot be organized into a valid structure
+	ErrBrokenInvariant = errors.New("intervaltree: broken structure invariant")
+	// ErrEmptyInterval is returned when an interval with an open side holds no point, as [x, x) or (x, x + 1)
+	ErrEmptyInterval = errors.New("intervaltree: empty interval")
+	// ErrMixedModes is the panic value of the operations given trees with closed and half-open intervals
+	ErrMixedModes = errors.New("intervaltree: trees mixing closed and half-open intervals")
+	// ErrNilTree is returned when intervals are added to a nil *IntervalTree, which behaves as an empty tree otherwise
+	ErrNilTree = errors.New("intervaltree: nil tree")
+	// ErrModifiedInterval is returned when a stored interval no longer has the endpoints it was indexed with
+	ErrModifiedInterval = errors.New("intervaltree: stored interval modified")
+	// ErrCountedInterval is returned when Replace is given an interval stored several times WithMultiplicity, whose
+	// copies would all be moved with the single *Interval
+	ErrCountedInterval = errors.New("intervaltree: interval stored several times")
+	// ErrTooManyIntervals is returned when the intervals do not fit the 32 bits positions of a CompactIntervalTree
+	ErrTooManyIntervals = errors.New("intervaltree: too many intervals")
+)
+
+// invariantPanic is the panic value raised when a query finds the internal state of the IntervalTree inconsistent.
+// The E variants of the queries, as ContainingE, recover it and return its error, wrapping ErrBrokenInvariant
+type invariantPanic struct {
+	err error
+}
+
+// Error returns the message of the wrapped error, so that an unrecovered panic still tells what is broken
+func (p invariantPanic) Error() string {
+	return p.err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (p invariantPanic) Unwrap() error {
+	return p.err
+}
+
+// brokenInvariant panics with an invariantPanic wrapping ErrBrokenInvariant and the formatted message
+func brokenInvariant(format string, args ...interface{}) {
+	panic(invariantPanic{fmt.Errorf("%w: %s", ErrBrokenInvariant, fmt.Sprintf(format, args...))})
+}
+
+// recoverInvariant stores in err the error of an invariantPanic raised by the caller, any other panic going on.
+// It must be deferred
+func recoverInvariant(err *error) {
+	if r := recover(); r != nil {
+		p, ok := r.(invariantPanic)
+		if !ok {
+			panic(r)
+		}
+		*err = p.err
+	}
+}
+
+// validate returns an error if the interval cannot be stored in an IntervalTree, open being 1 if the intervals are
+// half-open and 0 if they are closed
+func validate(in *Interval, open int) error {
+	if in == nil {
+		return ErrNilInterval
+	}
+	if in.Start > in.End {
+		return fmt.Errorf("%w: %s", ErrReversedInterval, in)
+	}
+	if vacant(in, open) {
+		return fmt.Errorf("%w: %s", ErrEmptyInterval, in)
+	}
+	return nil
+}
+
+// sameMode panics with ErrMixedModes if the trees do not all hold the same kind of intervals. A nil tree holds
+// none and matches any tree
+func sameMode(trees ...*IntervalTree) {
+	var first *IntervalTree
+	for _, t := range trees {
+		if t == nil {
+			continue
+		}
+		if first == nil {
+			first = t
+		} else if t.open != first.open {
+			panic(ErrMixedModes)
+		}
+	}
+}
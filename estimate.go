@@ -0,0 +1,63 @@
+package intervaltree
+
+import "slices"
+
+// -----------------------------------------------------
+// 				RESULT ESTIMATION
+// -----------------------------------------------------
+
+// EstimateResultCount returns the number of intervals Intersecting returns for the query, to pre-size a buffer. It
+// adds up the cutoffs of the lists of the nodes holding them, those on the path of the first point of the query and
+// those whose xMid lies after it on the way to its last point, found by binary search without looking at the
+// intervals in between, so a node holding many intervals near the query costs no more than a small one. A small
+// tree is scanned. The single point query [x, x] counts Containing(x), as [x, x + 1) does in a half-open tree, and a
+// nil or reversed query gives 0 unless the tree was built WithSwappedQueries
+// Complexity: O((ln n + p) ln m), n = len(intervals in struct), p = number of nodes able to hold the intervals and
+// m = number of intervals in a node
+func (t *IntervalTree) EstimateResultCount(query *Interval) int {
+	t = t.orEmpty()
+	t.heal()
+	query, err := t.window(query)
+	if err != nil || t.empty(query) {
+		return 0
+	}
+	if t.small != nil {
+		count := 0
+		t.scanSmall(
+			query.first(), t.last(query), func(*Interval) bool {
+				count++
+				return true
+			},
+		)
+		return count
+	}
+	q := borrowScratch()
+	defer q.release()
+	return t.estimate(q, query.first(), t.last(query))
+}
+
+// estimate returns the number of intervals having a point in [start, end], see EstimateResultCount: those
+// containing start, then those starting in (start, end], as the intersecting queries report them
+func (t *IntervalTree) estimate(q *queryScratch, start, end int) int {
+	count := 0
+	root := t.nodes().root()
+	stabCuts(
+		root, start, t.open, func(_ *elt, _ bool, n int) bool {
+			count += n
+			return true
+		},
+	)
+	nodesAfter(
+		root, start, end, &q.stack, func(e *elt) bool {
+			from, to := e.starting(start, end)
+			count += to - from
+			return true
+		},
+	)
+	return count
+}
+
+// reserve grows the buffer of the found intervals to hold n of them, up to the capacity kept in the pool
+func (q *queryScratch) reserve(n int) {
+	q.found = slices.Grow(q.found, minInt(n, maxPooledScratch))
+}
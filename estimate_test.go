@@ -0,0 +1,75 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_EstimateResultCount(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals, mode := randomFlagged(rnd, rnd.Intn(1000), 1000, 1+rnd.Intn(200)), []Option(nil)
+		if i%2 == 1 {
+			intervals, mode = randomHalfOpen(rnd, rnd.Intn(1000), 1000, 1+rnd.Intn(200)), []Option{WithHalfOpenIntervals()}
+		}
+		for _, tree := range append(treesOf(t, intervals, mode...), MustNewIntervalTree(intervals, mode...)) {
+			for q := 0; q < 100; q++ {
+				x := rnd.Intn(1200) - 100
+				window := &Interval{Start: x, End: x + rnd.Intn(300)}
+				if got, want := tree.EstimateResultCount(window), len(tree.Intersecting(window)); got != want {
+					t.Fatalf("%s: EXPECTING AN ESTIMATE OF %d, GOT %d", window, want, got)
+				}
+				if got, want := tree.EstimateResultCount(&Interval{Start: x, End: x + tree.open}), len(tree.Containing(x)); got != want {
+					t.Fatalf("%d: EXPECTING AN ESTIMATE OF %d, GOT %d", x, want, got)
+				}
+			}
+		}
+	}
+	intervals := []*Interval{{Start: 0, End: 10}, {Start: 2, End: 3}, {Start: 5, End: 20}}
+	small := MustNewIntervalTree(intervals)
+	if got := small.EstimateResultCount(&Interval{Start: 4, End: 4}); got != 1 {
+		t.Fatalf("A SMALL TREE MUST GIVE THE EXACT COUNT 1, GOT %d", got)
+	}
+	if small.EstimateResultCount(nil) != 0 || small.EstimateResultCount(&Interval{Start: 4, End: 1}) != 0 {
+		t.Fatalf("A NIL OR REVERSED QUERY MUST GIVE 0")
+	}
+	swapped := MustNewIntervalTree(intervals, WithSwappedQueries(), WithSmallThreshold(0))
+	if got := swapped.EstimateResultCount(&Interval{Start: 4, End: 1}); got != 2 {
+		t.Fatalf("A SWAPPED QUERY MUST BE ESTIMATED AS [1, 4], GOT %d", got)
+	}
+}
+
+// benchmarkLargeContaining runs Containing queries returning tens of thousands of intervals
+func benchmarkLargeContaining(b *testing.B, containing func(tree *IntervalTree, x int) []*Interval) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 1_000_000, 10_000_000, 1_000_000))
+	if k := len(containing(tree, 5_000_000)); k < 10_000 {
+		b.Fatalf("EXPECTING TENS OF THOUSANDS OF INTERVALS, GOT %d", k)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		containing(tree, 1_000_000+rnd.Intn(8_000_000))
+	}
+}
+
+func BenchmarkContaining_Large(b *testing.B) {
+	benchmarkLargeContaining(b, (*IntervalTree).Containing)
+}
+
+// BenchmarkContaining_Large_Append grows the result by appending to it, as Containing did before estimating it
+func BenchmarkContaining_Large_Append(b *testing.B) {
+	benchmarkLargeContaining(
+		b, func(tree *IntervalTree, x int) []*Interval {
+			var res []*Interval
+			stab(
+				tree.nodes().root(), x, tree.open, func(in *Interval) bool {
+					res = append(res, in)
+					return true
+				},
+			)
+			return res
+		},
+	)
+}
@@ -0,0 +1,151 @@
+package intervaltree
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+// extremeCoords are coordinates at both ends of the int range and around 0
+var extremeCoords = func() []int {
+	var coords []int
+	for d := 0; d <= 10; d++ {
+		coords = append(coords, math.MinInt+d, math.MaxInt-d)
+	}
+	return append(coords, -1, 0, 1)
+}()
+
+// randomExtreme generates n intervals whose endpoints are extreme coordinates, with random boundary flags, none
+// being vacant with open being 1 for half-open intervals
+func randomExtreme(rnd *rand.Rand, n, open int) []*Interval {
+	intervals := make([]*Interval, 0, n)
+	for len(intervals) < n {
+		a, b := extremeCoords[rnd.Intn(len(extremeCoords))], extremeCoords[rnd.Intn(len(extremeCoords))]
+		in := &Interval{Start: minInt(a, b), End: maxInt(a, b), StartOpen: rnd.Intn(4) == 0, EndOpen: rnd.Intn(4) == 0}
+		if !vacant(in, open) {
+			intervals = append(intervals, in)
+		}
+	}
+	return intervals
+}
+
+// extremeQueries returns Containing queries at every extreme coordinate and Intersecting ones between them
+func extremeQueries(rnd *rand.Rand) []Query {
+	var queries []Query
+	for _, x := range extremeCoords {
+		queries = append(queries, Query{X: x})
+	}
+	for _, w := range randomExtreme(rnd, 100, 0) {
+		queries = append(queries, Query{Window: w})
+	}
+	return append(
+		queries, Query{Window: &Interval{Start: math.MinInt, End: math.MinInt}},
+		Query{Window: &Interval{Start: math.MaxInt, End: math.MaxInt}},
+		Query{Window: &Interval{Start: math.MinInt, End: math.MaxInt}},
+		Query{Window: &Interval{Start: math.MaxInt - 1, End: math.MaxInt, StartOpen: true}},
+		Query{Window: &Interval{Start: math.MinInt, End: math.MinInt + 1, EndOpen: true}},
+		Query{Window: &Interval{Start: math.MaxInt, End: math.MaxInt, StartOpen: true}},
+		Query{Window: &Interval{Start: math.MinInt, End: math.MinInt, EndOpen: true}},
+	)
+}
+
+func TestIntervalTree_ExtremeCoordinates(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		open := i % 2
+		var mode []Option
+		if open == 1 {
+			mode = []Option{WithHalfOpenIntervals()}
+		}
+		intervals := randomExtreme(rnd, 1+rnd.Intn(200), open)
+		naive := NewNaiveIntervalSet(intervals, mode...)
+		for _, tree := range treesOf(t, intervals, mode...) {
+			checkStructure(t, tree)
+			if err := CheckAgainstNaive(tree, naive, extremeQueries(rnd)); err != nil {
+				t.Fatalf("%v", err)
+			}
+			for _, x := range extremeCoords {
+				if got, want := tree.CountContaining(x), len(naive.Containing(x)); got != want {
+					t.Fatalf("COUNTCONTAINING(%d): EXPECTING %d, GOT %d", x, want, got)
+				}
+			}
+			// the coverage saturates, as Length does
+			var covered uint64
+			for _, r := range tree.Compact() {
+				r.End -= open
+				covered += span(r.first(), lastPoint(&r, 0))
+			}
+			if got := tree.TotalCoveredLength(); got != measure(covered, tree.Len() > 0) {
+				t.Fatalf("EXPECTING %d COVERED COORDINATES, GOT %d", measure(covered, tree.Len() > 0), got)
+			}
+			boundaries := tree.Boundaries()
+			if !slices.IsSorted(boundaries) {
+				t.Fatalf("BOUNDARIES NOT SORTED: %v", boundaries)
+			}
+			if len(tree.EndingBefore(math.MinInt)) != 0 || len(tree.StartingAfter(math.MaxInt)) != 0 {
+				t.Fatalf("NOTHING ENDS BEFORE math.MinInt NOR STARTS AFTER math.MaxInt")
+			}
+			if got := tree.EndingAt(math.MaxInt); len(got) != tree.Len() {
+				t.Fatalf("EVERY INTERVAL ENDS AT OR BEFORE math.MaxInt, GOT %d OF %d", len(got), tree.Len())
+			}
+			if got := tree.StartingAt(math.MinInt); len(got) != tree.Len() {
+				t.Fatalf("EVERY INTERVAL STARTS AT OR AFTER math.MinInt, GOT %d OF %d", len(got), tree.Len())
+			}
+			x, depth := tree.MaxOverlapPoint()
+			if depth != len(naive.Containing(x)) {
+				t.Fatalf("MAXOVERLAPPOINT: %d HOLDS %d INTERVALS, NOT %d", x, len(naive.Containing(x)), depth)
+			}
+			for _, x := range []int{math.MinInt, math.MaxInt} {
+				left, right := tree.Split(x, SplitClip)
+				checkStructure(t, left)
+				checkStructure(t, right)
+				if left.Len()+right.Len() < tree.Len() {
+					t.Fatalf("SPLIT AT %d LOST INTERVALS: %d + %d OF %d", x, left.Len(), right.Len(), tree.Len())
+				}
+			}
+			if clipped := tree.Clip(&Interval{Start: math.MinInt, End: math.MaxInt}); clipped.Len() != tree.Len() {
+				t.Fatalf("CLIPPING TO THE FULL RANGE KEEPS EVERY INTERVAL, GOT %d OF %d", clipped.Len(), tree.Len())
+			}
+			for _, gap := range tree.Gaps(&Interval{Start: math.MinInt, End: math.MaxInt}) {
+				if gap.Start > gap.End || len(tree.Intersecting(gap)) != 0 {
+					t.Fatalf("GAP %s INTERSECTS %v", gap, tree.Intersecting(gap))
+				}
+			}
+		}
+		// the mutations reach the extremes too
+		tree := MustNewIntervalTree(intervals, mode...)
+		for _, in := range randomExtreme(rnd, 50, open) {
+			if err := tree.Insert(in); err != nil {
+				t.Fatalf("CANNOT INSERT %s: %v", in, err)
+			}
+			naive.Insert(in)
+		}
+		for _, in := range naive.All()[:naive.Len()/2] {
+			tree.Delete(in)
+			naive.Delete(in)
+		}
+		checkStructure(t, tree)
+		if err := CheckAgainstNaive(tree, naive, extremeQueries(rnd)); err != nil {
+			t.Fatalf("AFTER MUTATIONS: %v", err)
+		}
+		end, _ := tree.MaxEnd()
+		if err := tree.Translate(1); end == math.MaxInt && err == nil {
+			t.Fatalf("TRANSLATING BEYOND math.MaxInt MUST FAIL")
+		}
+	}
+
+	whole := &Interval{Start: math.MinInt, End: math.MaxInt}
+	full := MustNewIntervalTree([]*Interval{whole})
+	length := full.CoveredLength(whole)
+	if whole.Length() != math.MaxInt || full.TotalCoveredLength() != math.MaxInt || length != math.MaxInt {
+		t.Fatalf("THE LENGTH OF THE FULL RANGE MUST SATURATE AT math.MaxInt")
+	}
+	if len(full.Containing(math.MinInt)) != 1 || len(full.Containing(math.MaxInt)) != 1 || !full.IsCovered(whole) {
+		t.Fatalf("THE FULL RANGE HOLDS BOTH EXTREMES")
+	}
+	if gaps := full.Gaps(whole); len(gaps) != 0 {
+		t.Fatalf("THE FULL RANGE HAS NO GAP, GOT %v", gaps)
+	}
+}
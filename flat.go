@@ -0,0 +1,229 @@
+package intervaltree
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// -----------------------------------------------------
+// 				FLAT LAYOUT
+// -----------------------------------------------------
+
+// CompactIntervalTree is a read-only IntervalIndex answering the queries of an IntervalTree from a handful of flat
+// slices instead of a node and a few slices per median: it suits the very large indexes built once and queried
+// many times. The nodes are laid out implicitly in Eytzinger order, the children of node k being 2k+1 and 2k+2,
+// on the distinct first points of the intervals so that the tree is complete. Each interval belongs to the first
+// node on the path from the root whose median it holds, and the intervals of a node are a range of shared arrays
+// holding their first and last points next to their positions, sorted by start and by end, so that a query reads
+// the points in sequence and only loads the intervals it returns.
+// It holds at most math.MaxInt32 intervals and ignores WithMultiplicity, each *Interval being stored once
+type CompactIntervalTree struct {
+	intervals []*Interval // the stored intervals sorted by start, referenced by their position
+	xMid      []int       // the median of every node, in Eytzinger order
+	offsets   []int32     // the intervals of the node k are at [offsets[k], offsets[k+1]) in the arrays below
+	firsts    []int       // the first point of the intervals of every node, sorted by start
+	byStart   []int32     // their positions in intervals
+	lasts     []int       // the last point of the intervals of every node, sorted by end in descending order
+	byEnd     []int32     // their positions in intervals
+	open      int         // 1 if the intervals are half-open, see WithHalfOpenIntervals
+	swap      bool        // see WithSwappedQueries
+}
+
+var _ IntervalIndex = (*CompactIntervalTree)(nil)
+
+// NewCompactIntervalTree creates a CompactIntervalTree holding the intervals given in parameter. They are checked,
+// normalized and deduplicated as NewIntervalTree does with the same options, without building an IntervalTree
+// Build complexity: O(n log n), n = len(intervals)
+func NewCompactIntervalTree(intervals []*Interval, opts ...Option) (*CompactIntervalTree, error) {
+	cfg := newConfig(opts)
+	cfg.smallThreshold, cfg.checked = math.MaxInt, false // only sorted, never indexed
+	t, err := newIntervalTree(intervals, cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	return t.Flatten()
+}
+
+// Flatten returns a CompactIntervalTree holding the intervals of the IntervalTree, its queries returning the same
+// intervals. It shares the *Interval pointers and is not updated by the later mutations of the IntervalTree. It
+// fails with ErrTooManyIntervals above math.MaxInt32 intervals
+// Complexity: O(n log n), n = len(intervals in struct)
+func (t *IntervalTree) Flatten() (*CompactIntervalTree, error) {
+	t = t.orEmpty()
+	t.heal()
+	intervals := t.small
+	if intervals == nil {
+		intervals = t.intervals()
+	}
+	if len(intervals) > math.MaxInt32 {
+		return nil, fmt.Errorf("%w: %d", ErrTooManyIntervals, len(intervals))
+	}
+	return flatten(intervals, t.tie, t.open, t.swap), nil
+}
+
+// flatten lays the intervals out as a CompactIntervalTree. Sorting the positions once by start and once by end and
+// spreading them over the nodes in that order sorts the range of every node
+func flatten(intervals []*Interval, tie func(a, b *Interval) bool, open int, swap bool) *CompactIntervalTree {
+	byStart := func(a, b *Interval) bool { return startOrder(a, b, tie) }
+	byEnd := func(a, b *Interval) bool { return endOrder(a, b, tie) }
+	sorted := slices.Clone(intervals)
+	slices.SortStableFunc(
+		sorted, func(a, b *Interval) int {
+			return compareBy(a, b, byStart)
+		},
+	)
+	c := &CompactIntervalTree{intervals: sorted, open: open, swap: swap}
+	var keys []int
+	for i, in := range sorted {
+		if i == 0 || in.first() != sorted[i-1].first() {
+			keys = append(keys, in.first())
+		}
+	}
+	c.xMid = make([]int, len(keys))
+	next := 0
+	eytzinger(keys, c.xMid, 0, &next)
+
+	// every first point is a median, so the search of the first point of an interval stops at a node holding it
+	node := make([]int32, len(sorted))
+	c.offsets = make([]int32, len(keys)+1)
+	for i, in := range sorted {
+		k := 0
+		for lastPoint(in, open) < c.xMid[k] || in.first() > c.xMid[k] {
+			if in.first() > c.xMid[k] {
+				k = 2*k + 2
+			} else {
+				k = 2*k + 1
+			}
+		}
+		node[i] = int32(k)
+		c.offsets[k+1]++
+	}
+	for k := 1; k < len(c.offsets); k++ {
+		c.offsets[k] += c.offsets[k-1]
+	}
+
+	c.firsts, c.byStart = make([]int, len(sorted)), make([]int32, len(sorted))
+	fill := slices.Clone(c.offsets[:len(keys)])
+	for i, in := range sorted {
+		at := fill[node[i]]
+		c.firsts[at], c.byStart[at] = in.first(), int32(i)
+		fill[node[i]]++
+	}
+	ends := make([]int32, len(sorted))
+	for i := range ends {
+		ends[i] = int32(i)
+	}
+	slices.SortStableFunc(
+		ends, func(a, b int32) int {
+			return compareBy(sorted[a], sorted[b], byEnd)
+		},
+	)
+	c.lasts, c.byEnd = make([]int, len(sorted)), make([]int32, len(sorted))
+	copy(fill, c.offsets)
+	for _, i := range ends {
+		at := fill[node[i]]
+		c.lasts[at], c.byEnd[at] = lastPoint(sorted[i], open), i
+		fill[node[i]]++
+	}
+	return c
+}
+
+// eytzinger stores the sorted keys in xMid in Eytzinger order, from the node k, next being the next key to store
+func eytzinger(keys, xMid []int, k int, next *int) {
+	if k >= len(xMid) {
+		return
+	}
+	eytzinger(keys, xMid, 2*k+1, next)
+	xMid[k] = keys[*next]
+	*next++
+	eytzinger(keys, xMid, 2*k+2, next)
+}
+
+// compareBy compares a and b in the order less for slices.SortStableFunc
+func compareBy(a, b *Interval, less func(a, b *Interval) bool) int {
+	switch {
+	case less(a, b):
+		return -1
+	case less(b, a):
+		return 1
+	}
+	return 0
+}
+
+// Containing returns all the intervals containing the value x, node by node from the root
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
+func (c *CompactIntervalTree) Containing(x int) []*Interval {
+	var res []*Interval
+	for k := 0; k < len(c.xMid); {
+		from, to := c.offsets[k], c.offsets[k+1]
+		switch {
+		case x < c.xMid[k]:
+			for i := from; i < to && c.firsts[i] <= x; i++ {
+				res = append(res, c.intervals[c.byStart[i]])
+			}
+			k = 2*k + 1
+		case x > c.xMid[k]:
+			for i := from; i < to && c.lasts[i] >= x; i++ {
+				res = append(res, c.intervals[c.byEnd[i]])
+			}
+			k = 2*k + 2
+		default:
+			for i := from; i < to; i++ {
+				res = append(res, c.intervals[c.byStart[i]])
+			}
+			return res
+		}
+	}
+	return res
+}
+
+// Intersecting returns all the intervals intersecting the Interval given in parameter, node by node from the root.
+// A nil or reversed query returns nothing unless built WithSwappedQueries, as for the IntervalTree
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
+func (c *CompactIntervalTree) Intersecting(interval *Interval) []*Interval {
+	interval, err := queryWindow(interval, c.swap)
+	if err != nil || vacant(interval, c.open) {
+		return nil
+	}
+	start, end := interval.first(), lastPoint(interval, c.open)
+	var res []*Interval
+	stack := []int{0}
+	for len(stack) > 0 {
+		k := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if k >= len(c.xMid) {
+			continue
+		}
+		from, to := c.offsets[k], c.offsets[k+1]
+		switch {
+		case end < c.xMid[k]: // the right subtree starts after the query
+			for i := from; i < to && c.firsts[i] <= end; i++ {
+				res = append(res, c.intervals[c.byStart[i]])
+			}
+			stack = append(stack, 2*k+1)
+		case start > c.xMid[k]: // the left subtree ends before the query
+			for i := from; i < to && c.lasts[i] >= start; i++ {
+				res = append(res, c.intervals[c.byEnd[i]])
+			}
+			stack = append(stack, 2*k+2)
+		default:
+			for i := from; i < to; i++ {
+				res = append(res, c.intervals[c.byStart[i]])
+			}
+			stack = append(stack, 2*k+2, 2*k+1)
+		}
+	}
+	return res
+}
+
+// All returns every stored interval in a new slice sorted by Start
+// Complexity: O(n), n = len(intervals in struct)
+func (c *CompactIntervalTree) All() []*Interval {
+	return slices.Clone(c.intervals)
+}
+
+// Len returns the number of stored intervals
+func (c *CompactIntervalTree) Len() int {
+	return len(c.intervals)
+}
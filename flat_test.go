@@ -0,0 +1,128 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCompactIntervalTree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 300; i++ {
+		open := rnd.Intn(2)
+		var intervals []*Interval
+		switch {
+		case i%10 == 0:
+			intervals = randomExtreme(rnd, rnd.Intn(50), open)
+		case open == 1:
+			intervals = randomHalfOpen(rnd, rnd.Intn(500), 2000, 1+rnd.Intn(300))
+		default:
+			intervals = randomFlagged(rnd, rnd.Intn(500), 2000, 1+rnd.Intn(300))
+		}
+		opts := []Option{WithSwappedQueries()}
+		if open == 1 {
+			opts = append(opts, WithHalfOpenIntervals())
+		}
+		tree := MustNewIntervalTree(intervals, opts...)
+		flat, err := tree.Flatten()
+		if err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		built, err := NewCompactIntervalTree(intervals, opts...)
+		if err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		for _, c := range []*CompactIntervalTree{flat, built} {
+			if c.Len() != tree.Len() || !sameSet(c.All(), tree.All()) {
+				t.Fatalf("EXPECTING %s, GOT %s", listOf(tree.All()), listOf(c.All()))
+			}
+			queries := extremeQueries(rnd)
+			for q := 0; q < 50; q++ {
+				x := rnd.Intn(2200) - 100
+				queries = append(queries, Query{X: x}, Query{Window: &Interval{Start: x, End: x + rnd.Intn(400) - 200}})
+			}
+			for _, q := range queries {
+				want, got := tree.Containing(q.X), c.Containing(q.X)
+				if q.Window != nil {
+					q.Window.StartOpen, q.Window.EndOpen = rnd.Intn(2) == 0, rnd.Intn(2) == 0
+					want, got = tree.Intersecting(q.Window), c.Intersecting(q.Window)
+				}
+				if !sameSet(want, got) {
+					t.Fatalf("%s: EXPECTING %s, GOT %s", q, listOf(want), listOf(got))
+				}
+			}
+		}
+	}
+	c, err := NewCompactIntervalTree([]*Interval{{Start: 1, End: 5}})
+	if err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	if c.Intersecting(nil) != nil || c.Intersecting(&Interval{Start: 5, End: 1}) != nil {
+		t.Fatalf("A NIL OR REVERSED QUERY MUST RETURN NOTHING")
+	}
+	if _, err := NewCompactIntervalTree([]*Interval{{Start: 3, End: 3, EndOpen: true}}, WithHalfOpenIntervals()); err == nil {
+		t.Fatalf("EXPECTING AN ERROR ON AN EMPTY INTERVAL")
+	}
+	if empty, err := (*IntervalTree)(nil).Flatten(); err != nil || empty.Len() != 0 || empty.Containing(0) != nil {
+		t.Fatalf("A NIL TREE MUST FLATTEN TO AN EMPTY ONE")
+	}
+}
+
+// benchmarkCompactIntervals are the 10M intervals of the benchmarks of the flat layout
+func benchmarkCompactIntervals() []*Interval {
+	return randomIntervals(rand.New(rand.NewSource(1)), 10_000_000, 1_000_000_000, 10_000)
+}
+
+// benchmarkQueries runs random Containing and Intersecting queries on an index of benchmarkCompactIntervals
+func benchmarkQueries(b *testing.B, idx IntervalIndex) {
+	rnd := rand.New(rand.NewSource(2))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := rnd.Intn(1_000_000_000)
+		_ = idx.Containing(x)
+		_ = idx.Intersecting(&Interval{Start: x, End: x + 1000})
+	}
+}
+
+func BenchmarkCompact_Queries(b *testing.B) {
+	benchmarkQueries(b, mustIndex(NewCompactIntervalTree(benchmarkCompactIntervals())))
+}
+
+func BenchmarkCompact_Queries_Tree(b *testing.B) {
+	benchmarkQueries(b, mustIndex(NewIntervalTree(benchmarkCompactIntervals())))
+}
+
+// benchmarkHeapObjects reports the number of heap objects allocated to index benchmarkCompactIntervals, the
+// intervals themselves apart
+func benchmarkHeapObjects(b *testing.B, build func([]*Interval) IntervalIndex) {
+	intervals := benchmarkCompactIntervals()
+	var before, after runtime.MemStats
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		idx := build(intervals)
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.HeapObjects-before.HeapObjects), "heap-objects")
+		b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(len(intervals)), "heap-bytes/interval")
+		runtime.KeepAlive(idx)
+	}
+}
+
+func BenchmarkCompact_HeapObjects(b *testing.B) {
+	benchmarkHeapObjects(
+		b, func(intervals []*Interval) IntervalIndex {
+			return mustIndex(NewCompactIntervalTree(intervals))
+		},
+	)
+}
+
+func BenchmarkCompact_HeapObjects_Tree(b *testing.B) {
+	benchmarkHeapObjects(
+		b, func(intervals []*Interval) IntervalIndex {
+			return mustIndex(NewIntervalTree(intervals))
+		},
+	)
+}
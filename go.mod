@@ -1,8 +1,3 @@
 module github.com/ag0st/intervaltree
 
-go 1.18
-
-require (
-	github.com/ag0st/binarytree v0.0.0-20220412222724-22db34257cac
-	github.com/ag0st/bst v0.0.0-20220412222951-523e62820e13
-)
+go 1.23
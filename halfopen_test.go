@@ -0,0 +1,468 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+// closedCopies returns closed intervals holding the same points as the intervals, from their first to their last
+// point, each copy having its original as payload
+func closedCopies(intervals []*Interval, open int) []*Interval {
+	res := make([]*Interval, len(intervals))
+	for i, in := range intervals {
+		res[i] = &Interval{Start: in.first(), End: lastPoint(in, open), Payload: in}
+	}
+	return res
+}
+
+// originals returns the intervals the closed copies were made from, see closedCopies
+func originals(copies []*Interval) []*Interval {
+	res := make([]*Interval, len(copies))
+	for i, c := range copies {
+		res[i] = c.Payload.(*Interval)
+	}
+	return res
+}
+
+// randomHalfOpen generates n half-open intervals with bounds in [0, maxCoord + 1], each holding at least one point
+func randomHalfOpen(rnd *rand.Rand, n, maxCoord, maxLength int) []*Interval {
+	intervals := randomIntervals(rnd, n, maxCoord, maxLength)
+	for _, in := range intervals {
+		in.End++
+	}
+	return intervals
+}
+
+// treesOf builds the intervals with the options in every way a tree can be built
+func treesOf(t *testing.T, intervals []*Interval, opts ...Option) []*IntervalTree {
+	t.Helper()
+	materialized := append(slices.Clone(opts), WithSmallThreshold(0))
+	b := NewBuilder(materialized...)
+	if err := b.AddAll(intervals); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	built, err := b.Build()
+	if err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	sorted := slices.Clone(intervals)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].lessStart(sorted[j]) })
+	fromSorted, err := NewIntervalTreeFromSorted(sorted, materialized...)
+	if err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	inserted := NewEmptyIntervalTree(opts...)
+	for _, in := range intervals {
+		if err := inserted.Insert(in); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+	}
+	return []*IntervalTree{
+		MustNewIntervalTree(intervals, opts...), MustNewIntervalTree(intervals, materialized...), built, fromSorted, inserted,
+	}
+}
+
+func TestHalfOpen_Queries(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomHalfOpen(rnd, rnd.Intn(300), 1000, 50)
+		// touching intervals do not intersect
+		intervals = append(intervals, &Interval{Start: 500, End: 510}, &Interval{Start: 510, End: 520})
+		shadow := MustNewIntervalTree(closedCopies(intervals, 1))
+		for _, tree := range treesOf(t, intervals, WithHalfOpenIntervals()) {
+			checkStructure(t, tree)
+			checkQueries(t, rnd, tree, intervals, 1000)
+			for q := 0; q < 20; q++ {
+				x := rnd.Intn(1100)
+				if q == 0 {
+					x = 510
+				}
+				want := originals(shadow.Containing(x))
+				if got := tree.Containing(x); !sameIntervals(got, want) {
+					t.Fatalf("CONTAINING(%d): EXPECTING %v, GOT %v", x, want, got)
+				}
+				if got := slices.Collect(tree.ContainingSeq(x)); !sameIntervals(got, want) {
+					t.Fatalf("CONTAININGSEQ(%d): EXPECTING %v, GOT %v", x, want, got)
+				}
+				if got := tree.ContainingWith(x); !sameIntervals(got, want) {
+					t.Fatalf("CONTAININGWITH(%d): EXPECTING %v, GOT %v", x, want, got)
+				}
+				if got := tree.CountContaining(x); got != len(want) {
+					t.Fatalf("COUNTCONTAINING(%d): EXPECTING %d, GOT %d", x, len(want), got)
+				}
+
+				query := &Interval{Start: x, End: x + rnd.Intn(100)} // may be empty
+				if q == 0 {
+					query = &Interval{Start: 500, End: 510}
+				}
+				want = nil
+				if query.Start < query.End {
+					want = originals(shadow.Intersecting(&Interval{Start: query.Start, End: query.End - 1}))
+				}
+				if got := tree.Intersecting(query); !sameIntervals(got, want) {
+					t.Fatalf("INTERSECTING(%s): EXPECTING %v, GOT %v", query, want, got)
+				}
+				if got := slices.Collect(tree.IntersectingSeq(query)); !sameIntervals(got, want) {
+					t.Fatalf("INTERSECTINGSEQ(%s): EXPECTING %v, GOT %v", query, want, got)
+				}
+				if got := tree.IntersectingWith(query); !sameIntervals(got, want) {
+					t.Fatalf("INTERSECTINGWITH(%s): EXPECTING %v, GOT %v", query, want, got)
+				}
+			}
+		}
+	}
+
+	tree := MustNewIntervalTree([]*Interval{{Start: 0, End: 10}, {Start: 10, End: 20}}, WithHalfOpenIntervals())
+	if got := tree.Containing(10); len(got) != 1 || got[0].Start != 10 {
+		t.Fatalf("CONTAINING(10) MUST ONLY RETURN [10, 20), GOT %v", got)
+	}
+	if got := tree.Intersecting(&Interval{Start: 0, End: 10}); len(got) != 1 || got[0].Start != 0 {
+		t.Fatalf("[0, 10) MUST ONLY INTERSECT ITSELF, GOT %v", got)
+	}
+	if got := tree.Intersecting(&Interval{Start: 5, End: 5}); len(got) != 0 {
+		t.Fatalf("AN EMPTY QUERY INTERSECTS NOTHING, GOT %v", got)
+	}
+}
+
+func TestHalfOpen_Mutations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomHalfOpen(rnd, rnd.Intn(200), 1000, 100)
+		tree := MustNewIntervalTree(intervals, WithHalfOpenIntervals(), WithSmallThreshold(0))
+		for k := 0; k < 200; k++ {
+			switch op := rnd.Intn(4); {
+			case op == 0 || len(intervals) == 0:
+				in := randomHalfOpen(rnd, 1, 1000, 100)[0]
+				if err := tree.Insert(in); err != nil {
+					t.Fatalf("UNEXPECTED ERROR %v", err)
+				}
+				intervals = append(intervals, in)
+			case op == 1:
+				j := rnd.Intn(len(intervals))
+				if !tree.Delete(intervals[j]) {
+					t.Fatalf("CANNOT DELETE %s", intervals[j])
+				}
+				intervals = slices.Delete(intervals, j, j+1)
+			case op == 2:
+				in := intervals[rnd.Intn(len(intervals))]
+				start := rnd.Intn(1000)
+				if err := tree.Replace(in, start, start+1+rnd.Intn(100)); err != nil {
+					t.Fatalf("UNEXPECTED ERROR %v", err)
+				}
+			default:
+				x := rnd.Intn(1000)
+				window := &Interval{Start: x, End: x + rnd.Intn(5)}
+				removed := tree.DeleteIntersecting(window)
+				intervals = slices.DeleteFunc(intervals, func(in *Interval) bool { return slices.Contains(removed, in) })
+				for _, in := range removed {
+					if in.Start >= window.End || in.End <= window.Start {
+						t.Fatalf("%s DOES NOT INTERSECT %s", in, window)
+					}
+				}
+			}
+		}
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, intervals, 1000)
+		batch := randomHalfOpen(rnd, rnd.Intn(300), 1000, 100)
+		if err := tree.ExtendWith(batch); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		intervals = append(intervals, batch...)
+		tree.Rebuild()
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, intervals, 1000)
+	}
+}
+
+func TestHalfOpen_Coverage(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomHalfOpen(rnd, rnd.Intn(60), 500, 40)
+		tree := MustNewIntervalTree(intervals, WithHalfOpenIntervals())
+		shadow := MustNewIntervalTree(closedCopies(intervals, 1))
+		if tree.TotalCoveredLength() != shadow.TotalCoveredLength() {
+			t.Fatalf("EXPECTING %d COVERED, GOT %d", shadow.TotalCoveredLength(), tree.TotalCoveredLength())
+		}
+		compact, want := tree.Compact(), shadow.Compact()
+		for k := range want {
+			want[k].End++
+		}
+		if !slices.Equal(compact, want) {
+			t.Fatalf("COMPACT: EXPECTING %v, GOT %v", want, compact)
+		}
+		for k, in := range tree.MergeOverlapping() {
+			if *in != want[k] {
+				t.Fatalf("MERGEOVERLAPPING: EXPECTING %v, GOT %v", want[k], *in)
+			}
+		}
+		if end, ok := tree.MaxEnd(); len(intervals) > 0 && (!ok || end != want[len(want)-1].End) {
+			t.Fatalf("MAXEND: EXPECTING %d, GOT %d", want[len(want)-1].End, end)
+		}
+		if x, depth := tree.MaxOverlapPoint(); depth != len(tree.Containing(x)) {
+			t.Fatalf("MAXOVERLAPPOINT: %d IS CONTAINED BY %d INTERVALS, NOT %d", x, len(tree.Containing(x)), depth)
+		} else if wantX, wantDepth := shadow.MaxOverlapPoint(); x != wantX || depth != wantDepth {
+			t.Fatalf("MAXOVERLAPPOINT: EXPECTING %d AT %d, GOT %d AT %d", wantDepth, wantX, depth, x)
+		}
+		for q := 0; q < 20; q++ {
+			x := rnd.Intn(600)
+			window := &Interval{Start: x, End: x + 1 + rnd.Intn(60)}
+			closed := &Interval{Start: window.Start, End: window.End - 1}
+			if got, want := tree.CoveredLength(window), shadow.CoveredLength(closed); got != want {
+				t.Fatalf("COVEREDLENGTH(%s): EXPECTING %d, GOT %d", window, want, got)
+			}
+			if got, want := tree.IsCovered(window), shadow.IsCovered(closed); got != want {
+				t.Fatalf("ISCOVERED(%s): EXPECTING %v, GOT %v", window, want, got)
+			}
+			gaps, wantGaps := tree.Gaps(window), shadow.Gaps(closed)
+			if len(gaps) != len(wantGaps) {
+				t.Fatalf("GAPS(%s): EXPECTING %v, GOT %v", window, wantGaps, gaps)
+			}
+			for k, g := range gaps {
+				if g.Start != wantGaps[k].Start || g.End != wantGaps[k].End+1 {
+					t.Fatalf("GAPS(%s): EXPECTING %v, GOT %v", window, wantGaps, gaps)
+				}
+			}
+			profile, wantProfile := tree.CoverageProfile(window), shadow.CoverageProfile(closed)
+			for k := range wantProfile {
+				wantProfile[k].End++
+			}
+			if !slices.Equal(profile, wantProfile) {
+				t.Fatalf("COVERAGEPROFILE(%s): EXPECTING %v, GOT %v", window, wantProfile, profile)
+			}
+		}
+	}
+
+	tree := MustNewIntervalTree(
+		[]*Interval{{Start: 1, End: 4}, {Start: 4, End: 7}, {Start: 8, End: 9}}, WithHalfOpenIntervals(),
+	)
+	if got := tree.Compact(); !slices.Equal(got, []Interval{{Start: 1, End: 7}, {Start: 8, End: 9}}) {
+		t.Fatalf("EXPECTING [1, 7) AND [8, 9), GOT %v", got)
+	}
+	if got := tree.Gaps(&Interval{Start: 0, End: 10}); len(got) != 3 || *got[1] != (Interval{Start: 7, End: 8}) {
+		t.Fatalf("EXPECTING THE GAPS [0, 1), [7, 8) AND [9, 10), GOT %v", got)
+	}
+	if got := tree.Coalesce(nil); len(got) != 2 || got[0].End != 7 {
+		t.Fatalf("EXPECTING 2 COALESCED INTERVALS, GOT %v", got)
+	}
+	if !tree.IsCovered(&Interval{Start: 7, End: 7}) || tree.CoveredLength(&Interval{Start: 0, End: 100}) != 7 {
+		t.Fatalf("EXPECTING 7 COVERED COORDINATES, GOT %d", tree.CoveredLength(&Interval{Start: 0, End: 100}))
+	}
+}
+
+func TestHalfOpen_Algorithms(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomHalfOpen(rnd, rnd.Intn(60), 300, 30)
+		tree := MustNewIntervalTree(intervals, WithHalfOpenIntervals())
+		shadow := MustNewIntervalTree(closedCopies(intervals, 1))
+
+		points := tree.MinStabbingPoints()
+		if len(points) != len(shadow.MinStabbingPoints()) {
+			t.Fatalf("EXPECTING %d STABBING POINTS, GOT %d", len(shadow.MinStabbingPoints()), len(points))
+		}
+		for _, in := range intervals {
+			if !slices.ContainsFunc(points, func(x int) bool { return in.Start <= x && x < in.End }) {
+				t.Fatalf("%s IS NOT STABBED BY %v", in, points)
+			}
+		}
+		disjoint := tree.MaxDisjointSubset()
+		if len(disjoint) != len(shadow.MaxDisjointSubset()) {
+			t.Fatalf("EXPECTING %d DISJOINT INTERVALS, GOT %d", len(shadow.MaxDisjointSubset()), len(disjoint))
+		}
+		for k := 1; k < len(disjoint); k++ {
+			if disjoint[k].Start < disjoint[k-1].End {
+				t.Fatalf("%s AND %s INTERSECT", disjoint[k-1], disjoint[k])
+			}
+		}
+		pairs, want := 0, 0
+		for a := range intervals {
+			for b := a + 1; b < len(intervals); b++ {
+				if intervals[a].Start < intervals[b].End && intervals[b].Start < intervals[a].End {
+					want++
+				}
+			}
+		}
+		tree.OverlappingPairs(
+			func(a, b *Interval) bool {
+				if a.Start >= b.End || b.Start >= a.End {
+					t.Fatalf("%s AND %s DO NOT INTERSECT", a, b)
+				}
+				pairs++
+				return true
+			},
+		)
+		if pairs != want {
+			t.Fatalf("EXPECTING %d OVERLAPPING PAIRS, GOT %d", want, pairs)
+		}
+		if got, want := len(tree.ConnectedComponents()), len(shadow.ConnectedComponents()); got != want {
+			t.Fatalf("EXPECTING %d COMPONENTS, GOT %d", want, got)
+		}
+
+		var boundaries []int
+		for _, in := range intervals {
+			boundaries = append(boundaries, in.Start, in.End)
+		}
+		slices.Sort(boundaries)
+		if got := tree.Boundaries(); !slices.Equal(got, slices.Compact(boundaries)) {
+			t.Fatalf("BOUNDARIES: EXPECTING %v, GOT %v", slices.Compact(boundaries), got)
+		}
+		x := rnd.Intn(330)
+		var ending, starting []*Interval
+		for _, in := range intervals {
+			if in.End <= x {
+				ending = append(ending, in)
+			}
+			if in.Start >= x {
+				starting = append(starting, in)
+			}
+		}
+		if got := tree.EndingAt(x); !sameIntervals(got, ending) {
+			t.Fatalf("ENDINGAT(%d): EXPECTING %v, GOT %v", x, ending, got)
+		}
+		if got := tree.StartingAt(x); !sameIntervals(got, starting) {
+			t.Fatalf("STARTINGAT(%d): EXPECTING %v, GOT %v", x, starting, got)
+		}
+	}
+}
+
+func TestHalfOpen_Transforms(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomHalfOpen(rnd, rnd.Intn(200), 1000, 50)
+		tree := MustNewIntervalTree(intervals, WithHalfOpenIntervals(), WithSequenceNumbers())
+		x := rnd.Intn(1000)
+		left, right := tree.Split(x, SplitClip)
+		for _, in := range left.All() {
+			if in.End > x {
+				t.Fatalf("SPLIT(%d): %s ON THE LEFT", x, in)
+			}
+		}
+		for _, in := range right.All() {
+			if in.Start < x {
+				t.Fatalf("SPLIT(%d): %s ON THE RIGHT", x, in)
+			}
+		}
+		if left.TotalCoveredLength()+right.TotalCoveredLength() != tree.TotalCoveredLength() {
+			t.Fatalf("SPLIT(%d) MUST PARTITION THE COVERAGE", x)
+		}
+		window := &Interval{Start: x, End: x + 1 + rnd.Intn(100)}
+		clipped := tree.Clip(window)
+		checkStructure(t, clipped)
+		if clipped.Len() != len(tree.Intersecting(window)) || clipped.TotalCoveredLength() != tree.CoveredLength(window) {
+			t.Fatalf("CLIP(%s): EXPECTING %d INTERVALS COVERING %d, GOT %d COVERING %d", window,
+				len(tree.Intersecting(window)), tree.CoveredLength(window), clipped.Len(), clipped.TotalCoveredLength())
+		}
+
+		before := make([][]*Interval, 1100)
+		for k := range before {
+			before[k] = tree.Containing(k)
+		}
+		if err := tree.Translate(7); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		checkStructure(t, tree)
+		for k := range before {
+			if got := tree.Containing(k + 7); !sameIntervals(got, before[k]) {
+				t.Fatalf("TRANSLATE: CONTAINING(%d) MUST BE THE FORMER CONTAINING(%d)", k+7, k)
+			}
+		}
+		covered := tree.TotalCoveredLength()
+		if err := tree.Scale(2, 1, RoundFloor); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		checkStructure(t, tree)
+		if tree.TotalCoveredLength() != 2*covered {
+			t.Fatalf("SCALE: EXPECTING %d COVERED, GOT %d", 2*covered, tree.TotalCoveredLength())
+		}
+	}
+
+	tree := MustNewIntervalTree([]*Interval{{Start: 0, End: 1}, {Start: 4, End: 10}}, WithHalfOpenIntervals())
+	if err := tree.Scale(1, 2, RoundFloor); !errors.Is(err, ErrEmptyInterval) || tree.Len() != 2 {
+		t.Fatalf("EXPECTING ErrEmptyInterval FOR [0, 1) SCALED TO [0, 0), GOT %v", err)
+	}
+	left, right := tree.Split(4, SplitClip)
+	if left.Len() != 1 || right.Len() != 1 || right.All()[0].Start != 4 {
+		t.Fatalf("[4, 10) MUST GO WHOLE TO THE RIGHT, GOT %v AND %v", left.All(), right.All())
+	}
+}
+
+func TestHalfOpen_Errors(t *testing.T) {
+	empty := &Interval{Start: 5, End: 5}
+	if _, err := NewIntervalTree([]*Interval{{Start: 0, End: 3}, empty}, WithHalfOpenIntervals()); !errors.Is(err, ErrEmptyInterval) {
+		t.Fatalf("EXPECTING ErrEmptyInterval, GOT %v", err)
+	}
+	tree := NewEmptyIntervalTree(WithHalfOpenIntervals())
+	if err := tree.Insert(empty); !errors.Is(err, ErrEmptyInterval) || tree.Len() != 0 {
+		t.Fatalf("EXPECTING ErrEmptyInterval, GOT %v", err)
+	}
+	if err := NewBuilder(WithHalfOpenIntervals()).AddInterval(empty); !errors.Is(err, ErrEmptyInterval) {
+		t.Fatalf("EXPECTING ErrEmptyInterval, GOT %v", err)
+	}
+	in := &Interval{Start: 0, End: 3}
+	_ = tree.Insert(in)
+	if err := tree.Replace(in, 2, 2); !errors.Is(err, ErrEmptyInterval) || in.End != 3 {
+		t.Fatalf("EXPECTING ErrEmptyInterval, GOT %v", err)
+	}
+	if MustNewIntervalTree([]*Interval{empty}).Len() != 1 {
+		t.Fatalf("[5, 5] IS A VALID CLOSED INTERVAL")
+	}
+}
+
+func TestHalfOpen_MixedModes(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	as, bs := randomHalfOpen(rnd, 50, 300, 30), randomHalfOpen(rnd, 50, 300, 30)
+	a, b := MustNewIntervalTree(as, WithHalfOpenIntervals()), MustNewIntervalTree(bs, WithHalfOpenIntervals())
+	pairs, want := 0, 0
+	for _, x := range as {
+		for _, y := range bs {
+			if x.Start < y.End && y.Start < x.End {
+				want++
+			}
+		}
+	}
+	OverlapJoin(a, b, func(x, y *Interval) bool { pairs++; return true })
+	if pairs != want {
+		t.Fatalf("EXPECTING %d PAIRS, GOT %d", want, pairs)
+	}
+	merged := Merge(a, b)
+	checkStructure(t, merged)
+	checkQueries(t, rnd, merged, append(slices.Clone(as), bs...), 300)
+
+	closed := MustNewIntervalTree(randomIntervals(rnd, 50, 300, 30))
+	operations := map[string]func(){
+		"MERGE":        func() { Merge(a, closed) },
+		"MERGEINPLACE": func() { closed.MergeInPlace(a) },
+		"OVERLAPJOIN":  func() { OverlapJoin(closed, b, func(x, y *Interval) bool { return true }) },
+		"UNION":        func() { UnionTree(a, closed, EqualEndpoints) },
+		"INTERSECTION": func() { IntersectionTree(closed, a, EqualPointers) },
+		"DIFFERENCE":   func() { DifferenceTree(a, closed, EqualPointers) },
+	}
+	for name, op := range operations {
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, ErrMixedModes) {
+					t.Fatalf("%s: EXPECTING A PANIC WITH ErrMixedModes, GOT %v", name, err)
+				}
+			}()
+			op()
+		}()
+	}
+}
+
+func TestHalfOpen_Find(t *testing.T) {
+	tree := MustNewIntervalTree(
+		[]*Interval{{Start: 0, End: 10}, {Start: 10, End: 20}, {Start: 5, End: 6}}, WithHalfOpenIntervals(),
+	)
+	for _, in := range tree.All() {
+		if found := tree.Find(in.Start, in.End); len(found) != 1 || found[0] != in {
+			t.Fatalf("FIND(%d, %d): EXPECTING %s, GOT %v", in.Start, in.End, in, found)
+		}
+	}
+	if tree.Has(0, 9) || tree.Has(5, 5) || tree.Has(10, 19) {
+		t.Fatalf("ONLY THE EXACT HALF-OPEN ENDPOINTS ARE STORED")
+	}
+}
@@ -0,0 +1,134 @@
+package intervaltree
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// -----------------------------------------------------
+// 				INDEX BACKENDS
+// -----------------------------------------------------
+
+// IntervalIndex is the read API shared by the structures indexing a set of intervals, so that they can be swapped
+type IntervalIndex interface {
+	// Containing returns all the intervals containing the value x
+	Containing(x int) []*Interval
+	// Intersecting returns all the intervals intersecting the Interval given in parameter
+	Intersecting(interval *Interval) []*Interval
+	// All returns every stored interval in a new slice
+	All() []*Interval
+	// Len returns the number of stored intervals
+	Len() int
+}
+
+var (
+	_ IntervalIndex = (*IntervalTree)(nil)
+	_ IntervalIndex = (*SortedSliceIndex)(nil)
+)
+
+// AutoIndexThreshold is the number of intervals from which NewAutoIndex builds an IntervalTree
+const AutoIndexThreshold = 256
+
+// NewAutoIndex creates the IntervalIndex best suited to the number of intervals: a SortedSliceIndex below
+// AutoIndexThreshold, an IntervalTree from there
+func NewAutoIndex(intervals []*Interval) (IntervalIndex, error) {
+	var idx IntervalIndex
+	var err error
+	// assigned apart so that a failure gives a nil interface rather than a typed nil
+	if len(intervals) < AutoIndexThreshold {
+		var sorted *SortedSliceIndex
+		if sorted, err = NewSortedSliceIndex(intervals); err == nil {
+			idx = sorted
+		}
+	} else {
+		var tree *IntervalTree
+		if tree, err = NewIntervalTree(intervals); err == nil {
+			idx = tree
+		}
+	}
+	return idx, err
+}
+
+// SortedSliceIndex is an IntervalIndex keeping the intervals in a slice sorted by Start. A query binary searches
+// the intervals starting in the window extended on the left by the longest interval, the only ones able to reach it,
+// so it is fast as long as the intervals have close lengths.
+type SortedSliceIndex struct {
+	sorted  []*Interval
+	longest uint // greatest distance from the first to the last point of an interval
+}
+
+// NewSortedSliceIndex creates a SortedSliceIndex holding the intervals given in parameter, each *Interval once. It
+// fails as NewIntervalTree does on a nil or reversed interval
+// Build complexity: O(n log n), n = len(intervals)
+func NewSortedSliceIndex(intervals []*Interval) (*SortedSliceIndex, error) {
+	for i, in := range intervals {
+		if err := validate(in, 0); err != nil {
+			return nil, fmt.Errorf("interval %d: %w", i, err)
+		}
+	}
+	idx := &SortedSliceIndex{sorted: make([]*Interval, 0, len(intervals))}
+	if positions := distinctPointers(intervals, nil); positions != nil {
+		for _, pos := range positions {
+			idx.sorted = append(idx.sorted, intervals[pos])
+		}
+	} else {
+		idx.sorted = append(idx.sorted, intervals...)
+	}
+	sort.SliceStable(
+		idx.sorted, func(i, j int) bool {
+			return idx.sorted[i].lessStart(idx.sorted[j])
+		},
+	)
+	for _, in := range idx.sorted {
+		if length := uint(lastPoint(in, 0)) - uint(in.first()); length > idx.longest {
+			idx.longest = length
+		}
+	}
+	return idx, nil
+}
+
+// Containing returns all the intervals containing the value x, sorted by Start
+// Complexity: O(ln n + c), n = len(intervals in struct) and c = intervals starting in [x - longest, x]
+func (idx *SortedSliceIndex) Containing(x int) []*Interval {
+	return idx.Intersecting(&Interval{Start: x, End: x})
+}
+
+// Intersecting returns all the intervals intersecting the Interval given in parameter, sorted by Start. A nil,
+// reversed or empty query returns nothing, as with an IntervalTree built without WithSwappedQueries
+// Complexity: O(ln n + c), n = len(intervals in struct) and c = intervals starting in the window extended by the
+// longest interval
+func (idx *SortedSliceIndex) Intersecting(interval *Interval) []*Interval {
+	var res []*Interval
+	interval, err := queryWindow(interval, false)
+	if err != nil || vacant(interval, 0) {
+		return res
+	}
+	start, end := interval.first(), lastPoint(interval, 0)
+	// the first interval able to reach the window starts at start - longest, saturated at math.MinInt
+	lowest := math.MinInt
+	if fromMin := uint(start) - uint(math.MaxInt) - 1; fromMin > idx.longest {
+		lowest = start - int(idx.longest)
+	}
+	first := sort.Search(len(idx.sorted), func(i int) bool { return idx.sorted[i].first() >= lowest })
+	for _, in := range idx.sorted[first:] {
+		if in.first() > end {
+			break
+		}
+		if lastPoint(in, 0) >= start {
+			res = append(res, in)
+		}
+	}
+	return res
+}
+
+// All returns every stored interval in a new slice sorted by Start
+// Complexity: O(n), n = len(intervals in struct)
+func (idx *SortedSliceIndex) All() []*Interval {
+	return append([]*Interval(nil), idx.sorted...)
+}
+
+// Len returns the number of stored intervals
+func (idx *SortedSliceIndex) Len() int {
+	return len(idx.sorted)
+}
@@ -0,0 +1,104 @@
+package intervaltree
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalIndex(t *testing.T) {
+	backends := []struct {
+		name string
+		new  func([]*Interval) (IntervalIndex, error)
+	}{
+		{"TREE", func(intervals []*Interval) (IntervalIndex, error) { return NewIntervalTree(intervals) }},
+		{"FULL TREE", func(intervals []*Interval) (IntervalIndex, error) {
+			return NewIntervalTree(intervals, WithSmallThreshold(0))
+		}},
+		{"SORTED SLICE", func(intervals []*Interval) (IntervalIndex, error) { return NewSortedSliceIndex(intervals) }},
+		{"COMPACT", func(intervals []*Interval) (IntervalIndex, error) { return NewCompactIntervalTree(intervals) }},
+		{"AUTO", NewAutoIndex},
+	}
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		size := rnd.Intn(2 * AutoIndexThreshold)
+		intervals := randomIntervals(rnd, size, 5000, 1+rnd.Intn(500))
+		if size > 0 && rnd.Intn(4) == 0 {
+			intervals = append(intervals, intervals[0]) // stored once by every backend
+			intervals = append(intervals, &Interval{Start: math.MinInt, End: math.MaxInt})
+		}
+		distinct := make(map[*Interval]bool)
+		for _, in := range intervals {
+			distinct[in] = true
+		}
+		for _, backend := range backends {
+			idx, err := backend.new(intervals)
+			if err != nil {
+				t.Fatalf("%s: UNEXPECTED ERROR %v", backend.name, err)
+			}
+			if idx.Len() != len(distinct) || len(idx.All()) != len(distinct) {
+				t.Fatalf("%s: EXPECTING %d INTERVALS, GOT %d AND %d", backend.name, len(distinct), idx.Len(), len(idx.All()))
+			}
+			for _, in := range idx.All() {
+				if !distinct[in] {
+					t.Fatalf("%s: UNEXPECTED INTERVAL %s", backend.name, in)
+				}
+			}
+			for q := 0; q < 50; q++ {
+				x := rnd.Intn(5600) - 50
+				query := &Interval{Start: x, End: x + rnd.Intn(300)}
+				var containing, intersecting []*Interval
+				for in := range distinct {
+					if in.Start <= x && x <= in.End {
+						containing = append(containing, in)
+					}
+					if in.Start <= query.End && query.Start <= in.End {
+						intersecting = append(intersecting, in)
+					}
+				}
+				if got := idx.Containing(x); !sameIntervals(got, containing) {
+					t.Fatalf("%s: CONTAINING(%d): EXPECTING %v, GOT %v", backend.name, x, containing, got)
+				}
+				if got := idx.Intersecting(query); !sameIntervals(got, intersecting) {
+					t.Fatalf("%s: INTERSECTING(%s): EXPECTING %v, GOT %v", backend.name, query, intersecting, got)
+				}
+			}
+		}
+	}
+	// the queries holding no point return nothing from every backend
+	for _, backend := range backends {
+		idx, err := backend.new([]*Interval{{Start: 0, End: 20}})
+		if err != nil {
+			t.Fatalf("%s: UNEXPECTED ERROR %v", backend.name, err)
+		}
+		empty := []*Interval{nil, {Start: 10, End: 5}, {Start: 5, End: 5, EndOpen: true}, {Start: 5, End: 6, StartOpen: true, EndOpen: true}}
+		for _, query := range empty {
+			if got := idx.Intersecting(query); len(got) != 0 {
+				t.Fatalf("%s: INTERSECTING(%v): EXPECTING NOTHING, GOT %v", backend.name, query, got)
+			}
+		}
+	}
+	for _, backend := range backends {
+		if _, err := backend.new([]*Interval{{Start: 2, End: 1}}); !errors.Is(err, ErrReversedInterval) {
+			t.Fatalf("%s: EXPECTING %v, GOT %v", backend.name, ErrReversedInterval, err)
+		}
+	}
+	if idx, err := NewAutoIndex([]*Interval{nil}); idx != nil || !errors.Is(err, ErrNilInterval) {
+		t.Fatalf("EXPECTING A NIL INDEX AND %v, GOT %v AND %v", ErrNilInterval, idx, err)
+	}
+	if _, ok := mustIndex(NewAutoIndex(randomIntervals(rnd, AutoIndexThreshold, 100, 10))).(*IntervalTree); !ok {
+		t.Fatalf("EXPECTING AN INTERVALTREE AT THE THRESHOLD")
+	}
+	if _, ok := mustIndex(NewAutoIndex(randomIntervals(rnd, 10, 100, 10))).(*SortedSliceIndex); !ok {
+		t.Fatalf("EXPECTING A SORTEDSLICEINDEX UNDER THE THRESHOLD")
+	}
+}
+
+func mustIndex(idx IntervalIndex, err error) IntervalIndex {
+	if err != nil {
+		panic(err)
+	}
+	return idx
+}
@@ -0,0 +1,130 @@
+package intervaltree
+
+// -----------------------------------------------------
+// 				INTERVAL HELPERS
+// -----------------------------------------------------
+
+// The helpers follow the semantics of the queries of a tree of closed intervals: both endpoints belong to the
+// interval unless flagged open, so [1, 5] and [5, 9] overlap while [1, 5) and [5, 9] do not. An interval holding
+// no point, as [5, 5), overlaps and contains nothing. The intervals of a half-open tree must be flagged EndOpen to
+// be compared the way the tree does
+
+// ContainsPoint tells if the value x belongs to the interval
+// Complexity: O(1)
+func (interval *Interval) ContainsPoint(x int) bool {
+	return interval.first() <= x && x <= lastPoint(interval, 0) && !vacant(interval, 0)
+}
+
+// Overlaps tells if the interval and the other one have at least one point in common, as the intervals returned
+// by Intersecting for the query interval other
+// Complexity: O(1)
+func (interval *Interval) Overlaps(other *Interval) bool {
+	if vacant(interval, 0) || vacant(other, 0) {
+		return false
+	}
+	return interval.first() <= lastPoint(other, 0) && other.first() <= lastPoint(interval, 0)
+}
+
+// Contains tells if every point of the other interval belongs to the interval, as [1, 9] contains [1, 5] and
+// itself. An interval holding no point is never contained
+// Complexity: O(1)
+func (interval *Interval) Contains(other *Interval) bool {
+	if vacant(interval, 0) || vacant(other, 0) {
+		return false
+	}
+	return interval.first() <= other.first() && lastPoint(other, 0) <= lastPoint(interval, 0)
+}
+
+// Intersection returns the interval holding the points shared by the interval and the other one, false if they do
+// not overlap. Its endpoints and flags are taken from the interval starting last and the one ending first, so
+// [1, 5) and [3, 9] give [3, 5), and it holds a nil payload
+// Complexity: O(1)
+func (interval *Interval) Intersection(other *Interval) (*Interval, bool) {
+	if !interval.Overlaps(other) {
+		return nil, false
+	}
+	from, to := interval, interval
+	if other.first() > interval.first() {
+		from = other
+	}
+	if lastPoint(other, 0) < lastPoint(interval, 0) {
+		to = other
+	}
+	return &Interval{Start: from.Start, End: to.End, StartOpen: from.StartOpen, EndOpen: to.EndOpen}, true
+}
+
+// Union returns the smallest interval holding the points of both intervals, false if they neither overlap nor are
+// adjacent: [1, 3] and [4, 6] give [1, 6], as the coverage of a tree holding them, while [1, 3] and [5, 6] leave
+// the point 4 out. Its endpoints and flags are taken from the interval starting first and the one ending last, and
+// it holds a nil payload
+// Complexity: O(1)
+func (interval *Interval) Union(other *Interval) (*Interval, bool) {
+	if vacant(interval, 0) || vacant(other, 0) {
+		return nil, false
+	}
+	from, to := interval, interval
+	if other.first() < interval.first() {
+		from = other
+	}
+	if lastPoint(other, 0) > lastPoint(interval, 0) {
+		to = other
+	}
+	later := other
+	if from == other {
+		later = interval
+	}
+	if !touches(lastPoint(from, 0), later.first()) {
+		return nil, false
+	}
+	return &Interval{Start: from.Start, End: to.End, StartOpen: from.StartOpen, EndOpen: to.EndOpen}, true
+}
+
+// Gap returns the interval holding the points strictly between the interval and the other one, in either order,
+// false if they overlap or are adjacent. Its bounds are the complement of theirs, so [1, 3] and [6, 9] give the
+// open (3, 6) holding 4 and 5, and [1, 4) and [6, 9] give [4, 6). It holds a nil payload
+// Complexity: O(1)
+func (interval *Interval) Gap(other *Interval) (*Interval, bool) {
+	if vacant(interval, 0) || vacant(other, 0) {
+		return nil, false
+	}
+	earlier, later := interval, other
+	if other.first() < interval.first() {
+		earlier, later = other, interval
+	}
+	if touches(lastPoint(earlier, 0), later.first()) {
+		return nil, false
+	}
+	return &Interval{Start: earlier.End, End: later.Start, StartOpen: !earlier.EndOpen, EndOpen: !later.StartOpen}, true
+}
+
+// DistanceTo returns the number of points between the interval and the other one, 0 if they overlap or are
+// adjacent, saturated at math.MaxInt. It is the Length of their Gap: [1, 3] and [6, 9] are at distance 2
+// Complexity: O(1)
+func (interval *Interval) DistanceTo(other *Interval) int {
+	gap, ok := interval.Gap(other)
+	if !ok {
+		return 0
+	}
+	return gap.Length()
+}
+
+// Length returns the number of points of the interval, saturated at math.MaxInt: intervals are closed on the
+// integer grid so [3, 5] has length 3, (3, 5] and [3, 5) length 2. It is the measure used by the coverage of the
+// trees, and an interval holding no point, reversed or as [5, 5), has length 0
+// Complexity: O(1)
+func (interval *Interval) Length() int {
+	if vacant(interval, 0) || lastPoint(interval, 0) < interval.first() {
+		return 0
+	}
+	return measure(span(interval.first(), lastPoint(interval, 0)), true)
+}
+
+// LengthHalfOpen returns End - Start, saturated at math.MaxInt, the boundary flags being ignored: it is the length
+// of [Start, End) as stored by a tree of half-open intervals, 2 for [3, 5]. A reversed interval has length 0
+// Complexity: O(1)
+func (interval *Interval) LengthHalfOpen() int {
+	if interval.End <= interval.Start {
+		return 0
+	}
+	return saturated(uint64(interval.End) - uint64(interval.Start))
+}
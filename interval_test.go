@@ -0,0 +1,220 @@
+package intervaltree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestInterval_Helpers(t *testing.T) {
+	closed := func(start, end int) *Interval { return &Interval{Start: start, End: end} }
+	tests := []struct {
+		name                string
+		a, b                *Interval
+		overlaps, contains  bool
+		intersection, union *Interval // nil when the operation fails
+	}{
+		{"disjoint", closed(1, 3), closed(5, 6), false, false, nil, nil},
+		{"adjacent", closed(1, 3), closed(4, 6), false, false, nil, closed(1, 6)},
+		{"touching", closed(1, 5), closed(5, 9), true, false, closed(5, 5), closed(1, 9)},
+		{"crossing", closed(1, 6), closed(4, 9), true, false, closed(4, 6), closed(1, 9)},
+		{"nesting", closed(1, 9), closed(3, 5), true, true, closed(3, 5), closed(1, 9)},
+		{"nested", closed(3, 5), closed(1, 9), true, false, closed(3, 5), closed(1, 9)},
+		{"same start", closed(1, 9), closed(1, 5), true, true, closed(1, 5), closed(1, 9)},
+		{"same end", closed(1, 9), closed(5, 9), true, true, closed(5, 9), closed(1, 9)},
+		{"identical", closed(1, 9), closed(1, 9), true, true, closed(1, 9), closed(1, 9)},
+		{"point inside", closed(1, 9), closed(4, 4), true, true, closed(4, 4), closed(1, 9)},
+		{"point at end", closed(1, 9), closed(9, 9), true, true, closed(9, 9), closed(1, 9)},
+		{"point after", closed(1, 9), closed(10, 10), false, false, nil, closed(1, 10)},
+		{"same point", closed(4, 4), closed(4, 4), true, true, closed(4, 4), closed(4, 4)},
+		{
+			"open end touching", &Interval{Start: 1, End: 5, EndOpen: true}, closed(5, 9), false, false, nil,
+			closed(1, 9),
+		},
+		{
+			"open end crossing", &Interval{Start: 1, End: 5, EndOpen: true}, closed(3, 9), true, false,
+			&Interval{Start: 3, End: 5, EndOpen: true}, closed(1, 9),
+		},
+		{
+			"open start", &Interval{Start: 0, End: 9, StartOpen: true}, closed(1, 9), true, true, closed(1, 9),
+			&Interval{Start: 0, End: 9, StartOpen: true},
+		},
+		{
+			"open start touching", closed(1, 5), &Interval{Start: 5, End: 9, StartOpen: true}, false, false, nil,
+			&Interval{Start: 1, End: 9},
+		},
+		{"no point", &Interval{Start: 5, End: 5, EndOpen: true}, closed(1, 9), false, false, nil, nil},
+		{"inside no point", closed(1, 9), &Interval{Start: 5, End: 5, EndOpen: true}, false, false, nil, nil},
+	}
+	for _, test := range tests {
+		if got := test.a.Overlaps(test.b); got != test.overlaps {
+			t.Fatalf("%s: %s OVERLAPS %s: EXPECTING %t, GOT %t", test.name, test.a, test.b, test.overlaps, got)
+		}
+		if got := test.b.Overlaps(test.a); got != test.overlaps {
+			t.Fatalf("%s: OVERLAPS MUST BE SYMMETRIC", test.name)
+		}
+		if got := test.a.Contains(test.b); got != test.contains {
+			t.Fatalf("%s: %s CONTAINS %s: EXPECTING %t, GOT %t", test.name, test.a, test.b, test.contains, got)
+		}
+		for _, op := range []struct {
+			name string
+			f    func(a, b *Interval) (*Interval, bool)
+			want *Interval
+		}{
+			{"INTERSECTION", (*Interval).Intersection, test.intersection},
+			{"UNION", (*Interval).Union, test.union},
+		} {
+			for _, pair := range [][2]*Interval{{test.a, test.b}, {test.b, test.a}} {
+				got, ok := op.f(pair[0], pair[1])
+				if ok != (op.want != nil) || ok && !samePoints(got, op.want) {
+					t.Fatalf("%s: %s %s %s: EXPECTING %v, GOT %v (%t)", test.name, pair[0], op.name, pair[1], op.want, got, ok)
+				}
+			}
+		}
+	}
+
+	if got, _ := (&Interval{Start: 1, End: 5, EndOpen: true, Payload: 1}).Intersection(closed(3, 9)); !got.EndOpen {
+		t.Fatalf("THE INTERSECTION MUST KEEP THE OPEN END, GOT %s", got)
+	}
+
+	// the helpers agree with the queries of a tree
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomFlagged(rnd, rnd.Intn(200), 200, 20)
+		tree := MustNewIntervalTree(intervals)
+		query := randomFlagged(rnd, 1, 220, 20)[0]
+		var want []*Interval
+		for _, in := range intervals {
+			if in.Overlaps(query) {
+				want = append(want, in)
+			}
+		}
+		if got := tree.Intersecting(query); !sameIntervals(got, want) {
+			t.Fatalf("INTERSECTING(%s): EXPECTING %v, GOT %v", query, want, got)
+		}
+		x := rnd.Intn(220)
+		want = nil
+		for _, in := range intervals {
+			if in.ContainsPoint(x) {
+				want = append(want, in)
+			}
+		}
+		if got := tree.Containing(x); !sameIntervals(got, want) {
+			t.Fatalf("CONTAINING(%d): EXPECTING %v, GOT %v", x, want, got)
+		}
+	}
+}
+
+func TestInterval_Gap(t *testing.T) {
+	closed := func(start, end int) *Interval { return &Interval{Start: start, End: end} }
+	tests := []struct {
+		a, b     *Interval
+		gap      *Interval // nil when there is no gap
+		distance int
+	}{
+		{closed(1, 3), closed(6, 9), &Interval{Start: 3, End: 6, StartOpen: true, EndOpen: true}, 2},
+		{closed(1, 3), closed(5, 9), &Interval{Start: 3, End: 5, StartOpen: true, EndOpen: true}, 1},
+		{closed(1, 3), closed(4, 9), nil, 0},
+		{closed(1, 5), closed(5, 9), nil, 0},
+		{closed(1, 9), closed(3, 5), nil, 0},
+		{closed(1, 9), closed(1, 9), nil, 0},
+		{closed(4, 4), closed(7, 7), &Interval{Start: 4, End: 7, StartOpen: true, EndOpen: true}, 2},
+		{&Interval{Start: 1, End: 4, EndOpen: true}, closed(6, 9), &Interval{Start: 4, End: 6, EndOpen: true}, 2},
+		{&Interval{Start: 1, End: 4, EndOpen: true}, closed(4, 9), nil, 0},
+		{closed(1, 3), &Interval{Start: 3, End: 9, StartOpen: true}, nil, 0},
+		{closed(1, 3), &Interval{Start: 5, End: 9, StartOpen: true}, &Interval{Start: 3, End: 5, StartOpen: true}, 2},
+		{closed(1, 3), &Interval{Start: 6, End: 6, EndOpen: true}, nil, 0},
+		{
+			closed(math.MinInt, math.MinInt), closed(math.MaxInt, math.MaxInt),
+			&Interval{Start: math.MinInt, End: math.MaxInt, StartOpen: true, EndOpen: true}, math.MaxInt,
+		},
+		{closed(math.MinInt, -1), closed(1, math.MaxInt), &Interval{Start: -1, End: 1, StartOpen: true, EndOpen: true}, 1},
+	}
+	for _, test := range tests {
+		for _, pair := range [][2]*Interval{{test.a, test.b}, {test.b, test.a}} {
+			gap, ok := pair[0].Gap(pair[1])
+			if ok != (test.gap != nil) || ok && (gap.bounds() != test.gap.bounds() || gap.Payload != nil) {
+				t.Fatalf("GAP BETWEEN %s AND %s: EXPECTING %v, GOT %v (%t)", pair[0], pair[1], test.gap, gap, ok)
+			}
+			if got := pair[0].DistanceTo(pair[1]); got != test.distance {
+				t.Fatalf("DISTANCE BETWEEN %s AND %s: EXPECTING %d, GOT %d", pair[0], pair[1], test.distance, got)
+			}
+		}
+	}
+
+	// a gap holds exactly the points lying between two disjoint intervals
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 1000; i++ {
+		pair := randomFlagged(rnd, 2, 100, 20)
+		gap, ok := pair[0].Gap(pair[1])
+		points := 0
+		for x := -1; x <= 121; x++ {
+			in0, in1 := pair[0].ContainsPoint(x), pair[1].ContainsPoint(x)
+			between := !in0 && !in1 && (pair[0].first() < x) != (pair[1].first() < x) &&
+				(lastPoint(pair[0], 0) < x) != (lastPoint(pair[1], 0) < x)
+			if ok && gap.ContainsPoint(x) != between {
+				t.Fatalf("GAP %s BETWEEN %s AND %s MISPLACES %d", gap, pair[0], pair[1], x)
+			}
+			if between {
+				points++
+			}
+		}
+		if ok != (points > 0) || pair[0].DistanceTo(pair[1]) != points {
+			t.Fatalf("EXPECTING %d POINTS BETWEEN %s AND %s, GOT %v (%t)", points, pair[0], pair[1], gap, ok)
+		}
+	}
+}
+
+func TestInterval_Length(t *testing.T) {
+	for _, test := range []struct {
+		in               *Interval
+		length, halfOpen int
+	}{
+		{&Interval{Start: 3, End: 5}, 3, 2},
+		{&Interval{Start: 3, End: 5, EndOpen: true}, 2, 2},
+		{&Interval{Start: 3, End: 5, StartOpen: true}, 2, 2},
+		{&Interval{Start: 3, End: 5, StartOpen: true, EndOpen: true}, 1, 2},
+		{&Interval{Start: 4, End: 4}, 1, 0},
+		{&Interval{Start: 4, End: 4, EndOpen: true}, 0, 0},
+		{&Interval{Start: 5, End: 3}, 0, 0},
+		{&Interval{Start: -5, End: 5}, 11, 10},
+		{&Interval{Start: 0, End: math.MaxInt}, math.MaxInt, math.MaxInt},
+		{&Interval{Start: 1, End: math.MaxInt}, math.MaxInt, math.MaxInt - 1},
+		{&Interval{Start: math.MinInt, End: -1}, math.MaxInt, math.MaxInt},
+		{&Interval{Start: math.MinInt, End: -2}, math.MaxInt, math.MaxInt - 1},
+		{&Interval{Start: math.MinInt, End: 0}, math.MaxInt, math.MaxInt},
+		{&Interval{Start: math.MinInt, End: math.MaxInt}, math.MaxInt, math.MaxInt},
+		{&Interval{Start: math.MinInt, End: math.MaxInt, StartOpen: true, EndOpen: true}, math.MaxInt, math.MaxInt},
+		{&Interval{Start: math.MaxInt, End: math.MaxInt}, 1, 0},
+		{&Interval{Start: math.MaxInt, End: math.MaxInt, StartOpen: true}, 0, 0},
+		{&Interval{Start: math.MinInt, End: math.MinInt, EndOpen: true}, 0, 0},
+		{&Interval{Start: math.MaxInt, End: math.MinInt}, 0, 0},
+	} {
+		if got := test.in.Length(); got != test.length {
+			t.Fatalf("LENGTH OF %s: EXPECTING %d, GOT %d", test.in, test.length, got)
+		}
+		if got := test.in.LengthHalfOpen(); got != test.halfOpen {
+			t.Fatalf("HALF-OPEN LENGTH OF %s: EXPECTING %d, GOT %d", test.in, test.halfOpen, got)
+		}
+	}
+
+	// the coverage of a tree measures its intervals the same way
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		in := randomFlagged(rnd, 1, 1000, 100)[0]
+		single := MustNewIntervalTree([]*Interval{in})
+		if single.TotalCoveredLength() != in.Length() || single.CoveredLength(in) != in.Length() {
+			t.Fatalf("%s OF LENGTH %d COVERS %d", in, in.Length(), single.TotalCoveredLength())
+		}
+		half := MustNewIntervalTree([]*Interval{{Start: in.Start, End: in.End + 1}}, WithHalfOpenIntervals())
+		if got := half.TotalCoveredLength(); got != (&Interval{Start: in.Start, End: in.End + 1}).LengthHalfOpen() {
+			t.Fatalf("[ %d - %d ) COVERS %d", in.Start, in.End+1, got)
+		}
+	}
+}
+
+// samePoints tells if both intervals hold the same points and the first has a nil payload
+func samePoints(a, b *Interval) bool {
+	return a.Payload == nil && a.first() == b.first() && lastPoint(a, 0) == lastPoint(b, 0)
+}
@@ -2,10 +2,9 @@ package intervaltree
 
 import (
 	"fmt"
-	"github.com/ag0st/binarytree"
-	"github.com/ag0st/bst"
-	"log"
+	"math"
 	"sort"
+	"sync"
 )
 
 // -----------------------------------------------------
@@ -13,112 +12,586 @@ import (
 // -----------------------------------------------------
 
 // IntervalTree struct used to represent an interval tree
-// An IntervalTree is a simple BinaryTree with specific values as data. Here data are of type elt
+// An IntervalTree is a simple binary tree, see nodeTree, with specific values as data. Here data are of type elt
+// An empty IntervalTree returns empty results, and so does a nil *IntervalTree, except Insert and ExtendWith returning
+// ErrNilTree and MergeInPlace panicking with it as the intervals would be lost
 type IntervalTree struct {
-	tree *binarytree.BinaryTree
-	bst  *bst.BST
+	tree    *nodeTree
+	bst     *pointTree // first and last points of the intervals, searched by the endpoint queries
+	cover   *coverage
+	size    int                  // number of stored intervals
+	seq     map[*Interval]uint64 // insertion sequence numbers, nil if not recorded
+	nextSeq uint64
+	keyFunc func(*Interval) interface{} // key of the secondary index, nil if disabled
+	keys    map[interface{}][]*Interval // secondary index from key to intervals
+	epoch   uint64                      // incremented by every mutation to invalidate views and iterators
+
+	equal   func(a, b *Interval) bool // equality of the intervals with the same endpoints, nil to only compare them
+	tie     func(a, b *Interval) bool // order of the intervals with the same endpoints, nil for the input order
+	open    int                       // 1 if the intervals are half-open, their last point being End - 1, else 0
+	swap    bool                      // reversed query intervals are swapped, see WithSwappedQueries
+	checked bool                      // queries run CheckIntegrity, see WithIntegrityChecks
+	counts  map[*Interval]int         // multiplicity of every stored interval, nil if duplicates are stored
+
+	mutations int // mutations since the last build
+	rebuildAt int // mutations triggering a Rebuild on the next query, 0 if disabled
+
+	owner       *owner // shared with the snapshots of the structure, see Snapshot
+	snapshotted bool   // intervals shared with a snapshot, they must not be modified in place
+
+	small []*Interval // intervals sorted by Start while the tree is small and not mutated, nil otherwise
+	lazy  *sync.Once  // builds tree from small on first need, nil if it is built at construction
+	index *sync.Once  // builds bst from the nodes on first need, nil if it is built
 }
 
-// NewIntervalTree creates a new interval tree with the intervals given in parameters
-func NewIntervalTree(intervals []*Interval) *IntervalTree {
-	return &IntervalTree{fromIntervals(intervals[:]), buildBST(intervals[:])}
+// NewIntervalTree creates a new interval tree with the intervals given in parameters. It returns an error, and no
+// tree, if an interval is nil or reversed, the error naming its index, or if the intervals cannot be organized into
+// a valid structure. Reversed intervals are accepted with WithNormalization and WithInPlaceNormalization.
+// The tree is a set of *Interval: a pointer given several times is stored once, at its first occurrence
+func NewIntervalTree(intervals []*Interval, opts ...Option) (*IntervalTree, error) {
+	return newIntervalTree(intervals, newConfig(opts), nil)
 }
 
-// fromIntervals create a binary tree containing elt struct as data
-// Build complexity: O(n), n = len(intervals) cause of searching the median point
-func fromIntervals(intervals []*Interval) *binarytree.BinaryTree {
-	tree := &binarytree.BinaryTree{}
-	length := len(intervals)
-	if length == 0 {
-		return tree
+// newIntervalTree creates a new interval tree as NewIntervalTree does. The endpoints are sorted once for the whole
+// tree, in the buffers given or in new ones, see scratch
+func newIntervalTree(intervals []*Interval, cfg *config, s *scratch) (*IntervalTree, error) {
+	sorted := s != nil && s.sorted
+	if cfg.normalize {
+		intervals = normalize(intervals, cfg.inPlace)
 	}
-	// Get the xMid by creating array and sort it
-	allPoints := make([]int, length*2)
 	for i, in := range intervals {
-		allPoints[i] = in.Start
-		allPoints[length+i] = in.End
+		if err := validate(in, cfg.open); err != nil {
+			return nil, fmt.Errorf("interval %d: %w", i, err)
+		}
+		if sorted && i > 0 && in.first() < intervals[i-1].first() {
+			return nil, fmt.Errorf("interval %d: %w", i, ErrUnsorted)
+		}
 	}
+	input := intervals
+	var positions []int // position in the input of every kept interval, nil if all are kept
+	switch {
+	case cfg.counted:
+		positions = deduplicate(intervals, nil)
+	case cfg.dedup:
+		positions = deduplicate(intervals, cfg.equal)
+	case s != nil:
+		positions = distinctPointers(intervals, s.seen)
+	default:
+		positions = distinctPointers(intervals, nil)
+	}
+	if positions != nil {
+		kept := make([]*Interval, len(positions))
+		for i, pos := range positions {
+			kept[i] = intervals[pos]
+		}
+		intervals = kept
+	}
+	if cfg.copied {
+		backing := make([]Interval, len(intervals))
+		for i, in := range intervals {
+			backing[i] = *in
+		}
+		intervals = pointersTo(backing)
+	}
+	t := &IntervalTree{
+		size:  len(intervals),
+		owner: new(owner),
 
-	sort.Ints(allPoints)
-	xMid := allPoints[length]
-
-	// divide left and right part
-	var left []*Interval
-	var right []*Interval
-	var mid []*Interval
-	for _, in := range intervals {
-		if in.End < xMid {
-			left = append(left, in)
-		} else if in.Start > xMid {
-			right = append(right, in)
+		equal:     cfg.equal,
+		tie:       cfg.tie,
+		open:      cfg.open,
+		swap:      cfg.swap,
+		checked:   cfg.checked,
+		rebuildAt: cfg.rebuildAt,
+	}
+	switch {
+	case len(intervals) < cfg.smallThreshold && !cfg.checked:
+		t.setSmall(intervals)
+	default:
+		if s == nil {
+			s = &scratch{}
+		}
+		ends, buf := s.endpoints(intervals, cfg.open)
+		if cfg.eager || cfg.checked {
+			t.bst = newPointTree(pointsOf(ends))
 		} else {
-			mid = append(mid, in)
+			t.index = new(sync.Once)
+		}
+		t.cover = coverageOf(ends)
+		t.tree = fromEndpoints(ends, buf, &s.mid, newArena(len(intervals)), cfg.tie, cfg.open) // reorders ends
+	}
+	if cfg.sequence {
+		t.seq = make(map[*Interval]uint64, len(intervals))
+		for i, in := range intervals {
+			if positions != nil {
+				i = positions[i]
+			}
+			t.seq[in] = uint64(i)
+		}
+		t.nextSeq = uint64(len(input))
+	}
+	if cfg.counted {
+		t.counts = make(map[*Interval]int, len(intervals))
+		representatives := make(map[bounds]*Interval, len(intervals))
+		for _, in := range intervals {
+			representatives[in.bounds()] = in
+		}
+		for _, in := range input {
+			t.counts[representatives[in.bounds()]]++
 		}
 	}
+	if cfg.keyFunc != nil {
+		t.keyFunc = cfg.keyFunc
+		t.keys = make(map[interface{}][]*Interval, len(intervals))
+		for _, in := range intervals {
+			t.indexKey(in)
+		}
+	}
+	return t, nil
+}
 
-	if len(mid)+len(right)+len(left) != len(intervals) {
-		log.Fatalln("MID + LEFT + RIGHT == INTERVALS")
+// normalize returns the intervals with Start and End swapped on the reversed ones, either in place or on copies put
+// in a new slice so neither the caller's slice nor its intervals are modified
+// Complexity: O(n), n = len(intervals)
+func normalize(intervals []*Interval, inPlace bool) []*Interval {
+	res, copied := intervals, false
+	for i, in := range intervals {
+		if in == nil || in.Start <= in.End {
+			continue
+		}
+		if inPlace {
+			in.Start, in.End = in.End, in.Start
+			in.StartOpen, in.EndOpen = in.EndOpen, in.StartOpen
+			continue
+		}
+		if !copied {
+			res, copied = append([]*Interval(nil), intervals...), true
+		}
+		res[i] = &Interval{Start: in.End, End: in.Start, StartOpen: in.EndOpen, EndOpen: in.StartOpen, Payload: in.Payload}
 	}
-	itr := tree.Root()
-	itr.Insert(newElt(mid[:], xMid))
-	err := itr.Left().Paste(fromIntervals(left[:]))
+	return res
+}
+
+// NewEmptyIntervalTree creates a new interval tree holding no interval, to be filled with Insert. The first Insert
+// creates the root node and the first BST points
+func NewEmptyIntervalTree(opts ...Option) *IntervalTree {
+	return MustNewIntervalTree(nil, opts...) // cannot fail, there is no interval
+}
+
+// MustNewIntervalTree is like NewIntervalTree but panics if the tree cannot be built
+func MustNewIntervalTree(intervals []*Interval, opts ...Option) *IntervalTree {
+	t, err := NewIntervalTree(intervals, opts...)
 	if err != nil {
-		log.Fatalln("Cannot paste left tree")
+		panic(err)
 	}
-	err = itr.Right().Paste(fromIntervals(right[:]))
-	if err != nil {
-		log.Fatalln("Cannot paste right tree")
+	return t
+}
+
+// fromIntervals create a binary tree containing elt struct as data. The endpoints are sorted once and partitioned
+// down the recursion by fromEndpoints, which gives the tree the former sort at every level gave: the same xMid and
+// the same lists in every node. Every node must hold at least one interval, the one owning its median point, which
+// only an interval holding no point, reversed for instance, prevents: it fails on such an interval rather than
+// building a broken structure. tie orders the intervals with the same endpoints in the nodes, see newElt, and open
+// is subtracted from End to get the last point of an interval.
+// Build complexity: O(n log n), n = len(intervals) cause of sorting the endpoints
+func fromIntervals(intervals []*Interval, tie func(a, b *Interval) bool, open int) (*nodeTree, error) {
+	for _, in := range intervals {
+		if lastPoint(in, open) < in.first() {
+			return nil, fmt.Errorf("%w: no node can hold %s", ErrBrokenInvariant, in)
+		}
 	}
-	return tree
+	s := &scratch{}
+	ends, buf := s.endpoints(intervals, open)
+	return fromEndpoints(ends, buf, &s.mid, newArena(len(intervals)), tie, open), nil
 }
 
-// intersecting returns all intervals intersecting the value x int he IntervalTree
-// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
-func intersecting(itr *binarytree.Iterator, x int) []*Interval {
+// walk calls fn on every element of the tree in ascending order of xMid, stops as soon as fn returns false.
+// The traversal is iterative so it does not depend on the depth of the tree
+func walk(tree *nodeTree, fn func(e *elt) bool) {
+	var stack []*iterator
+	itr := tree.root()
+	for !itr.isBottom() || len(stack) > 0 {
+		for !itr.isBottom() {
+			stack = append(stack, itr)
+			itr = itr.left()
+		}
+		itr = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fn(itr.consult()) {
+			return
+		}
+		itr = itr.right()
+	}
+}
+
+// intervals returns every interval stored in the tree, each once as an interval lives in a single element
+// Complexity: O(n), n = number of stored intervals
+func (t *IntervalTree) intervals() []*Interval {
 	var res []*Interval
+	walk(
+		t.nodes(), func(e *elt) bool {
+			res = append(res, e.leftSorted...)
+			return true
+		},
+	)
+	return res
+}
 
-	if itr.IsBottom() {
-		return res
+// locate returns the iterator on the element that stores or would store the intervals whose points are [start, end],
+// end being End - 1 for a half-open interval: the first element on the path from the root whose xMid is in
+// [start, end]. If there is none, the iterator is at the bottom of the tree where such an element must be inserted
+// Complexity: O(ln n), n = len(intervals in struct)
+func (t *IntervalTree) locate(start, end int) *iterator {
+	itr := t.nodes().root()
+	for !itr.isBottom() {
+		e := itr.consult()
+		if end < e.xMid {
+			itr = itr.left()
+		} else if start > e.xMid {
+			itr = itr.right()
+		} else {
+			break
+		}
 	}
-	e := itr.Consult().(*elt) // must be of this type or panic
-	res = append(res, e.intersecting(x)...)
-	if x > e.xMid {
-		res = append(res, intersecting(itr.Right(), x)...)
-	} else if x < e.xMid {
-		res = append(res, intersecting(itr.Left(), x)...)
+	return itr
+}
+
+// edit returns the path from the root to the iterator locate returns for [start, end], the nodes on it being
+// copied first if they are shared with a snapshot, see iterator.own, so that the mutation can modify them in place
+// and prune the empty leaves from the bottom of the path
+// Complexity: O(ln n), n = len(intervals in struct)
+func (t *IntervalTree) edit(start, end int) []iterator {
+	var path []iterator
+	itr := *t.nodes().root()
+	for {
+		if !itr.isBottom() {
+			itr.own()
+		}
+		path = append(path, itr)
+		if itr.isBottom() {
+			return path
+		}
+		e := itr.consult()
+		if end < e.xMid {
+			itr = *itr.left()
+		} else if start > e.xMid {
+			itr = *itr.right()
+		} else {
+			return path
+		}
 	}
-	return res
 }
 
-// Containing returns all intervals containing the value x int he IntervalTree
+// stab calls fn on every interval containing the value x in the IntervalTree, node by node from the root. It
+// follows a single path in a loop, so it does not depend on the depth of the tree, stops at the first node whose
+// subtree misses x, see elt.misses, and returns false as soon as fn returns false, stopping the traversal
+func stab(root *iterator, x, open int, fn func(*Interval) bool) bool {
+	// moved by value, so the iterators stay on the stack as those of a recursive descent
+	itr := *root
+	for !itr.isBottom() {
+		e := itr.consult()
+		if e.misses(x) {
+			break
+		}
+		if !e.stab(x, open, fn) {
+			return false
+		}
+		if x > e.xMid {
+			itr = *itr.right()
+		} else if x < e.xMid {
+			itr = *itr.left()
+		} else {
+			break
+		}
+	}
+	return true
+}
+
+// stabCuts calls visit on the nodes on the path of x with the number of their intervals containing x, see
+// elt.containing, in the order of stab, until visit returns false. Nothing is reported interval by interval, so it
+// counts them without depending on their number. It returns false if visit stopped the traversal
+// Complexity: O(ln n · ln m), n = len(intervals in struct) and m = number of intervals in a node
+func stabCuts(root *iterator, x, open int, visit func(e *elt, byEnd bool, n int) bool) bool {
+	itr := *root // moved by value, see stab
+	for !itr.isBottom() {
+		e := itr.consult()
+		if e.misses(x) {
+			break
+		}
+		if byEnd, n := e.containing(x, open); !visit(e, byEnd, n) {
+			return false
+		}
+		if x > e.xMid {
+			itr = *itr.right()
+		} else if x < e.xMid {
+			itr = *itr.left()
+		} else {
+			break
+		}
+	}
+	return true
+}
+
+// startingIn calls fn on the intervals of the subtree whose first point lies in (start, end], in ascending order of
+// xMid and in the order of leftSorted in every node, until fn returns false. The intervals of a node hold its xMid:
+// the node is skipped if its xMid is not after start, and only its intervals starting at or before start, holding
+// start too, are stepped over, those being reported by the stabbing query at start. It returns false if fn stopped
+// the traversal. The nodes are visited by nodesAfter, using the stack given
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = intervals containing start or
+// starting in (start, end]
+func startingIn(root *iterator, start, end int, stack *[]iterator, fn func(*Interval) bool) bool {
+	return nodesAfter(
+		root, start, end, stack, func(e *elt) bool {
+			for _, in := range e.leftSorted {
+				if in.first() > end {
+					break
+				}
+				if in.first() > start && !fn(in) {
+					return false
+				}
+			}
+			return true
+		},
+	)
+}
+
+// nodesAfter calls visit on the nodes of the subtree able to hold intervals starting in (start, end], the ones
+// whose xMid is after start on the path to end, in ascending order of xMid, until visit returns false. A subtree
+// whose bounds show that its intervals all start after end, or at or before start, is skipped. The in-order
+// traversal keeps an explicit stack, in the buffer given, so it does not depend on the depth of the tree. It returns
+// false if visit stopped the traversal
+func nodesAfter(root *iterator, start, end int, stack *[]iterator, visit func(e *elt) bool) bool {
+	// the nodes whose xMid is after start, waiting for their left subtree to be visited. The iterators are moved by
+	// value, see stab
+	itr, descend := *root, true
+	for {
+		for descend && !itr.isBottom() {
+			e := itr.consult()
+			if e.lo > end || e.hi <= start {
+				// every interval of the subtree starts after end, or at or before start
+				break
+			} else if e.xMid > start {
+				// the intervals on the left end before xMid, the ones of the node start at or before it
+				*stack = append(*stack, itr)
+				itr = *itr.left()
+			} else if e.xMid < end {
+				itr = *itr.right()
+			} else {
+				break
+			}
+		}
+		if len(*stack) == 0 {
+			return true
+		}
+		itr = (*stack)[len(*stack)-1]
+		*stack = (*stack)[:len(*stack)-1]
+		e := itr.consult()
+		e.check()
+		if !visit(e) {
+			return false
+		}
+		// the intervals on the right start after xMid
+		if descend = e.xMid < end; descend {
+			itr = *itr.right()
+		}
+	}
+}
+
+// Containing returns all intervals containing the value x int he IntervalTree, node by node from the root of the
+// tree. A small tree, see WithSmallThreshold, returns them sorted as ByStart does, the intervals with the same
+// endpoints in the order of WithTieBreaker, else of the input, so the order changes when the tree leaves that mode
 // Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
 func (t *IntervalTree) Containing(x int) []*Interval {
-	return intersecting(t.tree.Root(), x)
+	t = t.orEmpty()
+	t.heal()
+	t.checkIntegrity()
+	if t.small != nil {
+		return t.collectSmall(x, x)
+	}
+	q := borrowScratch()
+	defer q.release()
+	q.reserve(t.estimate(q, x, x))
+	stab(t.nodes().root(), x, t.open, q.collect)
+	return q.result()
+}
+
+// AnyContaining tells if an interval contains the value x, as len(Containing(x)) > 0, without collecting them: it
+// stops at the first node on the path of x whose lists hold one, their cutoffs being found by binary search
+// Complexity: O(ln n · ln m), n = len(intervals in struct) and m = number of intervals in a node
+func (t *IntervalTree) AnyContaining(x int) bool {
+	t = t.orEmpty()
+	t.heal()
+	t.checkIntegrity()
+	if t.small != nil {
+		return !t.scanSmall(x, x, func(*Interval) bool { return false })
+	}
+	return !stabCuts(t.nodes().root(), x, t.open, func(_ *elt, _ bool, n int) bool { return n == 0 })
 }
 
-// Intersecting returns all intervals intersecting the Interval given in parameter.
+// Intersecting returns all intervals intersecting the Interval given in parameter. The order no longer depends on
+// the iteration of a map and is the same for every run of the query: the intervals containing interval.Start come
+// first, in the order Containing returns them, then the ones starting after it inside the interval, node by node in
+// ascending order of xMid, as IntersectingSeq yields them. A small tree sorts them by Start, see Containing.
+// IntersectingWith and SortBy give a sorted result.
+// The single point query [x, x] returns the intervals containing x, in the order of Containing, as [x, x + 1) does
+// in a half-open tree. A nil or reversed query returns nothing, see IntersectingE and WithSwappedQueries
 // Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
 func (t *IntervalTree) Intersecting(interval *Interval) []*Interval {
-	// First search in the BST for all intersecting intervals
-	intervalSearchResult := t.bst.IntervalSearch(&Point{x: interval.Start}, &Point{x: interval.End})
-	// remove the duplicates, time depending on searchResult size as bst.IntervalSearch is output sensitive
-	set := make(map[*Interval]bool) // uses of map prevent duplicates
-	for _, p := range intervalSearchResult {
-		p1 := p.(*Point)
-		for _, i := range p1.ptrs {
-			set[i] = true
-		}
+	t = t.orEmpty()
+	t.heal()
+	t.checkIntegrity()
+	interval, err := t.window(interval)
+	if err != nil {
+		return nil
+	}
+	if t.small != nil && !t.empty(interval) {
+		return t.collectSmall(interval.first(), t.last(interval))
+	}
+	return t.overlapping(interval)
+}
+
+// ContainingE returns all intervals containing the value x, as Containing, or an error wrapping ErrBrokenInvariant if
+// the internal state of the IntervalTree is found inconsistent, instead of panicking
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
+func (t *IntervalTree) ContainingE(x int) (res []*Interval, err error) {
+	defer recoverInvariant(&err)
+	return t.Containing(x), nil
+}
+
+// IntersectingE returns all intervals intersecting the Interval given in parameter, as Intersecting, or an error
+// wrapping ErrBrokenInvariant if the internal state of the IntervalTree is found inconsistent, instead of panicking.
+// It fails with ErrNilInterval for a nil query and with ErrReversedInterval for a reversed one, unless the tree was
+// built WithSwappedQueries
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
+func (t *IntervalTree) IntersectingE(interval *Interval) (res []*Interval, err error) {
+	defer recoverInvariant(&err)
+	if _, err := t.orEmpty().window(interval); err != nil {
+		return nil, err
+	}
+	return t.Intersecting(interval), nil
+}
+
+// overlapping returns all intervals intersecting the Interval given in parameter, without triggering the automatic
+// rebuild so that it can be used in the middle of a mutation
+func (t *IntervalTree) overlapping(interval *Interval) []*Interval {
+	q := borrowScratch()
+	defer q.release()
+	if !t.empty(interval) {
+		q.reserve(t.estimate(q, interval.first(), t.last(interval)))
 	}
-	// query the IntervalTree to get all interval that intersect the query interval
-	intersectSearchResult := t.Containing(interval.Start)
-	for _, in := range intersectSearchResult {
-		set[in] = true
+	t.overlapIn(q, interval, q.collect)
+	return q.result()
+}
+
+// overlap calls fn on every interval intersecting the Interval given in parameter, each once, until fn returns
+// false. The intervals containing the first point of the interval come first, then the ones starting after it
+// inside the interval, so no set is needed to remove the duplicates. Both are found walking the nodes, the BST is
+// not searched. It returns false if fn stopped the traversal
+func (t *IntervalTree) overlap(interval *Interval, fn func(*Interval) bool) bool {
+	q := borrowScratch()
+	defer q.release()
+	return t.overlapIn(q, interval, fn)
+}
+
+// overlapIn is overlap traversing the nodes with the stack of the queryScratch
+func (t *IntervalTree) overlapIn(q *queryScratch, interval *Interval, fn func(*Interval) bool) bool {
+	if t.empty(interval) {
+		return true
+	}
+	first, root := interval.first(), t.nodes().root()
+	return stab(root, first, t.open, fn) && startingIn(root, first, t.last(interval), &q.stack, fn)
+}
+
+// last returns the last point of the interval, End - 1 if its end is open or the intervals are half-open, else End
+func (t *IntervalTree) last(in *Interval) int {
+	return lastPoint(in, t.open)
+}
+
+// mode returns the option creating trees holding the same kind of intervals as the IntervalTree
+func (t *IntervalTree) mode() Option {
+	open := t.open
+	return func(c *config) {
+		c.open = open
+	}
+}
+
+// empty tells if the query interval has an open side and holds no point, as [x, x) or (x, x + 1)
+func (t *IntervalTree) empty(window *Interval) bool {
+	return vacant(window, t.open)
+}
+
+// window returns the query interval to search, or an error wrapping ErrNilInterval or ErrReversedInterval if there
+// is none to search. A reversed interval is swapped, with its boundary flags, if the tree was built
+// WithSwappedQueries
+func (t *IntervalTree) window(interval *Interval) (*Interval, error) {
+	return queryWindow(interval, t.swap)
+}
+
+// bounds returns the first and last points of the window to search, see window, for the methods reading a range of
+// coordinates rather than intervals. The error wraps ErrNilInterval or ErrReversedInterval as window does, or is
+// ErrEmptyInterval for a window holding no point, see empty: the methods answer as for a window holding nothing
+func (t *IntervalTree) bounds(window *Interval) (first, last int, err error) {
+	window, err = t.window(window)
+	if err != nil {
+		return 0, 0, err
 	}
-	result := make([]*Interval, 0, len(set))
-	for k := range set {
-		result = append(result, k)
+	if t.empty(window) {
+		return 0, 0, ErrEmptyInterval
 	}
-	return result
+	return window.first(), t.last(window), nil
+}
+
+// queryWindow returns the query interval to search, swapped if reversed and swap is set, see IntervalTree.window
+func queryWindow(interval *Interval, swap bool) (*Interval, error) {
+	switch {
+	case interval == nil:
+		return nil, ErrNilInterval
+	case interval.Start <= interval.End:
+		return interval, nil
+	case !swap:
+		return nil, fmt.Errorf("%w: query %s", ErrReversedInterval, interval)
+	}
+	swapped := &Interval{Start: interval.End, End: interval.Start, Payload: interval.Payload}
+	swapped.StartOpen, swapped.EndOpen = interval.EndOpen, interval.StartOpen
+	return swapped, nil
+}
+
+// orEmpty returns the IntervalTree, or a new empty one if it is nil, so that a nil *IntervalTree answers as an
+// empty tree. The methods adding intervals return ErrNilTree instead, the new intervals having nowhere to go
+func (t *IntervalTree) orEmpty() *IntervalTree {
+	if t == nil {
+		return MustNewIntervalTree(nil)
+	}
+	return t
+}
+
+// Len returns the number of intervals stored in the IntervalTree
+// Complexity: O(1), the count is maintained rather than computed
+func (t *IntervalTree) Len() int {
+	t = t.orEmpty()
+	return t.size
+}
+
+// IsEmpty tells if the IntervalTree stores no interval
+func (t *IntervalTree) IsEmpty() bool {
+	t = t.orEmpty()
+	return t.size == 0
+}
+
+// All returns every interval stored in the IntervalTree, each exactly once, in a new slice
+// Complexity: O(n), n = len(intervals in struct)
+func (t *IntervalTree) All() []*Interval {
+	t = t.orEmpty()
+	return t.intervals()
+}
+
+// AllSorted returns every interval stored in the IntervalTree in a new slice sorted in the order given in parameter
+// Complexity: O(n log n), n = len(intervals in struct)
+func (t *IntervalTree) AllSorted(order SortOrder) []*Interval {
+	t = t.orEmpty()
+	res := t.intervals()
+	t.sortIntervals(res, order)
+	return res
 }
 
 // -----------------------------------------------------
@@ -126,110 +599,334 @@ func (t *IntervalTree) Intersecting(interval *Interval) []*Interval {
 // -----------------------------------------------------
 
 // elt structure representing an IntervalTree element containing a left sorted (start increment) list of intervals
-// and right sorted (end decrement) list of intervals with the median point of all its intervals
+// and the right sorted (end decrement) order of the same intervals, with the median point of all its intervals.
+// The intervals are stored once: the order by end is a permutation of the positions in leftSorted, so every
+// interval costs a pointer and an int32 rather than two pointers and the permutation holds nothing for the GC to scan
 type elt struct {
-	leftSorted  []*Interval
-	rightSorted []*Interval
-	xMid        int
+	leftSorted []*Interval
+	byEnd      []int32 // positions in leftSorted in the order of rightSorted, see endAt
+	xMid       int
+	lo, hi     int // the first and last points of the intervals of the subtree, see span
+
+	left, right *elt   // the links of the node of the element, see nodeTree
+	owner       *owner // the tree that may modify the element in place, see nodeTree
 }
 
 // newElt creates a new element with
-// intervals must be a Slice
+// intervals must be a Slice. The intervals with the same endpoints are ordered by tie, or kept in the order of
+// intervals if tie is nil
 // This method is in O(n log n) as it uses sort.SliceStable to sort left and right lists
-func newElt(intervals []*Interval, xMid int) *elt {
-	length := len(intervals)
-	intervalTreeElt := &elt{
-		leftSorted:  make([]*Interval, length),
-		rightSorted: make([]*Interval, length),
-		xMid:        xMid,
-	}
+func newElt(intervals []*Interval, xMid int, tie func(a, b *Interval) bool) *elt {
+	intervalTreeElt := &elt{}
+	intervalTreeElt.fill(intervals, make([]*Interval, len(intervals)), make([]int32, len(intervals)), xMid, tie)
+	return intervalTreeElt
+}
+
+// fill makes the element hold the intervals at xMid as newElt does, in the storage given for leftSorted and byEnd
+// PRE: len(leftSorted) == len(byEnd) == len(intervals)
+func (e *elt) fill(intervals, leftSorted []*Interval, byEnd []int32, xMid int, tie func(a, b *Interval) bool) {
+	e.leftSorted, e.xMid = leftSorted, xMid
 	// copy the array of intervals
-	copy(intervalTreeElt.leftSorted[:], intervals)
-	copy(intervalTreeElt.rightSorted[:], intervals)
+	copy(e.leftSorted, intervals)
 	// sort start
 	sort.SliceStable(
-		intervalTreeElt.leftSorted[:], func(i, j int) bool {
-			return intervalTreeElt.leftSorted[i].lessStart(intervalTreeElt.leftSorted[j])
+		e.leftSorted, func(i, j int) bool {
+			return startOrder(e.leftSorted[i], e.leftSorted[j], tie)
 		},
 	)
-	// sort end
+	// sort end, the stable sort of the positions keeping the intervals comparing equal in the order of intervals
+	e.byEnd = sortByEndIn(byEnd, e.leftSorted, tie)
+}
+
+// sortByEndIn stores in byEnd, of the length of the list, the positions of the intervals of the list in the order of
+// rightSorted, see endOrder. The intervals comparing equal keep the order of the list
+// Complexity: O(m log m), m = len(list)
+func sortByEndIn(byEnd []int32, list []*Interval, tie func(a, b *Interval) bool) []int32 {
+	for i := range byEnd {
+		byEnd[i] = int32(i)
+	}
 	sort.SliceStable(
-		intervalTreeElt.rightSorted[:], func(i, j int) bool {
-			return intervalTreeElt.rightSorted[i].lessEnd(intervalTreeElt.rightSorted[j])
+		byEnd, func(i, j int) bool {
+			return endOrder(list[byEnd[i]], list[byEnd[j]], tie)
 		},
 	)
-	return intervalTreeElt
+	return byEnd
+}
+
+// endAt returns the k-th interval of the element in the order of rightSorted: descending End, then descending
+// Start, see lessEnd
+func (e *elt) endAt(k int) *Interval {
+	return e.leftSorted[e.byEnd[k]]
+}
+
+// check panics with ErrBrokenInvariant if the two orders of the element do not hold the same intervals count
+func (e *elt) check() {
+	if len(e.byEnd) != len(e.leftSorted) {
+		brokenInvariant(
+			"node at %d holding %d intervals by start and %d by end", e.xMid, len(e.leftSorted), len(e.byEnd),
+		)
+	}
+}
+
+// span sets the bounds of the subtree of the element, lo > hi if it holds no interval, from its intervals and the
+// bounds of its children, which must be set. The intervals of the element hold xMid, so the first of leftSorted has
+// the first point and the first in the order by end the last one. The bounds are kept loose by the deletions, the
+// queries pruning no more than they would without them until the tree is rebuilt
+// Complexity: O(1)
+func (e *elt) span(open int) {
+	e.lo, e.hi = math.MaxInt, math.MinInt
+	if len(e.leftSorted) > 0 {
+		e.lo, e.hi = e.leftSorted[0].first(), lastPoint(e.endAt(0), open)
+	}
+	for _, c := range [...]*elt{e.left, e.right} {
+		if c != nil {
+			e.lo, e.hi = minInt(e.lo, c.lo), maxInt(e.hi, c.hi)
+		}
+	}
+}
+
+// widen extends the bounds of the nodes of the path to the points [start, end] of an interval added to the last
+// one. The bounds of a node hold the ones of its children, so it stops at the first node from the bottom already
+// holding them
+// Complexity: O(h), h = length of the path
+func widen(path []iterator, start, end int) {
+	for i := len(path) - 1; i >= 0; i-- {
+		if e := path[i].consult(); e != nil {
+			if start >= e.lo && end <= e.hi {
+				return
+			}
+			e.lo, e.hi = minInt(e.lo, start), maxInt(e.hi, end)
+		}
+	}
+}
+
+// misses tells if no interval of the subtree of the element contains the value x, which lies outside of its
+// bounds: the queries stop there instead of going down to the bottom
+func (e *elt) misses(x int) bool {
+	return x < e.lo || x > e.hi
 }
 
-// intersecting returns all the intervals that intersect the value "x".
-// This method creates a new array of intervals
-// Method in O(k) where k is the number of returned intervals
-func (e *elt) intersecting(x int) []*Interval {
-	if len(e.rightSorted) != len(e.leftSorted) {
-		log.Fatalln("MUST HAVE SAME LENGTH")
+// spans sets the bounds of every node of the tree, see elt.span. The nodes are listed from the root with an
+// explicit stack and their bounds set in the reverse order, the children before their parent
+// Complexity: O(m), m = number of nodes
+func spans(tree *nodeTree, open int) {
+	var nodes []*elt
+	if tree.top != nil {
+		nodes = append(nodes, tree.top)
+	}
+	for i := 0; i < len(nodes); i++ {
+		for _, c := range [...]*elt{nodes[i].left, nodes[i].right} {
+			if c != nil {
+				nodes = append(nodes, c)
+			}
+		}
 	}
+	for i := len(nodes) - 1; i >= 0; i-- {
+		nodes[i].span(open)
+	}
+}
+
+// find returns the intervals of the element with exactly the endpoints and boundary flags of the interval given in
+// parameter
+// Complexity: O(ln m + k), m = number of intervals in the element and k = returned intervals
+func (e *elt) find(like *Interval) []*Interval {
+	i := sort.Search(
+		len(e.leftSorted), func(k int) bool {
+			return !e.leftSorted[k].lessStart(like)
+		},
+	)
 	var res []*Interval
+	for ; i < len(e.leftSorted) && e.leftSorted[i].bounds() == like.bounds(); i++ {
+		res = append(res, e.leftSorted[i])
+	}
+	return res
+}
+
+// stab calls fn on every interval of the element that intersect the value "x": from the end of the order by end if
+// x is after xMid, from the start of leftSorted else. It returns false as soon as fn returns false
+func (e *elt) stab(x, open int, fn func(*Interval) bool) bool {
+	e.check()
 	if x > e.xMid {
-		// begin to check from the end
-		for _, in := range e.rightSorted {
-			if in.End < x {
+		for k := range e.byEnd {
+			in := e.endAt(k)
+			if lastPoint(in, open) < x {
 				break
 			}
-			res = append(res, in)
+			if !fn(in) {
+				return false
+			}
 		}
 	} else if x < e.xMid {
-		// begin to check from the start
 		for _, in := range e.leftSorted {
-			if in.Start > x {
+			if in.first() > x {
 				break
 			}
-			res = append(res, in)
+			if !fn(in) {
+				return false
+			}
 		}
 	} else {
-		// return all the intervals
-		res = append(res, e.leftSorted...)
+		for _, in := range e.leftSorted {
+			if !fn(in) {
+				return false
+			}
+		}
 	}
-	return res
+	return true
+}
+
+// containing returns the number of intervals of the element containing the value "x", the ones stab reports: a
+// prefix of the order by end if x is after xMid, byEnd being true, a prefix of leftSorted else. Only the cutoff
+// matters to count them, so it is found by binary search, the intervals ending, or starting, at x being on the same
+// side of it whatever their number
+// Complexity: O(ln m), m = number of intervals in the element
+func (e *elt) containing(x, open int) (byEnd bool, n int) {
+	e.check()
+	if x > e.xMid {
+		return true, sort.Search(len(e.byEnd), func(k int) bool { return lastPoint(e.endAt(k), open) < x })
+	} else if x < e.xMid {
+		return false, sort.Search(len(e.leftSorted), func(i int) bool { return e.leftSorted[i].first() > x })
+	}
+	return false, len(e.leftSorted)
+}
+
+// starting returns the range leftSorted[from:to] of the intervals of the element whose first point lies in
+// (start, end], found by binary search
+// Complexity: O(ln m), m = number of intervals in the element
+func (e *elt) starting(start, end int) (from, to int) {
+	from = sort.Search(len(e.leftSorted), func(i int) bool { return e.leftSorted[i].first() > start })
+	to = sort.Search(len(e.leftSorted), func(i int) bool { return e.leftSorted[i].first() > end })
+	return from, to
 }
 
 // -----------------------------------------------------
 // 				INTERVAL
 // -----------------------------------------------------
 
-// Interval structure used to store an interval
+// Interval structure used to store an interval. Both endpoints belong to the interval unless flagged open: the
+// interval (3, 7], with StartOpen, holds the points 4 to 7. A closed interval with Start == End is a single point,
+// as an instantaneous event: it contains and intersects what a longer interval covering its point would
 type Interval struct {
-	Start   int // Start <= End
-	End     int
-	Payload interface{}
+	Start     int // Start <= End
+	End       int
+	StartOpen bool // Start is excluded from the interval
+	EndOpen   bool // End is excluded from the interval
+	Payload   interface{}
+}
+
+// first returns the first point of the interval, Start + 1 if its start is open, else Start
+func (interval *Interval) first() int {
+	if interval.StartOpen {
+		return interval.Start + 1
+	}
+	return interval.Start
+}
+
+// lastPoint returns the last point of the interval, End - 1 if its end is open or if open is 1 for a tree of
+// half-open intervals, else End
+func lastPoint(in *Interval, open int) int {
+	if in.EndOpen {
+		return in.End - 1
+	}
+	return in.End - open
 }
 
-// lessStart method used to sort Interval in ascending order of the Interval.Start value, if equals,
-// use ascending comparison on Interval.End
+// vacant tells if the interval, with open being 1 for a half-open one, has an open side and holds no point, as
+// [x, x) or (x, x + 1). A closed interval is never vacant, even reversed
+func vacant(in *Interval, open int) bool {
+	excluded := uint(open)
+	if in.EndOpen {
+		excluded = 1
+	}
+	if in.StartOpen {
+		excluded++
+	}
+	return excluded > 0 && (in.End < in.Start || uint(in.End)-uint(in.Start) < excluded)
+}
+
+// copyWith returns a new interval with the endpoints and boundary flags of the interval and the payload given in
+// parameter
+func (interval *Interval) copyWith(payload interface{}) *Interval {
+	c := *interval
+	c.Payload = payload
+	return &c
+}
+
+// bounds returns the endpoints and boundary flags of the interval, as a comparable key
+func (interval *Interval) bounds() bounds {
+	return bounds{interval.Start, interval.End, interval.StartOpen, interval.EndOpen}
+}
+
+// bounds is the key of the intervals with the same endpoints and boundary flags, see Interval.bounds
+type bounds struct {
+	start, end         int
+	startOpen, endOpen bool
+}
+
+// lessStart method used to sort Interval in ascending order of the Interval.Start value, a closed start first, if
+// equals, use ascending comparison on Interval.End, an open end first. This is the ascending order of the first
+// points, then of the last points, see Interval.first and lastPoint
 // This is used to build the elt.leftSorted array
 func (interval *Interval) lessStart(than *Interval) bool {
-	if interval.Start == than.Start {
+	if interval.Start != than.Start {
+		return interval.Start < than.Start
+	}
+	if interval.StartOpen != than.StartOpen {
+		return than.StartOpen
+	}
+	if interval.End != than.End {
 		return interval.End < than.End
 	}
-	return interval.Start < than.Start
+	return interval.EndOpen && !than.EndOpen
 }
 
-// lessEnd method used to sort Interval in descending order of the Interval.End value, if equals,
-// use descending comparison on Interval.Start
-// This is used to build the elt.rightSorted array
+// lessEnd method used to sort Interval in descending order of the Interval.End value, a closed end first, if
+// equals, use descending comparison on Interval.Start, an open start first. This is the descending order of the last
+// points, then of the first points
+// This is used to build the elt.byEnd order
 func (interval *Interval) lessEnd(than *Interval) bool {
-	if interval.End == than.End {
+	if interval.End != than.End {
+		return interval.End > than.End
+	}
+	if interval.EndOpen != than.EndOpen {
+		return than.EndOpen
+	}
+	if interval.Start != than.Start {
 		return interval.Start > than.Start
 	}
-	return interval.End > than.End
+	return interval.StartOpen && !than.StartOpen
+}
+
+// startOrder is the order of elt.leftSorted: lessStart, then tie on the intervals with the same endpoints
+func startOrder(a, b *Interval, tie func(a, b *Interval) bool) bool {
+	if tie != nil && a.bounds() == b.bounds() {
+		return tie(a, b)
+	}
+	return a.lessStart(b)
+}
+
+// endOrder is the order of elt.byEnd: lessEnd, then tie on the intervals with the same endpoints
+func endOrder(a, b *Interval, tie func(a, b *Interval) bool) bool {
+	if tie != nil && a.bounds() == b.bounds() {
+		return tie(a, b)
+	}
+	return a.lessEnd(b)
 }
 
-// String prints an interval
+// String prints an interval, a parenthesis marking an open side as in ( 3 - 7 ]
 func (interval *Interval) String() string {
-	return fmt.Sprintf("[ %d - %d ]", interval.Start, interval.End)
+	left, right := "[", "]"
+	if interval.StartOpen {
+		left = "("
+	}
+	if interval.EndOpen {
+		right = ")"
+	}
+	return fmt.Sprintf("%s %d - %d %s", left, interval.Start, interval.End, right)
 }
 
 // -----------------------------------------------------
-// 				BST FOR INTERVAL SEARCH
+// 				BST OF THE ENDPOINTS
 // -----------------------------------------------------
 
 // Point struct representing a point and linked to one or more interval
@@ -238,59 +935,95 @@ type Point struct {
 	ptrs []*Interval
 }
 
-// CompareTo implementation of the compare to method from bst.Comparable
-func (p *Point) CompareTo(other bst.Comparable) int {
-	switch v := other.(type) {
-	case *Point:
-		if p.x < v.x {
-			return -1
-		} else if p.x > v.x {
-			return 1
-		}
-		return 0
-	default:
-		log.Fatalf("POINT COMPARETO: Trying to compare to %s", v)
-	}
-	return -1 // never reached
-}
-
-func buildBST(intervals []*Interval) *bst.BST {
+// buildBST creates the BST of the first and last points of the intervals, see Interval.first and lastPoint
+func buildBST(intervals []*Interval, open int) *pointTree {
 	length := len(intervals)
-	if length == 0 {
-		return bst.NewBST()
-	}
 	// Create the array for the BST
-	allPoints := make([]bst.Comparable, length*2)
+	allPoints := make([]Point, length*2)
 	for i, in := range intervals {
-		allPoints[i] = &Point{in.Start, []*Interval{in}}
-		allPoints[length+i] = &Point{in.End, []*Interval{in}}
+		allPoints[i] = Point{in.first(), []*Interval{in}}
+		allPoints[length+i] = Point{lastPoint(in, open), []*Interval{in}}
 	}
 
 	sort.Slice(
 		allPoints, func(i, j int) bool {
-			return allPoints[i].CompareTo(allPoints[j]) < 0
+			return allPoints[i].x < allPoints[j].x
 		},
 	)
 
 	allPoints = removeDuplicateByFusion(allPoints)
-	return bst.NewBSTReady(allPoints)
+	return newPointTree(allPoints)
+}
+
+// pointsIn returns the points of the BST in [min, max], in ascending order
+// Output sensitive: Complexity of O(ln p + k), p = number of points and k = returned points
+func (t *IntervalTree) pointsIn(min, max int) []*Point {
+	return t.points().search(min, max)
+}
+
+// events returns the number of intervals whose first or last point is the point, see Interval.first and lastPoint.
+// A single point interval [x, x] is referenced twice by its point and counts as one start and one end
+func (p *Point) events(open int) (starts, ends int) {
+	degenerate := 0
+	for _, in := range p.ptrs {
+		if in.first() != p.x {
+			ends++
+		} else if lastPoint(in, open) != p.x {
+			starts++
+		} else {
+			degenerate++
+		}
+	}
+	return starts + degenerate/2, ends + degenerate/2
+}
+
+// starting returns the intervals whose first point is the point, each once
+func (p *Point) starting(open int) []*Interval {
+	return p.side(open, func(in *Interval) bool { return in.first() == p.x })
+}
+
+// ending returns the intervals whose last point is the point, each once
+func (p *Point) ending(open int) []*Interval {
+	return p.side(open, func(in *Interval) bool { return lastPoint(in, open) == p.x })
+}
+
+// side returns the intervals of the point for which at is true, each once even for single point intervals
+// referenced twice by the point
+func (p *Point) side(open int, at func(*Interval) bool) []*Interval {
+	var res []*Interval
+	var degenerate map[*Interval]bool
+	for _, in := range p.ptrs {
+		if in.first() == p.x && lastPoint(in, open) == p.x {
+			if degenerate[in] {
+				continue
+			}
+			if degenerate == nil {
+				degenerate = make(map[*Interval]bool)
+			}
+			degenerate[in] = true
+		}
+		if at(in) {
+			res = append(res, in)
+		}
+	}
+	return res
 }
 
 // fusion payload of a point with another
 // POST: p.ptrs = [p.ptrs +  p2.ptrs]
-func (p *Point) fusion(p2 bst.Comparable) {
-	p.ptrs = append(p.ptrs, p2.(*Point).ptrs...) // must be *Point, else panic
+func (p *Point) fusion(p2 *Point) {
+	p.ptrs = append(p.ptrs, p2.ptrs...)
 }
 
-// removeDuplicateByFusion returns a new list of points without duplicates. It merge duplicates points to save all
-// the Interval pointer to a same point if multiple intervals shared the same point
-func removeDuplicateByFusion(points []bst.Comparable) []bst.Comparable {
-	var res []bst.Comparable
-	for _, p := range points {
-		if res != nil && res[len(res)-1].CompareTo(p) == 0 {
-			res[len(res)-1].(*Point).fusion(p) // must be *Point, else panic
+// removeDuplicateByFusion returns the sorted points without duplicates, reusing their slice. It merge duplicates
+// points to save all the Interval pointer to a same point if multiple intervals shared the same point
+func removeDuplicateByFusion(points []Point) []Point {
+	res := points[:0]
+	for i := range points {
+		if len(res) > 0 && res[len(res)-1].x == points[i].x {
+			res[len(res)-1].fusion(&points[i])
 		} else {
-			res = append(res, p)
+			res = append(res, points[i])
 		}
 	}
 	return res
@@ -1,9 +1,11 @@
 package intervaltree
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
+	"slices"
 	"testing"
 	"time"
 )
@@ -54,7 +56,7 @@ func TestIntervalTree(t *testing.T) {
 				log.Println("Generation of the the intervals done")
 				log.Println("Creating the the interval tree...")
 				now := time.Now()
-				intTree := NewIntervalTree(ints[:])
+				intTree := MustNewIntervalTree(ints[:])
 				log.Printf("IntervalTree created in %d nanoseconds", time.Now().Sub(now).Nanoseconds())
 				log.Printf("Query question with the value %d", valueSearched)
 				now = time.Now()
@@ -173,9 +175,512 @@ func TestIntervalTree_Intersecting(t *testing.T) {
 		)
 	}
 
-	tree := NewIntervalTree(intervals)
+	tree := MustNewIntervalTree(intervals)
 	result := tree.Intersecting(&Interval{Start: lower, End: upper})
 	if len(result) != totalIntersect {
 		t.Fatalf("EXPECTING %d VALUES, GOT %d", totalIntersect, len(result))
 	}
 }
+
+func TestIntervalTree_IntersectingOrder(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 3000, 1000, 100)
+	for _, tree := range treesOf(t, intervals) {
+		again := MustNewIntervalTree(intervals)
+		for q := 0; q < 20; q++ {
+			x := rnd.Intn(1100)
+			query := &Interval{Start: x, End: x + rnd.Intn(100)}
+			want := tree.Intersecting(query)
+			for i := 0; i < 50; i++ {
+				if got := tree.Intersecting(query); !slices.Equal(got, want) {
+					t.Fatalf("INTERSECTING(%s) MUST ALWAYS RETURN THE SAME ORDER", query)
+				}
+			}
+			if got := slices.Collect(tree.IntersectingSeq(query)); !slices.Equal(got, want) {
+				t.Fatalf("INTERSECTING(%s) MUST FOLLOW THE ORDER OF INTERSECTINGSEQ", query)
+			}
+			if got := tree.IntersectingWith(query); !slices.Equal(got, want) {
+				t.Fatalf("INTERSECTINGWITH(%s) MUST FOLLOW THE ORDER OF INTERSECTING", query)
+			}
+			if got := MustNewIntervalTree(intervals).Intersecting(query); !slices.Equal(got, again.Intersecting(query)) {
+				t.Fatalf("TREES BUILT FROM THE SAME INTERVALS MUST RETURN THE SAME ORDER")
+			}
+			small, full := MustNewIntervalTree(intervals[:q]), MustNewIntervalTree(intervals[:q], WithSmallThreshold(0))
+			got := small.Intersecting(query)
+			if !sameIntervals(got, full.Intersecting(query)) {
+				t.Fatalf("INTERSECTING(%s): A SMALL TREE MUST RETURN THE SAME INTERVALS", query)
+			}
+			if !slices.IsSortedFunc(got, func(a, b *Interval) int { return compareBy(a, b, (*Interval).lessStart) }) {
+				t.Fatalf("INTERSECTING(%s): A SMALL TREE MUST SORT THE INTERVALS BY START", query)
+			}
+			// the intervals containing the Start first, then the ones starting after it
+			k := 0
+			for k < len(want) && want[k].Start <= query.Start {
+				k++
+			}
+			if k != tree.CountContaining(query.Start) {
+				t.Fatalf("INTERSECTING(%s): EXPECTING THE %d INTERVALS CONTAINING %d FIRST", query, k, query.Start)
+			}
+			for _, in := range want[k:] {
+				if in.Start <= query.Start {
+					t.Fatalf("INTERSECTING(%s): %s MUST COME FIRST", query, in)
+				}
+			}
+		}
+	}
+}
+
+func TestIntervalTree_All(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	var intervals []*Interval
+	for i := 0; i < 1000; i++ {
+		// few distinct endpoints so that many intervals share them
+		start := rand.Intn(10)
+		intervals = append(intervals, &Interval{Start: start, End: start + rand.Intn(10), Payload: i})
+	}
+	tree := MustNewIntervalTree(intervals, WithSequenceNumbers())
+	all := tree.All()
+	if len(all) != len(intervals) {
+		t.Fatalf("EXPECTING %d VALUES, GOT %d", len(intervals), len(all))
+	}
+	seen := make(map[*Interval]bool)
+	for _, in := range all {
+		if seen[in] {
+			t.Fatalf("%s RETURNED TWICE", in)
+		}
+		seen[in] = true
+	}
+	// the result is a fresh slice
+	all[0] = nil
+	if tree.All()[0] == nil {
+		t.Fatalf("ALL EXPOSES AN INTERNAL SLICE")
+	}
+
+	sorted := tree.AllSorted(ByStart)
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].lessStart(sorted[i-1]) {
+			t.Fatalf("ALLSORTED(BYSTART) IS NOT SORTED: %s AFTER %s", sorted[i], sorted[i-1])
+		}
+	}
+	for i, in := range tree.AllSorted(BySequence) {
+		if in != intervals[i] {
+			t.Fatalf("ALLSORTED(BYSEQUENCE) MUST GIVE BACK THE CONSTRUCTOR ORDER")
+		}
+	}
+	if len(MustNewIntervalTree(nil).All()) != 0 {
+		t.Fatalf("AN EMPTY TREE MUST HAVE NO INTERVAL")
+	}
+}
+
+func TestIntervalTree_Len(t *testing.T) {
+	for _, intervals := range [][]*Interval{nil, {}} {
+		tree := MustNewIntervalTree(intervals)
+		if tree.Len() != 0 || !tree.IsEmpty() {
+			t.Fatalf("EXPECTING AN EMPTY TREE, GOT LEN %d", tree.Len())
+		}
+		if res := tree.Containing(0); len(res) != 0 {
+			t.Fatalf("EXPECTING NO VALUE, GOT %v", res)
+		}
+		if res := tree.Intersecting(&Interval{Start: -10, End: 10}); len(res) != 0 {
+			t.Fatalf("EXPECTING NO VALUE, GOT %v", res)
+		}
+	}
+	tree := MustNewIntervalTree([]*Interval{{Start: 0, End: 1}, {Start: 0, End: 1}, {Start: 4, End: 4}})
+	if tree.Len() != 3 || tree.IsEmpty() {
+		t.Fatalf("EXPECTING 3 VALUES, GOT LEN %d", tree.Len())
+	}
+}
+
+func TestIntervalTree_BrokenInvariant(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 500, 1000, 100)
+	corruptions := map[string]func(tree *IntervalTree){
+		"UNBALANCED NODE": func(tree *IntervalTree) {
+			e := tree.nodes().root().consult()
+			e.byEnd = e.byEnd[1:]
+		},
+	}
+	for name, corrupt := range corruptions {
+		tree := MustNewIntervalTree(intervals, WithSmallThreshold(0), WithEagerIndex())
+		x := tree.nodes().root().consult().xMid
+		if _, err := tree.ContainingE(x); err != nil {
+			t.Fatalf("%s: UNEXPECTED ERROR BEFORE THE CORRUPTION: %v", name, err)
+		}
+		if _, err := tree.IntersectingE(&Interval{Start: 0, End: 1100}); err != nil {
+			t.Fatalf("%s: UNEXPECTED ERROR BEFORE THE CORRUPTION: %v", name, err)
+		}
+		corrupt(tree)
+		query := &Interval{Start: x, End: 1100}
+		if got, err := tree.IntersectingE(query); !errors.Is(err, ErrBrokenInvariant) || got != nil {
+			t.Fatalf("%s: EXPECTING ErrBrokenInvariant, GOT %v (%d VALUES)", name, err, len(got))
+		}
+		if got, err := tree.ContainingE(x); !errors.Is(err, ErrBrokenInvariant) || got != nil {
+			t.Fatalf("%s: EXPECTING ErrBrokenInvariant, GOT %v (%d VALUES)", name, err, len(got))
+		}
+		// the other queries still panic, with a value wrapping the error
+		func() {
+			defer func() {
+				err, ok := recover().(error)
+				if !ok || !errors.Is(err, ErrBrokenInvariant) {
+					t.Fatalf("%s: EXPECTING A PANIC WRAPPING ErrBrokenInvariant, GOT %v", name, err)
+				}
+			}()
+			tree.Intersecting(query)
+		}()
+	}
+}
+
+func TestNewIntervalTree_Error(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []*Interval
+		err       error
+		message   string
+	}{
+		{"ALONE", []*Interval{{Start: 5, End: 3}}, ErrReversedInterval, "interval 0: intervaltree: interval with Start > End: [ 5 - 3 ]"},
+		{"AMONG VALID", []*Interval{{Start: 0, End: 10}, {Start: 12, End: 11}, {Start: 4, End: 6}}, ErrReversedInterval, "interval 1: intervaltree: interval with Start > End: [ 12 - 11 ]"},
+		{"NIL", []*Interval{{Start: 0, End: 10}, {Start: 4, End: 6}, nil}, ErrNilInterval, "interval 2: intervaltree: nil interval"},
+	}
+	for _, test := range tests {
+		tree, err := NewIntervalTree(test.intervals)
+		if !errors.Is(err, test.err) || tree != nil {
+			t.Fatalf("%s: EXPECTING %v AND NO TREE, GOT %v AND %v", test.name, test.err, err, tree)
+		}
+		if err.Error() != test.message {
+			t.Fatalf("%s: EXPECTING MESSAGE %q, GOT %q", test.name, test.message, err.Error())
+		}
+		b := NewBuilder()
+		b.intervals = test.intervals // bypass the validation of the builder
+		if _, err := b.Build(); !errors.Is(err, test.err) {
+			t.Fatalf("%s: BUILD: EXPECTING %v, GOT %v", test.name, test.err, err)
+		}
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatalf("%s: MUSTNEWINTERVALTREE MUST PANIC", test.name)
+				}
+			}()
+			MustNewIntervalTree(test.intervals)
+		}()
+	}
+
+	// the partition fails rather than recursing forever on the intervals the validation rejects
+	for _, intervals := range [][]*Interval{
+		{{Start: 5, End: 3}},
+		{{Start: 0, End: 1}, {Start: 20, End: 8}, {Start: 30, End: 31}},
+	} {
+		if _, err := fromIntervals(intervals, nil, 0); !errors.Is(err, ErrBrokenInvariant) {
+			t.Fatalf("EXPECTING %v, GOT %v", ErrBrokenInvariant, err)
+		}
+	}
+
+	// a valid input builds the same structure as before the validation
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(200), 1000, 50)
+		intervals = append(intervals, &Interval{Start: 7, End: 7})
+		tree, err := NewIntervalTree(intervals)
+		if err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		built, _ := fromIntervals(intervals, nil, 0)
+		if !sameStructure(tree, &IntervalTree{tree: built}) {
+			t.Fatalf("VALIDATION CHANGED THE STRUCTURE")
+		}
+		checkStructure(t, tree)
+	}
+}
+
+func TestNewIntervalTree_Normalization(t *testing.T) {
+	reversed := func() []*Interval {
+		return []*Interval{{Start: 5, End: 3, Payload: "a"}, {Start: 0, End: 10}, {Start: 7, End: 7}, {Start: 20, End: -4, Payload: "b"}}
+	}
+	want := [][2]int{{3, 5}, {0, 10}, {7, 7}, {-4, 20}}
+	for _, inPlace := range []bool{false, true} {
+		intervals := reversed()
+		original := append([]*Interval(nil), intervals...)
+		opt := WithNormalization()
+		if inPlace {
+			opt = WithInPlaceNormalization()
+		}
+		tree, err := NewIntervalTree(intervals, opt, WithSequenceNumbers())
+		if err != nil {
+			t.Fatalf("IN PLACE %t: UNEXPECTED ERROR %v", inPlace, err)
+		}
+		checkStructure(t, tree)
+		stored := tree.AllSorted(BySequence)
+		if len(stored) != len(want) {
+			t.Fatalf("IN PLACE %t: EXPECTING %d INTERVALS, GOT %d", inPlace, len(want), len(stored))
+		}
+		for i, in := range stored {
+			if in.Start != want[i][0] || in.End != want[i][1] || in.Payload != reversed()[i].Payload {
+				t.Fatalf("IN PLACE %t: EXPECTING %v, GOT %s", inPlace, want[i], in)
+			}
+			// the valid intervals, the single point included, are stored as is
+			if (in == original[i]) != (inPlace || i == 1 || i == 2) {
+				t.Fatalf("IN PLACE %t: %s MUST BE STORED AS IS ONLY IF VALID OR NORMALIZED IN PLACE", inPlace, in)
+			}
+		}
+		for i, in := range intervals {
+			if in != original[i] {
+				t.Fatalf("IN PLACE %t: THE CALLER'S SLICE MUST BE LEFT UNTOUCHED", inPlace)
+			}
+			if r := reversed()[i]; !inPlace && (in.Start != r.Start || in.End != r.End) {
+				t.Fatalf("THE CALLER'S INTERVALS MUST BE LEFT UNTOUCHED, GOT %s", in)
+			}
+		}
+		if got := len(tree.Containing(4)); got != 3 {
+			t.Fatalf("IN PLACE %t: CONTAINING(4): EXPECTING 3 INTERVALS, GOT %d", inPlace, got)
+		}
+	}
+	// strict without the option
+	if _, err := NewIntervalTree(reversed()); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING %v, GOT %v", ErrReversedInterval, err)
+	}
+	if _, err := NewIntervalTree([]*Interval{nil}, WithNormalization()); !errors.Is(err, ErrNilInterval) {
+		t.Fatalf("EXPECTING %v, GOT %v", ErrNilInterval, err)
+	}
+}
+
+func TestNewIntervalTree_RepeatedPointers(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		distinct := randomIntervals(rnd, 1+rnd.Intn(100), 500, 30)
+		intervals := append([]*Interval(nil), distinct...)
+		for k := rnd.Intn(50); k >= 0; k-- {
+			intervals = append(intervals, distinct[rnd.Intn(len(distinct))])
+		}
+		rnd.Shuffle(len(intervals), func(a, b int) { intervals[a], intervals[b] = intervals[b], intervals[a] })
+		tree := MustNewIntervalTree(intervals, WithSequenceNumbers())
+		checkStructure(t, tree)
+		if tree.Len() != len(distinct) {
+			t.Fatalf("EXPECTING %d INTERVALS, GOT %d", len(distinct), tree.Len())
+		}
+		for x := -1; x < 532; x++ {
+			if c, in := len(tree.Containing(x)), len(tree.Intersecting(&Interval{Start: x, End: x})); c != in {
+				t.Fatalf("CONTAINING(%d) RETURNS %d INTERVALS, INTERSECTING %d", x, c, in)
+			}
+		}
+		// the first occurrence is kept
+		for _, in := range tree.All() {
+			seq, _ := tree.SeqOf(in)
+			for _, other := range intervals[:seq] {
+				if other == in {
+					t.Fatalf("%s IS NOT NUMBERED AFTER ITS FIRST OCCURRENCE", in)
+				}
+			}
+		}
+		// inserting a stored interval again does nothing either
+		_ = tree.Insert(distinct[0])
+		if tree.Len() != len(distinct) {
+			t.Fatalf("INSERT MUST NOT STORE AN INTERVAL TWICE")
+		}
+	}
+}
+
+func TestNewEmptyIntervalTree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, opts := range [][]Option{nil, {WithSmallThreshold(0)}, {WithSequenceNumbers(), WithAutoRebuild(10)}} {
+		tree := NewEmptyIntervalTree(opts...)
+		if tree.Len() != 0 || !tree.IsEmpty() || len(tree.Containing(0)) != 0 || len(tree.Intersecting(&Interval{Start: -5, End: 5})) != 0 {
+			t.Fatalf("EXPECTING AN EMPTY TREE, GOT LEN %d", tree.Len())
+		}
+		checkStructure(t, tree)
+		for round := 0; round < 3; round++ {
+			// grow from empty, the first insert creating the root
+			intervals := randomIntervals(rnd, 1+rnd.Intn(300), 1000, 50)
+			for _, in := range intervals {
+				if err := tree.Insert(in); err != nil {
+					t.Fatalf("UNEXPECTED ERROR %v", err)
+				}
+			}
+			checkStructure(t, tree)
+			checkQueries(t, rnd, tree, intervals, 1000)
+			// and back to empty
+			for _, in := range intervals {
+				if !tree.Delete(in) {
+					t.Fatalf("CANNOT DELETE %s", in)
+				}
+			}
+			checkStructure(t, tree)
+			if tree.Len() != 0 || len(tree.Intersecting(&Interval{Start: 0, End: 1000})) != 0 || tree.TotalCoveredLength() != 0 {
+				t.Fatalf("EXPECTING AN EMPTY TREE AFTER DELETING EVERYTHING, GOT LEN %d", tree.Len())
+			}
+		}
+	}
+}
+
+func TestNewIntervalTree_TieBreaker(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	byPayload := func(a, b *Interval) bool { return a.Payload.(int) < b.Payload.(int) }
+	for _, n := range []int{10, 300} {
+		// few distinct endpoints, so many intervals share them
+		var intervals []*Interval
+		for i := 0; i < n; i++ {
+			start := rnd.Intn(20)
+			intervals = append(intervals, &Interval{Start: start, End: start + rnd.Intn(5), Payload: i})
+		}
+		build := func(intervals []*Interval, opts ...Option) *IntervalTree {
+			b := NewBuilder(opts...)
+			_ = b.AddAll(intervals)
+			tree, err := b.Build()
+			if err != nil {
+				t.Fatalf("UNEXPECTED ERROR %v", err)
+			}
+			return tree
+		}
+		reference := make([][][]*Interval, 3) // by kind of tree, their orders may differ
+		for round := 0; round < 10; round++ {
+			shuffled := append([]*Interval(nil), intervals...)
+			rnd.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+			trees := []*IntervalTree{
+				MustNewIntervalTree(shuffled, WithTieBreaker(byPayload)),
+				MustNewIntervalTree(shuffled, WithTieBreaker(byPayload), WithSmallThreshold(0)),
+				build(shuffled, WithTieBreaker(byPayload), WithSmallThreshold(0)),
+			}
+			for kind, tree := range trees {
+				var results [][]*Interval
+				for x := -1; x < 26; x++ {
+					results = append(results, tree.Containing(x))
+				}
+				if reference[kind] == nil {
+					reference[kind] = results
+				}
+				for x := range results {
+					if !slices.Equal(results[x], reference[kind][x]) {
+						t.Fatalf("CONTAINING(%d) MUST NOT DEPEND ON THE INPUT ORDER", x-1)
+					}
+				}
+			}
+		}
+
+		// the inserted intervals take their place among the ones with the same endpoints
+		tree := NewEmptyIntervalTree(WithTieBreaker(byPayload))
+		for _, i := range rnd.Perm(n) {
+			_ = tree.Insert(intervals[i])
+		}
+		checkStructure(t, tree)
+		for x := -1; x < 26; x++ {
+			res := tree.Containing(x)
+			for k := 1; k < len(res); k++ {
+				if res[k].Start == res[k-1].Start && res[k].End == res[k-1].End && byPayload(res[k], res[k-1]) {
+					t.Fatalf("CONTAINING(%d): %v BEFORE %v", x, res[k-1].Payload, res[k].Payload)
+				}
+			}
+		}
+	}
+}
+
+// overlapByPoints is the former Intersecting: the intervals containing the first point of the query, then the ones
+// starting after it found by a range search in the BST
+func overlapByPoints(tree *IntervalTree, query *Interval) []*Interval {
+	var res []*Interval
+	if tree.empty(query) {
+		return res
+	}
+	collect := func(in *Interval) bool {
+		res = append(res, in)
+		return true
+	}
+	stab(tree.nodes().root(), query.first(), tree.open, collect)
+	for _, p := range tree.pointsIn(query.first(), tree.last(query)) {
+		if p.x != query.first() {
+			res = append(res, p.starting(tree.open)...)
+		}
+	}
+	return res
+}
+
+func TestIntervalTree_IntersectingByNodes(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 20; i++ {
+		intervals, mode := randomFlagged(rnd, 1+rnd.Intn(2000), 1000, 1+rnd.Intn(200)), []Option(nil)
+		if i%2 == 1 {
+			intervals, mode = randomHalfOpen(rnd, 1+rnd.Intn(2000), 1000, 1+rnd.Intn(200)), []Option{WithHalfOpenIntervals()}
+		}
+		for _, tree := range treesOf(t, intervals, mode...) {
+			// deletions leave empty nodes the traversal must go through
+			for _, in := range tree.All()[:tree.Len()/3] {
+				tree.Delete(in)
+			}
+			for q := 0; q < 200; q++ {
+				start := rnd.Intn(1200) - 100
+				query := &Interval{Start: start, End: start + rnd.Intn(300), StartOpen: rnd.Intn(4) == 0}
+				got, want := tree.Intersecting(query), overlapByPoints(tree, query)
+				if !sameSet(got, want) {
+					t.Fatalf("INTERSECTING(%s): EXPECTING %s, GOT %s", query, listOf(want), listOf(got))
+				}
+				if k := tree.CountContaining(query.first()); !tree.empty(query) && !slices.Equal(got[:k], want[:k]) {
+					t.Fatalf("INTERSECTING(%s) MUST START WITH THE INTERVALS CONTAINING %d", query, query.first())
+				}
+			}
+		}
+	}
+}
+
+func benchmarkIntersecting(b *testing.B, query func(tree *IntervalTree, window *Interval) []*Interval) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 1_000_000, 100_000_000, 10_000))
+	windows := make([]*Interval, 1000)
+	for i := range windows {
+		start := rnd.Intn(100_000_000)
+		windows[i] = &Interval{Start: start, End: start + rnd.Intn(100_000)}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		query(tree, windows[i%len(windows)])
+	}
+}
+
+func BenchmarkIntersecting_Nodes(b *testing.B) {
+	benchmarkIntersecting(b, (*IntervalTree).Intersecting)
+}
+
+func BenchmarkIntersecting_Points(b *testing.B) {
+	benchmarkIntersecting(b, overlapByPoints)
+}
+
+// overlapBySet is the first Intersecting: the intervals containing the first point of the query and the ones
+// referenced by the BST points inside it, the duplicates removed with a set
+func overlapBySet(tree *IntervalTree, query *Interval) []*Interval {
+	var res []*Interval
+	seen := make(map[*Interval]bool)
+	add := func(in *Interval) bool {
+		if !seen[in] {
+			seen[in] = true
+			res = append(res, in)
+		}
+		return true
+	}
+	stab(tree.nodes().root(), query.first(), tree.open, add)
+	for _, p := range tree.pointsIn(query.first(), tree.last(query)) {
+		for _, in := range p.ptrs {
+			add(in)
+		}
+	}
+	return res
+}
+
+// benchmarkWideIntersecting runs queries each overlapping about 100k of 1M intervals
+func benchmarkWideIntersecting(b *testing.B, query func(tree *IntervalTree, window *Interval) []*Interval) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 1_000_000, 10_000_000, 10_000))
+	window := &Interval{Start: 5_000_000, End: 5_990_000}
+	if n := len(query(tree, window)); n < 95_000 || n > 105_000 {
+		b.Fatalf("EXPECTING ABOUT 100K INTERVALS, GOT %d", n)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		query(tree, window)
+	}
+}
+
+func BenchmarkIntersecting_Wide(b *testing.B) {
+	benchmarkWideIntersecting(b, (*IntervalTree).Intersecting)
+}
+
+func BenchmarkIntersecting_Wide_Set(b *testing.B) {
+	benchmarkWideIntersecting(b, overlapBySet)
+}
@@ -0,0 +1,94 @@
+package intervaltree
+
+import (
+	"math"
+	"math/bits"
+)
+
+// -----------------------------------------------------
+// 				JOIN BETWEEN TREES
+// -----------------------------------------------------
+
+// OverlapJoin calls fn once for every pair (x, y) where x is stored in a, y is stored in b and x intersects y,
+// stops as soon as fn returns false. It panics with ErrMixedModes if one tree holds closed intervals and the other
+// half-open ones.
+// When one tree is much smaller, each of its intervals is queried in the other one with Intersecting, which costs
+// O(s log l + p), s and l = sizes of the smaller and the larger trees. Otherwise both endpoint indexes are swept
+// together in O((s + l) log(s + l) + p). The query strategy is chosen when s * log2(l) < s + l.
+func OverlapJoin(a, b *IntervalTree, fn func(x, y *Interval) bool) {
+	sameMode(a, b)
+	if a == nil || b == nil {
+		return
+	}
+	as, bs := a.intervals(), b.intervals()
+	if len(as) == 0 || len(bs) == 0 {
+		return
+	}
+	small, large := len(as), len(bs)
+	if small > large {
+		small, large = large, small
+	}
+	if small*bits.Len(uint(large)) >= small+large {
+		sweepJoin(a, b, fn)
+		return
+	}
+	if len(as) <= len(bs) {
+		for _, x := range as {
+			for _, y := range b.Intersecting(x) {
+				if !fn(x, y) {
+					return
+				}
+			}
+		}
+		return
+	}
+	for _, y := range bs {
+		for _, x := range a.Intersecting(y) {
+			if !fn(x, y) {
+				return
+			}
+		}
+	}
+}
+
+// sweepJoin reports the intersecting pairs of OverlapJoin by sweeping the endpoints of both trees at once
+func sweepJoin(a, b *IntervalTree, fn func(x, y *Interval) bool) {
+	pa, pb := a.pointsIn(math.MinInt, math.MaxInt), b.pointsIn(math.MinInt, math.MaxInt)
+	activeA, activeB := newActiveSet(), newActiveSet()
+	var i, j int
+	for i < len(pa) || j < len(pb) {
+		var p, q *Point // points of a and b at the next coordinate, either may be missing
+		if i < len(pa) && (j == len(pb) || pa[i].x <= pb[j].x) {
+			p = pa[i]
+			i++
+		}
+		if j < len(pb) && (p == nil || pb[j].x == p.x) {
+			q = pb[j]
+			j++
+		}
+		// start everything beginning here before ending anything, as the last points are included
+		if p != nil && !activeA.startAt(p, func(x *Interval) bool { return pairAll(x, activeB.items, fn, false) }) {
+			return
+		}
+		if q != nil && !activeB.startAt(q, func(y *Interval) bool { return pairAll(y, activeA.items, fn, true) }) {
+			return
+		}
+		if p != nil {
+			activeA.endAt(p, a.open)
+		}
+		if q != nil {
+			activeB.endAt(q, b.open)
+		}
+	}
+}
+
+// pairAll calls fn on the interval in paired with every interval of others, in is passed second when swapped. It
+// returns false as soon as fn returns false
+func pairAll(in *Interval, others []*Interval, fn func(x, y *Interval) bool, swapped bool) bool {
+	for _, other := range others {
+		if swapped && !fn(other, in) || !swapped && !fn(in, other) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestOverlapJoin(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	type pair struct{ x, y *Interval }
+	for i := 0; i < 200; i++ {
+		// comparable sizes use the sweep, very different sizes use the queries
+		na, nb := rnd.Intn(60), rnd.Intn(60)
+		if i%2 == 0 {
+			na = rnd.Intn(3)
+		}
+		as, bs := randomIntervals(rnd, na, 300, 30), randomIntervals(rnd, nb, 300, 30)
+		if len(as) > 0 && len(bs) > 0 {
+			bs[0] = as[0] // an interval stored in both trees pairs with itself
+		}
+		want := make(map[pair]bool)
+		for _, x := range as {
+			for _, y := range bs {
+				if x.Start <= y.End && y.Start <= x.End {
+					want[pair{x, y}] = true
+				}
+			}
+		}
+		got := make(map[pair]bool)
+		OverlapJoin(
+			MustNewIntervalTree(as), MustNewIntervalTree(bs), func(x, y *Interval) bool {
+				if got[pair{x, y}] || !want[pair{x, y}] {
+					t.Fatalf("PAIR (%s, %s) REPORTED TWICE OR NOT OVERLAPPING", x, y)
+				}
+				got[pair{x, y}] = true
+				return true
+			},
+		)
+		if len(got) != len(want) {
+			t.Fatalf("EXPECTING %d PAIRS, GOT %d", len(want), len(got))
+		}
+	}
+}
+
+func benchmarkJoinTrees(size int) (*IntervalTree, *IntervalTree) {
+	rnd := rand.New(rand.NewSource(42))
+	return MustNewIntervalTree(randomIntervals(rnd, size, size*10, 50)), MustNewIntervalTree(randomIntervals(rnd, size, size*10, 50))
+}
+
+func BenchmarkOverlapJoin(b *testing.B) {
+	ta, tb := benchmarkJoinTrees(20_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		OverlapJoin(ta, tb, func(x, y *Interval) bool { return true })
+	}
+}
+
+func BenchmarkOverlapJoin_Naive(b *testing.B) {
+	ta, tb := benchmarkJoinTrees(20_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, x := range ta.intervals() {
+			for range tb.Intersecting(x) {
+			}
+		}
+	}
+}
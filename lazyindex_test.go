@@ -0,0 +1,122 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_LazyIndex(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 20; i++ {
+		intervals := randomIntervals(rnd, 100+rnd.Intn(2000), 1000, 1+rnd.Intn(100))
+		lazy, eager := MustNewIntervalTree(intervals), MustNewIntervalTree(intervals, WithEagerIndex())
+		if lazy.bst != nil || eager.bst == nil {
+			t.Fatalf("THE BST MUST ONLY BE BUILT AT CONSTRUCTION WITH WithEagerIndex")
+		}
+		for q := 0; q < 50; q++ {
+			x := rnd.Intn(1200) - 100
+			lazy.Containing(x)
+			lazy.Intersecting(&Interval{Start: x, End: x + rnd.Intn(100)})
+		}
+		if lazy.bst != nil {
+			t.Fatalf("CONTAINING AND INTERSECTING MUST NOT BUILD THE BST")
+		}
+		if err := lazy.CheckIntegrity(); err != nil || lazy.bst != nil {
+			t.Fatalf("CHECKING AN UNINDEXED TREE MUST NOT BUILD THE BST, GOT %v", err)
+		}
+		x := rnd.Intn(1000)
+		if !sameIntervals(lazy.EndingAt(x), eager.EndingAt(x)) || !sameIntervals(lazy.StartingAfter(x), eager.StartingAfter(x)) {
+			t.Fatalf("%d: THE ENDPOINT QUERIES MUST NOT DEPEND ON WHEN THE BST IS BUILT", x)
+		}
+		if !slices.Equal(lazy.Boundaries(), eager.Boundaries()) || lazy.Stats() != eager.Stats() {
+			t.Fatalf("THE POINTS MUST NOT DEPEND ON WHEN THE BST IS BUILT")
+		}
+		checkStructure(t, lazy)
+	}
+	// the mutations index the intervals they update with the endpoints they had
+	intervals := randomIntervals(rnd, 1000, 1000, 100)
+	mutations := map[string]func(tree *IntervalTree){
+		"INSERT":    func(tree *IntervalTree) { _ = tree.Insert(&Interval{Start: 10, End: 20}) },
+		"DELETE":    func(tree *IntervalTree) { tree.Delete(tree.All()[0]) },
+		"REPLACE":   func(tree *IntervalTree) { _ = tree.Replace(tree.All()[0], 5000, 5010) },
+		"TRANSLATE": func(tree *IntervalTree) { _ = tree.Translate(7) },
+		"SCALE":     func(tree *IntervalTree) { _ = tree.Scale(3, 1, RoundFloor) },
+		"WHERE":     func(tree *IntervalTree) { tree.DeleteWhere(func(in *Interval) bool { return in.Start%2 == 0 }) },
+		"EXTEND":    func(tree *IntervalTree) { _ = tree.ExtendWith(randomIntervals(rnd, 500, 1000, 100)) },
+	}
+	for name, mutate := range mutations {
+		tree := MustNewIntervalTree(closedCopies(intervals, 0))
+		mutate(tree)
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("%s: INVALID STRUCTURE: %v", name, err)
+		}
+		if err := tree.CheckIntegrity(); err != nil {
+			t.Fatalf("%s: UNEXPECTED MODIFICATION: %v", name, err)
+		}
+	}
+	// an unindexed tree is checked against its nodes
+	tree := MustNewIntervalTree(intervals)
+	in := tree.All()[rnd.Intn(tree.Len())]
+	start, end := in.Start, in.End
+	in.Start, in.End = -10, -5
+	if err := tree.CheckIntegrity(); !errors.Is(err, ErrModifiedInterval) || tree.bst != nil {
+		t.Fatalf("THE MOVE OF %s OFF ITS NODE MUST BE DETECTED WITHOUT BUILDING THE BST, GOT %v", in, err)
+	}
+	in.Start, in.End = start, end
+}
+
+func TestIntervalTree_ConcurrentLazyIndex(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 5000, 100_000, 1000)
+	for _, n := range []int{len(intervals), 20} {
+		tree, eager := MustNewIntervalTree(intervals[:n]), MustNewIntervalTree(intervals[:n], WithEagerIndex())
+		var wg sync.WaitGroup
+		errs := make(chan string, 32)
+		for g := 0; g < 32; g++ {
+			x := rnd.Intn(100_000)
+			window := &Interval{Start: x, End: x + 1000}
+			intersecting, starting := eager.Intersecting(window), eager.StartingAfter(x)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if !slices.Equal(tree.Intersecting(window), intersecting) {
+					errs <- "INTERSECTING"
+					return
+				}
+				// the first calls needing the BST wait for a single build
+				if !sameIntervals(tree.StartingAfter(x), starting) {
+					errs <- "STARTINGAFTER"
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatalf("%s: A CONCURRENT FIRST QUERY MUST RETURN THE RESULT OF A SEQUENTIAL ONE", err)
+		}
+	}
+}
+
+// benchmarkLazyIndex builds a tree serving Containing queries only
+func benchmarkLazyIndex(b *testing.B, opts ...Option) {
+	rnd := rand.New(rand.NewSource(1))
+	intervals := randomIntervals(rnd, 1_000_000, 10_000_000, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree := MustNewIntervalTree(intervals, opts...)
+		tree.Containing(rnd.Intn(10_000_000))
+	}
+}
+
+func BenchmarkLazyIndex(b *testing.B) {
+	benchmarkLazyIndex(b)
+}
+
+func BenchmarkLazyIndex_Eager(b *testing.B) {
+	benchmarkLazyIndex(b, WithEagerIndex())
+}
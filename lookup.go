@@ -0,0 +1,61 @@
+package intervaltree
+
+// -----------------------------------------------------
+// 				EXACT LOOKUPS
+// -----------------------------------------------------
+
+// Has tells if the IntervalTree stores at least one interval with exactly the endpoints given in parameter
+// Complexity: O(ln n), n = len(intervals in struct)
+func (t *IntervalTree) Has(start, end int) bool {
+	return len(t.Find(start, end)) > 0
+}
+
+// Find returns all the stored intervals with exactly the endpoints given in parameter, whatever their payload.
+// The intervals with an open side are not returned, see FindLike.
+// It descends to the single node able to hold them and binary searches its list sorted by Start.
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
+func (t *IntervalTree) Find(start, end int) []*Interval {
+	return t.FindLike(&Interval{Start: start, End: end})
+}
+
+// FindLike returns all the stored intervals with exactly the endpoints and boundary flags of the interval given in
+// parameter, whatever their payload, nil for a nil interval
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
+func (t *IntervalTree) FindLike(like *Interval) []*Interval {
+	t = t.orEmpty()
+	if like == nil || t.empty(like) {
+		return nil
+	}
+	itr := t.locate(like.first(), t.last(like))
+	if itr.isBottom() {
+		return nil
+	}
+	return itr.consult().find(like)
+}
+
+// NewIntervalTreeWithKeyFunc creates a new interval tree with the intervals given in parameter, indexed by the key
+// computed by keyFunc, see WithKeyFunc
+func NewIntervalTreeWithKeyFunc(intervals []*Interval, keyFunc func(*Interval) interface{}, opts ...Option) (*IntervalTree, error) {
+	return NewIntervalTree(intervals, append(opts, WithKeyFunc(keyFunc))...)
+}
+
+// FindByKey returns all the intervals whose key is the one given in parameter, in a new slice, nil if there is none
+// or if the tree was built without key function. Keys are computed once when intervals are added: changing a payload afterwards invalidates the
+// index for that interval
+// Complexity: O(k), k = returned intervals
+func (t *IntervalTree) FindByKey(key interface{}) []*Interval {
+	t = t.orEmpty()
+	found := t.keys[key]
+	if len(found) == 0 {
+		return nil
+	}
+	res := make([]*Interval, len(found))
+	copy(res, found)
+	return res
+}
+
+// indexKey adds the interval to the secondary index
+func (t *IntervalTree) indexKey(in *Interval) {
+	key := t.keyFunc(in)
+	t.keys[key] = append(t.keys[key], in)
+}
@@ -0,0 +1,84 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Find(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 2000, 1000, 50)
+	// duplicates with identical endpoints and different payloads
+	for i := 0; i < 20; i++ {
+		intervals = append(intervals, &Interval{Start: 500, End: 520, Payload: i})
+	}
+	tree := MustNewIntervalTree(intervals)
+	for _, in := range intervals {
+		found := tree.Find(in.Start, in.End)
+		want := 0
+		for _, other := range intervals {
+			if other.Start == in.Start && other.End == in.End {
+				want++
+			}
+		}
+		if len(found) != want || !tree.Has(in.Start, in.End) {
+			t.Fatalf("EXPECTING %d INTERVALS %s, GOT %d", want, in, len(found))
+		}
+		for _, f := range found {
+			if f.Start != in.Start || f.End != in.End {
+				t.Fatalf("FIND(%d, %d) RETURNED %s", in.Start, in.End, f)
+			}
+		}
+	}
+	if got := tree.Find(500, 520); len(got) < 20 {
+		t.Fatalf("EXPECTING THE 20 DUPLICATES, GOT %d", len(got))
+	}
+
+	near := MustNewIntervalTree([]*Interval{{Start: 10, End: 20}, {Start: 10, End: 22}})
+	for _, miss := range [][2]int{{9, 20}, {11, 20}, {10, 19}, {10, 21}, {10, 23}} {
+		if near.Has(miss[0], miss[1]) {
+			t.Fatalf("[ %d - %d ] IS NOT STORED", miss[0], miss[1])
+		}
+	}
+	if MustNewIntervalTree(nil).Has(0, 0) {
+		t.Fatalf("AN EMPTY TREE HAS NO INTERVAL")
+	}
+}
+
+func TestIntervalTree_FindByKey(t *testing.T) {
+	var intervals []*Interval
+	for i := 0; i < 100; i++ {
+		intervals = append(intervals, &Interval{Start: i, End: i + 10, Payload: i % 30})
+	}
+	byPayload := func(in *Interval) interface{} { return in.Payload }
+	tree, err := NewIntervalTreeWithKeyFunc(intervals, byPayload)
+	if err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	for key := 0; key < 30; key++ {
+		found := tree.FindByKey(key)
+		want := 3
+		if key < 10 {
+			want = 4 // colliding keys return all their intervals
+		}
+		if len(found) != want {
+			t.Fatalf("KEY %d: EXPECTING %d INTERVALS, GOT %d", key, want, len(found))
+		}
+		for _, in := range found {
+			if in.Payload != key {
+				t.Fatalf("KEY %d: GOT %s WITH PAYLOAD %v", key, in, in.Payload)
+			}
+		}
+	}
+	if found := tree.FindByKey(30); len(found) != 0 {
+		t.Fatalf("EXPECTING NO INTERVAL FOR AN UNKNOWN KEY, GOT %v", found)
+	}
+	plain, _ := NewIntervalTreeWithKeyFunc(intervals, nil)
+	if found := plain.FindByKey(0); len(found) != 0 {
+		t.Fatalf("A NIL KEY FUNCTION MUST DISABLE THE INDEX, GOT %v", found)
+	}
+	if plain.keys != nil {
+		t.Fatalf("A NIL KEY FUNCTION MUST NOT ALLOCATE THE INDEX")
+	}
+}
@@ -0,0 +1,337 @@
+package intervaltree
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// -----------------------------------------------------
+// 				MERGING TREES
+// -----------------------------------------------------
+
+// Merge returns a new IntervalTree holding the intervals of all the trees given in parameter, an *Interval stored
+// in several of them being stored once. The endpoints already sorted in the nodes of every tree are merged rather
+// than sorted again. The new tree takes the settings of the first tree but records neither sequence numbers nor
+// multiplicities. The trees given in parameter are left untouched, and must all hold closed or all hold half-open
+// intervals, else Merge panics with ErrMixedModes. The nil trees are skipped.
+// Build complexity: O(n log n), n = total number of intervals, without sorting the endpoints
+func Merge(trees ...*IntervalTree) *IntervalTree {
+	trees = slices.DeleteFunc(slices.Clone(trees), func(t *IntervalTree) bool { return t == nil })
+	if len(trees) == 0 {
+		return MustNewIntervalTree(nil)
+	}
+	sameMode(trees...)
+	m := &IntervalTree{
+		keyFunc: trees[0].keyFunc, equal: trees[0].equal, tie: trees[0].tie, open: trees[0].open,
+		swap: trees[0].swap, checked: trees[0].checked, rebuildAt: trees[0].rebuildAt, owner: new(owner),
+	}
+	m.merge(trees)
+	if m.keyFunc != nil {
+		m.keys = make(map[interface{}][]*Interval, m.size)
+		for _, in := range m.intervals() {
+			m.indexKey(in)
+		}
+	}
+	return m
+}
+
+// MergeInPlace adds to the IntervalTree the intervals of other it does not already hold, see Merge. The tree keeps
+// its settings and sequence numbers, the new intervals being numbered after the existing ones. The structure is
+// built again so the tree ends up balanced. With WithMultiplicity, the intervals of other are inserted one by one,
+// adding their counts to the representatives with the same endpoints. other is left untouched. It panics with
+// ErrMixedModes if one tree holds closed intervals and the other half-open ones, and with ErrNilTree if the tree is
+// nil while other holds intervals.
+// Build complexity: O(n log n), n = total number of intervals, without sorting the endpoints
+func (t *IntervalTree) MergeInPlace(other *IntervalTree) {
+	if t == nil {
+		if other.Len() > 0 {
+			panic(ErrNilTree)
+		}
+		return
+	}
+	sameMode(t, other)
+	other = other.orEmpty()
+	t.unshare()
+	if t.counts != nil {
+		for _, in := range other.intervals() {
+			_ = t.Insert(in) // cannot fail, in is stored in other
+			rep := t.FindLike(in)[0]
+			t.counts[rep] += other.count(in) - 1
+		}
+		return
+	}
+	var added []*Interval
+	walk(
+		other.nodes(), func(e *elt) bool {
+			for _, in := range e.leftSorted {
+				if !t.holds(in) {
+					added = append(added, in)
+				}
+			}
+			return true
+		},
+	)
+	t.merge([]*IntervalTree{t, other})
+	t.register(added)
+	t.mutated()
+	t.mutations = 0
+}
+
+// ExtendRatio is the size ratio between the IntervalTree and a batch given to ExtendWith under which the structure
+// is built again rather than receiving the intervals one by one
+const ExtendRatio = 8
+
+// ExtendWith adds the intervals to the IntervalTree, the ones it already holds being ignored as with Insert. A batch
+// smaller than 1/ExtendRatio of the tree is inserted interval by interval, in O(b (ln n + m)), the tree getting
+// slightly unbalanced as with Insert. A larger batch is built into a tree and merged with this one as MergeInPlace
+// does, in O((n + b) log(n + b)), giving a balanced tree. Either way the queries answer as a tree built at once over
+// all the intervals, and the new intervals are numbered in the order of the batch. With WithMultiplicity, the
+// intervals are always inserted one by one. The batch is validated first, nothing being added if one is invalid.
+// It returns ErrNilTree on a nil IntervalTree.
+// Complexity: O(min(b (ln n + m), (n + b) log(n + b))), n = len(intervals in struct) and b = len(intervals)
+func (t *IntervalTree) ExtendWith(intervals []*Interval) error {
+	if t == nil {
+		return ErrNilTree
+	}
+	for i, in := range intervals {
+		if err := validate(in, t.open); err != nil {
+			return fmt.Errorf("interval %d: %w", i, err)
+		}
+	}
+	if t.counts != nil || len(intervals)*ExtendRatio < t.size {
+		for _, in := range intervals {
+			_ = t.Insert(in) // cannot fail, in is valid
+		}
+		return nil
+	}
+	var added []*Interval
+	seen := make(map[*Interval]bool, len(intervals))
+	for _, in := range intervals {
+		if !seen[in] && !t.holds(in) {
+			added = append(added, in)
+		}
+		seen[in] = true
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	t.unshare()
+	t.merge([]*IntervalTree{t, MustNewIntervalTree(added, t.mode())}) // cannot fail, added holds valid distinct intervals
+	t.register(added)
+	t.mutated()
+	t.mutations = 0
+	return nil
+}
+
+// register numbers the intervals just added to the structure and indexes their keys
+func (t *IntervalTree) register(added []*Interval) {
+	for _, in := range added {
+		if t.seq != nil {
+			t.seq[in] = t.nextSeq
+			t.nextSeq++
+		}
+		if t.keyFunc != nil {
+			t.indexKey(in)
+		}
+	}
+}
+
+// merge replaces the structure of t by the one holding the intervals of all the trees
+func (t *IntervalTree) merge(trees []*IntervalTree) {
+	owner := make(map[*Interval]int) // index of the first tree holding the interval
+	lists := make([][]endpoint, len(trees))
+	var runs []*Interval
+	for i, tree := range trees {
+		for _, in := range tree.intervals() {
+			if _, ok := owner[in]; !ok {
+				owner[in] = i
+			}
+		}
+		dst, tmp := make([]endpoint, 2*tree.size), make([]endpoint, 2*tree.size)
+		n := sortedEndpoints(tree.nodes().root(), func(in *Interval) bool { return owner[in] == i }, dst, tmp, t.open)
+		lists[i] = dst[:n]
+		for _, r := range tree.cover.runs() {
+			runs = append(runs, &r)
+		}
+	}
+	for len(lists) > 1 {
+		var next [][]endpoint
+		for i := 0; i+1 < len(lists); i += 2 {
+			next = append(next, mergeEndpoints(lists[i], lists[i+1]))
+		}
+		if len(lists)%2 == 1 {
+			next = append(next, lists[len(lists)-1])
+		}
+		lists = next
+	}
+	ends := lists[0]
+	t.bst, t.index = newPointTree(pointsOf(ends)), nil
+	t.tree = fromEndpoints(ends, make([]endpoint, len(ends)), new([]*Interval), newArena(len(ends)/2), t.tie, t.open)
+	t.cover = newCoverage(runs, 0) // the runs are closed
+	t.size = len(ends) / 2
+}
+
+// endpoint is one end of an interval
+type endpoint struct {
+	x     int
+	in    *Interval
+	start bool // true for the Start of the interval
+}
+
+// sortedEndpoints writes at the beginning of dst the endpoints of the intervals of the subtree for which keep is
+// true, sorted by coordinate, and returns their number. The endpoints of the left subtree are before xMid and the
+// ones of the right subtree after, so the sorted lists of the nodes only need to be merged, using tmp as buffer.
+// An interval goes from its first to its last point, see lastPoint.
+// Complexity: O(n log n), n = number of intervals in the subtree
+// PRE: len(dst) == len(tmp) and both can hold all the endpoints of the subtree
+func sortedEndpoints(itr *iterator, keep func(*Interval) bool, dst, tmp []endpoint, open int) int {
+	if itr.isBottom() {
+		return 0
+	}
+	e := itr.consult()
+	// endpoints up to xMid: the left subtree merged with the Starts of the node
+	left := sortedEndpoints(itr.left(), keep, tmp, dst, open)
+	n, i := 0, 0
+	for _, in := range e.leftSorted {
+		if !keep(in) {
+			continue
+		}
+		for ; i < left && tmp[i].x <= in.first(); i++ {
+			dst[n] = tmp[i]
+			n++
+		}
+		dst[n] = endpoint{in.first(), in, true}
+		n++
+	}
+	n += copy(dst[n:], tmp[i:left])
+	// endpoints from xMid: the Ends of the node merged with the right subtree
+	right := sortedEndpoints(itr.right(), keep, tmp[n:], dst[n:], open)
+	out, j := dst[n:], 0
+	m := 0
+	for k := len(e.byEnd) - 1; k >= 0; k-- {
+		in := e.endAt(k)
+		if !keep(in) {
+			continue
+		}
+		for ; j < right && tmp[n+j].x < lastPoint(in, open); j++ {
+			out[m] = tmp[n+j]
+			m++
+		}
+		out[m] = endpoint{lastPoint(in, open), in, false}
+		m++
+	}
+	m += copy(out[m:], tmp[n+j:n+right])
+	return n + m
+}
+
+// mergeEndpoints merges two lists of endpoints sorted by coordinate into a new sorted list
+func mergeEndpoints(a, b []endpoint) []endpoint {
+	res := make([]endpoint, len(a)+len(b))
+	mergeInto(res, a, b)
+	return res
+}
+
+// mergeInto merges two lists of endpoints sorted by coordinate into dst
+// PRE: len(dst) == len(a) + len(b)
+func mergeInto(dst, a, b []endpoint) {
+	i, j, n := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if b[j].x < a[i].x {
+			dst[n] = b[j]
+			j++
+		} else {
+			dst[n] = a[i]
+			i++
+		}
+		n++
+	}
+	n += copy(dst[n:], a[i:])
+	copy(dst[n:], b[j:])
+}
+
+// compareEndpoints orders the endpoints by coordinate
+func compareEndpoints(a, b endpoint) int {
+	return cmp.Compare(a.x, b.x)
+}
+
+// pointsOf fuses the endpoints sorted by coordinate into the sorted points of the BST
+func pointsOf(ends []endpoint) []Point {
+	var points []Point
+	for i := 0; i < len(ends); {
+		j := i + 1
+		for j < len(ends) && ends[j].x == ends[i].x {
+			j++
+		}
+		p := Point{ends[i].x, make([]*Interval, j-i)}
+		for k := i; k < j; k++ {
+			p.ptrs[k-i] = ends[k].in
+		}
+		points = append(points, p)
+		i = j
+	}
+	return points
+}
+
+// fromEndpoints creates a binary tree containing elt struct as data from the endpoints of the intervals sorted by
+// coordinate: every node takes the median endpoint as xMid and holds the intervals containing it. The endpoints are
+// partitioned back and forth between ends and buf, which keeps them sorted so nothing is sorted but the intervals
+// of every node. mid is the buffer collecting them and tie
+// orders the ones with the same endpoints, see newElt. An interval goes from its first to its last point. The
+// subtrees waiting to be built are kept on an explicit stack, so the construction does not depend on the depth of
+// the tree. The elements are carved out of the arena mem, or allocated one by one if it is nil
+// Build complexity: O(n log n), n = len(ends) / 2
+// PRE: len(buf) == len(ends)
+func fromEndpoints(ends, buf []endpoint, mid *[]*Interval, mem *arena, tie func(a, b *Interval) bool, open int) *nodeTree {
+	// a subtree to build at itr, the endpoints of its intervals in ends and buf the space to partition them in. The
+	// subtrees of a node get disjoint ranges of both slices, so they can be built in any order
+	type pending struct {
+		itr       *iterator
+		ends, buf []endpoint
+	}
+	tree := &nodeTree{}
+	stack := []pending{{tree.root(), ends, buf}}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if len(p.ends) == 0 {
+			continue
+		}
+		ends, buf := p.ends, p.buf
+		xMid := ends[len(ends)/2].x
+		*mid = (*mid)[:0]
+		left, right := 0, 0
+		for _, end := range ends {
+			switch {
+			case lastPoint(end.in, open) < xMid:
+				left++
+			case end.in.first() > xMid:
+				right++
+			}
+		}
+		// the left endpoints go to the front of buf and the right ones to its back, in the same order
+		l, r := 0, len(buf)-right
+		for _, end := range ends {
+			switch {
+			case lastPoint(end.in, open) < xMid:
+				buf[l] = end
+				l++
+			case end.in.first() > xMid:
+				buf[r] = end
+				r++
+			case end.start:
+				*mid = append(*mid, end.in)
+			}
+		}
+		// newElt copies mid, so the subtrees can reuse it
+		e := mem.newElt(*mid, xMid, tie)
+		e.lo, e.hi = ends[0].x, ends[len(ends)-1].x // the endpoints of the subtree are sorted
+		p.itr.insert(e)
+		stack = append(
+			stack,
+			pending{p.itr.right(), buf[len(buf)-right:], ends[len(ends)-right:]},
+			pending{p.itr.left(), buf[:left], ends[:left]},
+		)
+	}
+	return tree
+}
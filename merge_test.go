@@ -0,0 +1,116 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	var trees []*IntervalTree
+	var all []*Interval
+	for i := 0; i < 5; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(500), 1000, 50)
+		all = append(all, intervals...)
+		// shares some intervals with the previous tree
+		if i > 0 {
+			intervals = append(intervals, trees[i-1].All()[:trees[i-1].Len()/2]...)
+		}
+		trees = append(trees, MustNewIntervalTree(intervals))
+	}
+	trees = append(trees, MustNewIntervalTree(nil))
+	merged := Merge(trees...)
+	checkStructure(t, merged)
+	checkQueries(t, rnd, merged, all, 1000)
+	if !sameStructure(merged, MustNewIntervalTree(merged.AllSorted(ByStart))) {
+		t.Fatalf("MERGE MUST BUILD THE SAME STRUCTURE AS NEWINTERVALTREE")
+	}
+	// the merged tree is independent from its inputs
+	for _, in := range merged.All()[:100] {
+		merged.Delete(in)
+	}
+	for _, tree := range trees {
+		checkStructure(t, tree)
+	}
+	if Merge().Len() != 0 {
+		t.Fatalf("MERGING NOTHING MUST GIVE AN EMPTY TREE")
+	}
+
+	single := &Interval{Start: 3, End: 3}
+	tree := MustNewIntervalTree([]*Interval{single, {Start: 1, End: 5}}, WithSequenceNumbers())
+	other := MustNewIntervalTree([]*Interval{single, {Start: 2, End: 8}, {Start: 10, End: 12}})
+	tree.MergeInPlace(other)
+	checkStructure(t, tree)
+	if tree.Len() != 4 || len(tree.Containing(3)) != 3 {
+		t.Fatalf("EXPECTING 4 INTERVALS AND 3 CONTAINING 3, GOT %v", tree.All())
+	}
+	if seq, _ := tree.SeqOf(tree.Find(10, 12)[0]); seq != 3 {
+		t.Fatalf("EXPECTING THE SEQUENCE NUMBER 3 FOR THE LAST MERGED INTERVAL, GOT %d", seq)
+	}
+	if other.Len() != 3 {
+		t.Fatalf("MERGEINPLACE MUST NOT MODIFY ITS PARAMETER")
+	}
+}
+
+func BenchmarkMerge(b *testing.B) {
+	ta, tb := benchmarkJoinTrees(50_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Merge(ta, tb)
+	}
+}
+
+func BenchmarkMerge_Rebuild(b *testing.B) {
+	ta, tb := benchmarkJoinTrees(50_000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MustNewIntervalTree(append(ta.All(), tb.All()...))
+	}
+}
+
+func TestIntervalTree_ExtendWith(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 60; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(1000), 1000, 50)
+		tree := MustNewIntervalTree(intervals, WithSequenceNumbers())
+		// small and large batches, with repeated pointers and intervals already held
+		batch := randomIntervals(rnd, rnd.Intn(50+i*i), 1000, 50)
+		all := append(append([]*Interval{}, intervals...), batch...)
+		if len(batch) > 0 {
+			batch = append(batch, batch[0])
+		}
+		if len(intervals) > 0 {
+			batch = append(batch, intervals[rnd.Intn(len(intervals))])
+		}
+		if err := tree.ExtendWith(batch); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, all, 1000)
+		// the new intervals are numbered in the order of the batch
+		var last uint64
+		for k, in := range all[len(intervals):] {
+			seq, _ := tree.SeqOf(in)
+			if k > 0 && seq <= last {
+				t.Fatalf("%s NUMBERED %d AFTER %d", in, seq, last)
+			}
+			last = seq
+		}
+	}
+
+	tree := MustNewIntervalTree([]*Interval{{Start: 0, End: 10}})
+	err := tree.ExtendWith([]*Interval{{Start: 1, End: 2}, nil})
+	if !errors.Is(err, ErrNilInterval) || tree.Len() != 1 {
+		t.Fatalf("AN INVALID BATCH MUST BE REFUSED AS A WHOLE, GOT %v AND %d INTERVALS", err, tree.Len())
+	}
+
+	counted := MustNewIntervalTree([]*Interval{{Start: 0, End: 10}}, WithMultiplicity())
+	if err := counted.ExtendWith([]*Interval{{Start: 0, End: 10}, {Start: 0, End: 10}, {Start: 5, End: 6}}); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	if counted.Len() != 2 || counted.CountContaining(5) != 4 {
+		t.Fatalf("EXPECTING 2 INTERVALS COUNTING 4 AT 5, GOT %d AND %d", counted.Len(), counted.CountContaining(5))
+	}
+}
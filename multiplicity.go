@@ -0,0 +1,74 @@
+package intervaltree
+
+// -----------------------------------------------------
+// 				MULTIPLICITIES
+// -----------------------------------------------------
+
+// IntervalCount is a stored interval with the number of times it was given, see WithMultiplicity
+type IntervalCount struct {
+	Interval *Interval
+	Count    int
+}
+
+// ContainingWithCount returns the intervals containing the value x, in the same order as Containing, with their
+// multiplicities. Without WithMultiplicity every count is 1
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = returned intervals
+func (t *IntervalTree) ContainingWithCount(x int) []IntervalCount {
+	t = t.orEmpty()
+	containing := t.Containing(x)
+	res := make([]IntervalCount, len(containing))
+	for i, in := range containing {
+		res[i] = IntervalCount{Interval: in, Count: t.count(in)}
+	}
+	return res
+}
+
+// CountContaining returns the number of intervals containing the value x, weighted by their multiplicities. The
+// intervals of the nodes on the path of x are counted from the cutoffs of their lists, found by binary search,
+// without being visited, unless the tree was built WithMultiplicity: their counts are added up then
+// Complexity: O(ln n · ln m), n = len(intervals in struct) and m = number of intervals in a node, and O(ln n + k)
+// WithMultiplicity, k = intervals containing x
+func (t *IntervalTree) CountContaining(x int) int {
+	t = t.orEmpty()
+	t.heal()
+	t.checkIntegrity()
+	if t.counts == nil {
+		return t.stabCount(x)
+	}
+	total := 0
+	stabCuts(
+		t.nodes().root(), x, t.open, func(e *elt, byEnd bool, n int) bool {
+			for k := 0; k < n; k++ {
+				if byEnd {
+					total += t.count(e.endAt(k))
+				} else {
+					total += t.count(e.leftSorted[k])
+				}
+			}
+			return true
+		},
+	)
+	return total
+}
+
+// stabCount returns the number of stored intervals containing the value x, each counted once whatever its
+// multiplicity, see CountContaining
+// Complexity: O(ln n · ln m), n = len(intervals in struct) and m = number of intervals in a node
+func (t *IntervalTree) stabCount(x int) int {
+	total := 0
+	stabCuts(
+		t.nodes().root(), x, t.open, func(_ *elt, _ bool, n int) bool {
+			total += n
+			return true
+		},
+	)
+	return total
+}
+
+// count returns the multiplicity of the stored interval, 1 without WithMultiplicity
+func (t *IntervalTree) count(in *Interval) int {
+	if t.counts == nil {
+		return 1
+	}
+	return t.counts[in]
+}
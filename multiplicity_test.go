@@ -0,0 +1,120 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Multiplicity(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	// heavy duplication: few distinct endpoints
+	var intervals []*Interval
+	counts := make(map[[2]int]int)
+	for i := 0; i < 2000; i++ {
+		start := rnd.Intn(50)
+		in := &Interval{Start: start, End: start + rnd.Intn(10)}
+		intervals = append(intervals, in)
+		counts[[2]int{in.Start, in.End}]++
+	}
+	check := func(step string, tree *IntervalTree) {
+		checkStructure(t, tree)
+		if tree.Len() != len(counts) {
+			t.Fatalf("%s: EXPECTING %d REPRESENTATIVES, GOT %d", step, len(counts), tree.Len())
+		}
+		for x := -1; x < 61; x++ {
+			want, distinct := 0, 0
+			for ends, count := range counts {
+				if ends[0] <= x && x <= ends[1] {
+					want += count
+					distinct++
+				}
+			}
+			if got := len(tree.Containing(x)); got != distinct {
+				t.Fatalf("%s: CONTAINING(%d): EXPECTING %d REPRESENTATIVES, GOT %d", step, x, distinct, got)
+			}
+			if got := tree.CountContaining(x); got != want {
+				t.Fatalf("%s: COUNTCONTAINING(%d): EXPECTING %d, GOT %d", step, x, want, got)
+			}
+			for _, ic := range tree.ContainingWithCount(x) {
+				if c := counts[[2]int{ic.Interval.Start, ic.Interval.End}]; ic.Count != c {
+					t.Fatalf("%s: COUNT OF %s: EXPECTING %d, GOT %d", step, ic.Interval, c, ic.Count)
+				}
+			}
+		}
+	}
+	tree := MustNewIntervalTree(intervals, WithMultiplicity())
+	check("CONSTRUCTION", tree)
+	// the first occurrence is the representative
+	for _, in := range tree.All() {
+		for _, other := range intervals {
+			if other.Start == in.Start && other.End == in.End {
+				if other != in {
+					t.Fatalf("%s IS NOT THE FIRST OCCURRENCE", in)
+				}
+				break
+			}
+		}
+	}
+
+	// inserting duplicates increments, deleting decrements
+	for i := 0; i < 500; i++ {
+		start := rnd.Intn(60)
+		in := &Interval{Start: start, End: start + rnd.Intn(10)}
+		if err := tree.Insert(in); err != nil {
+			t.Fatalf("INSERT %s: %v", in, err)
+		}
+		counts[[2]int{in.Start, in.End}]++
+	}
+	check("INSERT", tree)
+	for i := 0; i < 2000; i++ {
+		start := rnd.Intn(60)
+		in := &Interval{Start: start, End: start + rnd.Intn(10)}
+		ends := [2]int{in.Start, in.End}
+		if got := tree.Delete(in); got != (counts[ends] > 0) {
+			t.Fatalf("DELETE %s: EXPECTING %t, GOT %t", in, counts[ends] > 0, got)
+		}
+		if counts[ends] > 0 {
+			counts[ends]--
+			if counts[ends] == 0 {
+				delete(counts, ends)
+			}
+		}
+	}
+	check("DELETE", tree)
+
+	// the derived trees and the clones keep the counts
+	check("CLONE", tree.CloneDeep())
+	check("FILTER", tree.Filter(func(*Interval) bool { return true }))
+	snapshot := tree.Snapshot()
+	_ = tree.Insert(&Interval{Start: 100, End: 100})
+	check("SNAPSHOT", snapshot)
+}
+
+func TestIntervalTree_MultiplicityReplace(t *testing.T) {
+	tree := MustNewIntervalTree(
+		[]*Interval{{Start: 1, End: 5}, {Start: 1, End: 5}, {Start: 20, End: 30}}, WithMultiplicity(), WithSmallThreshold(0),
+	)
+	rep := tree.Containing(3)[0]
+	if err := tree.Replace(rep, 100, 200); !errors.Is(err, ErrCountedInterval) {
+		t.Fatalf("EXPECTING %v, GOT %v", ErrCountedInterval, err)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("INVALID STRUCTURE: %v", err)
+	}
+	if rep.Start != 1 || rep.End != 5 || tree.CountContaining(3) != 2 {
+		t.Fatalf("A REJECTED REPLACE MUST LEAVE BOTH COPIES OF [1, 5], GOT %s COUNTING %d", rep, tree.CountContaining(3))
+	}
+	// a single copy moves
+	tree.Delete(rep)
+	if err := tree.Replace(rep, 100, 200); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("INVALID STRUCTURE: %v", err)
+	}
+	if tree.CountContaining(3) != 0 || tree.CountContaining(150) != 1 || tree.Len() != 2 {
+		t.Fatalf("EXPECTING [1, 5] MOVED TO [100, 200], GOT %v", tree.AllSorted(ByStart))
+	}
+}
@@ -0,0 +1,460 @@
+package intervaltree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// -----------------------------------------------------
+// 				MUTATIONS
+// -----------------------------------------------------
+
+// Insert adds the interval to the IntervalTree: it goes into the first node on its path whose xMid it contains,
+// or into a new leaf node centered on the interval, and its endpoints are fused into the BST points.
+// The tree is not rebalanced, so many inserts may degrade the query performance compared to a tree built at once.
+// Inserting an interval already stored does nothing, except incrementing its count with WithMultiplicity. It returns
+// ErrNilTree on a nil IntervalTree, which cannot hold the interval.
+// Complexity: O(ln n + m), n = len(intervals in struct) and m = number of intervals in the receiving node
+func (t *IntervalTree) Insert(in *Interval) error {
+	if t == nil {
+		return ErrNilTree
+	}
+	if err := validate(in, t.open); err != nil {
+		return err
+	}
+	if t.counts == nil && t.holds(in) {
+		return nil
+	}
+	t.unshare()
+	if t.counts != nil {
+		if found := t.FindLike(in); len(found) > 0 {
+			t.counts[found[0]]++
+			return nil
+		}
+		t.counts[in] = 1
+	}
+	path := t.edit(in.first(), t.last(in))
+	if itr := &path[len(path)-1]; itr.isBottom() {
+		// middle of the interval, without overflowing on extreme coordinates
+		e := newElt([]*Interval{in}, in.first()+int((uint(t.last(in))-uint(in.first()))/2), t.tie)
+		e.owner = t.tree.owner
+		e.span(t.open)
+		itr.insert(e)
+		widen(path[:len(path)-1], in.first(), t.last(in))
+	} else {
+		itr.consult().insert(in, t.tie)
+		widen(path, in.first(), t.last(in))
+	}
+	t.addPoint(in.first(), in)
+	t.addPoint(t.last(in), in)
+	t.cover.insert(in.first(), t.last(in))
+	t.size++
+	if t.seq != nil {
+		t.seq[in] = t.nextSeq
+		t.nextSeq++
+	}
+	if t.keyFunc != nil {
+		t.indexKey(in)
+	}
+	t.mutated()
+	return nil
+}
+
+// mutated invalidates the views and iterators created before a mutation, and the sorted slice of a small tree
+func (t *IntervalTree) mutated() {
+	t.epoch++
+	t.mutations++
+	t.small, t.lazy = nil, nil
+}
+
+// addPoint links the interval to the BST point at x, creating the point if needed
+func (t *IntervalTree) addPoint(x int, in *Interval) {
+	p := &Point{x, []*Interval{in}}
+	if found := t.points().edit(x); found != nil {
+		found.fusion(p)
+		return
+	}
+	t.points().add(p)
+}
+
+// insert adds the interval to both orders of the element, after the intervals comparing equal so that the order
+// matches the stable sort of newElt with the same tie
+// PRE: in contains e.xMid
+// Complexity: O(m), m = number of intervals in the element, cause of shifting the lists
+func (e *elt) insert(in *Interval, tie func(a, b *Interval) bool) {
+	j := sort.Search(len(e.byEnd), func(k int) bool { return endOrder(in, e.endAt(k), tie) })
+	i := sort.Search(len(e.leftSorted), func(k int) bool { return startOrder(in, e.leftSorted[k], tie) })
+	e.leftSorted = insertAt(e.leftSorted, i, in)
+	for k, pos := range e.byEnd {
+		if pos >= int32(i) {
+			e.byEnd[k]++
+		}
+	}
+	e.byEnd = append(e.byEnd, 0)
+	copy(e.byEnd[j+1:], e.byEnd[j:])
+	e.byEnd[j] = int32(i)
+}
+
+// insertAt inserts the interval at the index i of the list
+func insertAt(list []*Interval, i int, in *Interval) []*Interval {
+	list = append(list, nil)
+	copy(list[i+1:], list[i:])
+	list[i] = in
+	return list
+}
+
+// Delete removes the interval from the IntervalTree, matched by pointer identity, and tells if it was stored.
+// Empty leaf nodes are removed, up to the first ancestor still holding intervals or children; an empty node with
+// children stays to keep routing queries to them. With WithMultiplicity, the interval is matched by endpoints and
+// its count is decremented, the representative being removed at zero.
+// Complexity: O(ln n + m + k), n = len(intervals in struct), m = number of intervals in the node holding it and
+// k = number of intervals intersecting it, needed to update the merged coverage
+func (t *IntervalTree) Delete(in *Interval) bool {
+	t = t.orEmpty()
+	if in == nil {
+		return false
+	}
+	t.unshare()
+	if t.counts != nil {
+		found := t.FindLike(in)
+		if len(found) == 0 {
+			return false
+		}
+		if in = found[0]; t.counts[in] > 1 {
+			t.counts[in]--
+			return true
+		}
+		delete(t.counts, in)
+	}
+	if !t.holds(in) {
+		return false
+	}
+	path := t.edit(in.first(), t.last(in))
+	path[len(path)-1].consult().remove(in)
+	prune(path)
+	t.removePoint(in.first(), in)
+	t.removePoint(t.last(in), in)
+	t.cover.remove(in.first(), t.last(in), t.overlapping(in), t.open)
+	t.size--
+	if t.seq != nil {
+		delete(t.seq, in)
+	}
+	if t.keyFunc != nil {
+		t.unindexKey(in)
+	}
+	t.mutated()
+	return true
+}
+
+// prune cuts the last node of the path if it is an empty leaf, then does the same with the nodes above it. The
+// nodes of the path must be owned, see IntervalTree.edit
+func prune(path []iterator) {
+	for i := len(path) - 1; i >= 0; i-- {
+		itr := &path[i]
+		if itr.isBottom() || !itr.isLeaf() || len(itr.consult().leftSorted) > 0 {
+			return
+		}
+		itr.cut()
+	}
+}
+
+// DeleteByValue removes one stored interval with exactly the endpoints given in parameter, whatever its payload,
+// and tells if there was one
+// Complexity: O(ln n + m + k), as Delete
+func (t *IntervalTree) DeleteByValue(start, end int) bool {
+	found := t.Find(start, end)
+	return len(found) > 0 && t.Delete(found[0])
+}
+
+// removePoint unlinks the interval from the BST point at x, removing the point when no interval uses it anymore
+func (t *IntervalTree) removePoint(x int, in *Interval) {
+	p := t.points().edit(x)
+	if p == nil {
+		return
+	}
+	p.ptrs = removeInterval(p.ptrs, in)
+	if len(p.ptrs) == 0 {
+		t.points().remove(x)
+	}
+}
+
+// unindexKey removes the interval from the secondary index
+func (t *IntervalTree) unindexKey(in *Interval) {
+	key := t.keyFunc(in)
+	t.keys[key] = removeInterval(t.keys[key], in)
+	if len(t.keys[key]) == 0 {
+		delete(t.keys, key)
+	}
+}
+
+// remove deletes the interval from both orders of the element and tells if it was there
+// Complexity: O(m), m = number of intervals in the element
+func (e *elt) remove(in *Interval) bool {
+	removed := false
+	e.retain(
+		func(stored *Interval) bool {
+			if stored == in && !removed {
+				removed = true
+				return false
+			}
+			return true
+		},
+	)
+	return removed
+}
+
+// retain keeps the intervals of the element for which keep is true, in the same orders, calling keep once per
+// interval in the order of leftSorted
+// Complexity: O(m), m = number of intervals in the element
+func (e *elt) retain(keep func(*Interval) bool) {
+	moved := make([]int32, len(e.leftSorted)) // new position of every interval, -1 if removed
+	kept := e.leftSorted[:0]
+	for i, in := range e.leftSorted {
+		moved[i] = -1
+		if keep(in) {
+			moved[i] = int32(len(kept))
+			kept = append(kept, in)
+		}
+	}
+	if len(kept) == len(e.leftSorted) {
+		return
+	}
+	clear(e.leftSorted[len(kept):])
+	e.leftSorted = kept
+	byEnd := e.byEnd[:0]
+	for _, pos := range e.byEnd {
+		if moved[pos] >= 0 {
+			byEnd = append(byEnd, moved[pos])
+		}
+	}
+	e.byEnd = byEnd
+}
+
+// removeInterval removes the first occurrence of the interval from the list, keeping the order of the others
+func removeInterval(list []*Interval, in *Interval) []*Interval {
+	for i, ptr := range list {
+		if ptr == in {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// DeleteWhere removes all the intervals for which pred is true and returns how many were removed. Every node is
+// visited once, the BST and the merged coverage are only updated for the removed intervals.
+// Complexity: O(n + r (ln n + k)), n = len(intervals in struct), r = number of removed intervals and k = number of
+// intervals intersecting each of them
+func (t *IntervalTree) DeleteWhere(pred func(*Interval) bool) int {
+	t = t.orEmpty()
+	t.unshare()
+	var removed []*Interval
+	var nodes []int // the xMid of the nodes holding removed intervals, in ascending order
+	walk(
+		t.nodes(), func(e *elt) bool {
+			// pred is called once per interval
+			found := len(removed)
+			for _, in := range e.leftSorted {
+				if pred(in) {
+					removed = append(removed, in)
+				}
+			}
+			if len(removed) > found {
+				nodes = append(nodes, e.xMid)
+			}
+			return true
+		},
+	)
+	if len(removed) == 0 {
+		return 0
+	}
+	// only the paths to the nodes losing intervals are copied if shared. A node is pruned once its children are,
+	// the ones still holding removed intervals being kept until their turn
+	gone := make(map[*Interval]bool, len(removed))
+	for _, in := range removed {
+		gone[in] = true
+	}
+	for _, x := range nodes {
+		path := t.edit(x, x)
+		path[len(path)-1].consult().retain(func(in *Interval) bool { return !gone[in] })
+		prune(path)
+	}
+	t.detach(removed)
+	// the coverage inside each removed interval becomes the one of the intervals left
+	for _, in := range removed {
+		t.cover.remove(in.first(), t.last(in), t.overlapping(in), t.open)
+	}
+	return len(removed)
+}
+
+// DeleteIntersecting removes all the intervals intersecting the window and returns them
+// Output sensitive: Complexity of O(k (ln n + m) + c), n = len(intervals in struct), k = number of removed
+// intervals, m = size of their nodes and c = number of intervals intersecting the removed ones
+func (t *IntervalTree) DeleteIntersecting(window *Interval) []*Interval {
+	t = t.orEmpty()
+	removed := t.Intersecting(window)
+	if len(removed) == 0 {
+		return removed
+	}
+	t.unshare()
+	start, end := window.first(), t.last(window) // points spanned by the removed intervals
+	for _, in := range removed {
+		path := t.edit(in.first(), t.last(in))
+		path[len(path)-1].consult().remove(in)
+		prune(path)
+		start, end = minInt(start, in.first()), maxInt(end, t.last(in))
+	}
+	t.detach(removed)
+	t.cover.remove(start, end, t.overlapping(&Interval{Start: start, End: end + t.open}), t.open)
+	return removed
+}
+
+// detach finishes the removal of intervals already taken out of the nodes: it unlinks them from the BST points,
+// the sequence numbers and the key index. The merged coverage is left to the caller
+func (t *IntervalTree) detach(removed []*Interval) {
+	for _, in := range removed {
+		t.removePoint(in.first(), in)
+		t.removePoint(t.last(in), in)
+		if t.seq != nil {
+			delete(t.seq, in)
+		}
+		if t.keyFunc != nil {
+			t.unindexKey(in)
+		}
+		if t.counts != nil {
+			delete(t.counts, in)
+		}
+	}
+	t.size -= len(removed)
+	t.mutated()
+}
+
+// UpdatePayload sets the payload of a stored interval, keeping the key index up to date. The payload does not take
+// part in the structure so nothing else changes. After a Snapshot, the tree stores and returns a copy of the
+// interval holding the new payload, the snapshot keeping the original one
+// Complexity: O(ln n + m), n = len(intervals in struct) and m = number of intervals in the node holding it
+func (t *IntervalTree) UpdatePayload(in *Interval, payload interface{}) error {
+	t = t.orEmpty()
+	if !t.holds(in) {
+		return ErrNotStored
+	}
+	t.unshare()
+	if t.sharesIntervals() {
+		in = t.substitute(in)
+	}
+	if t.keyFunc != nil {
+		t.unindexKey(in)
+	}
+	in.Payload = payload
+	if t.keyFunc != nil {
+		t.indexKey(in)
+	}
+	return nil
+}
+
+// Replace moves a stored interval to the endpoints given in parameter, keeping the same *Interval, its payload, its
+// boundary flags and its sequence number. Nothing changes if it fails because old is not stored or the new interval
+// is not valid. After a Snapshot, the tree stores and returns a moved copy of old, the snapshot keeping the original
+// one. WithMultiplicity, an interval counted several times stands for all its copies: moving one of them is
+// rejected with ErrCountedInterval
+// Complexity: O(ln n + m + k), as a Delete followed by an Insert
+func (t *IntervalTree) Replace(old *Interval, newStart, newEnd int) error {
+	t = t.orEmpty()
+	if !t.holds(old) {
+		return ErrNotStored
+	}
+	moved := &Interval{Start: newStart, End: newEnd, StartOpen: old.StartOpen, EndOpen: old.EndOpen}
+	if err := validate(moved, t.open); err != nil {
+		return err
+	}
+	if count := t.count(old); count > 1 {
+		return fmt.Errorf("%w: %s counted %d times", ErrCountedInterval, old, count)
+	}
+	t.unshare()
+	if t.sharesIntervals() {
+		old = t.substitute(old)
+	}
+	seq, recorded := t.seq[old]
+	t.Delete(old)
+	old.Start, old.End = newStart, newEnd
+	_ = t.Insert(old) // cannot fail, old is not nil and is valid
+	if recorded {
+		t.nextSeq--
+		t.seq[old] = seq
+	}
+	return nil
+}
+
+// substitute replaces the stored interval by a copy, in the node holding it, its BST points and the maps, and
+// returns the copy. The paths to them are copied first, so a snapshot sharing the interval keeps it
+// PRE: in is stored and the structure is unshared
+// Complexity: O(ln n + m), n = len(intervals in struct) and m = number of intervals in the node holding it
+func (t *IntervalTree) substitute(in *Interval) *Interval {
+	c := in.copyWith(in.Payload)
+	path := t.edit(in.first(), t.last(in))
+	replaceAll(path[len(path)-1].consult().leftSorted, in, c)
+	for _, x := range []int{in.first(), t.last(in)} {
+		replaceAll(t.points().edit(x).ptrs, in, c)
+	}
+	if seq, ok := t.seq[in]; ok {
+		delete(t.seq, in)
+		t.seq[c] = seq
+	}
+	if count, ok := t.counts[in]; ok {
+		delete(t.counts, in)
+		t.counts[c] = count
+	}
+	if t.keyFunc != nil {
+		replaceAll(t.keys[t.keyFunc(in)], in, c)
+	}
+	t.mutated()
+	return c
+}
+
+// replaceAll replaces every occurrence of the interval in the list by the other one
+func replaceAll(list []*Interval, in, by *Interval) {
+	for i, stored := range list {
+		if stored == in {
+			list[i] = by
+		}
+	}
+}
+
+// holds tells if the interval is stored in the IntervalTree, by pointer identity
+// Complexity: O(ln n + m), n = len(intervals in struct) and m = number of intervals in the node holding it
+func (t *IntervalTree) holds(in *Interval) bool {
+	if in == nil {
+		return false
+	}
+	itr := t.locate(in.first(), t.last(in))
+	if itr.isBottom() {
+		return false
+	}
+	for _, ptr := range itr.consult().find(in) {
+		if ptr == in {
+			return true
+		}
+	}
+	return false
+}
+
+// Rebuild reconstructs the IntervalTree from the intervals it currently holds, restoring the balance of a tree
+// built at once after many mutations. It works in place so the *IntervalTree stays valid, and gives back exactly
+// the same structure for a tree that was never mutated.
+// Build complexity: O(n log n), n = len(intervals in struct)
+func (t *IntervalTree) Rebuild() {
+	t = t.orEmpty()
+	intervals := t.intervals()
+	t.tree, _ = fromIntervals(intervals, t.tie, t.open) // cannot fail, the stored intervals are valid
+	t.bst, t.index = buildBST(intervals, t.open), nil
+	t.cover = newCoverage(intervals, t.open)
+	t.mutated()
+	t.mutations = 0
+}
+
+// heal rebuilds the IntervalTree if the automatic rebuild is enabled and enough mutations happened since the last
+// build
+func (t *IntervalTree) heal() {
+	if t.rebuildAt > 0 && t.mutations >= t.rebuildAt {
+		t.Rebuild()
+	}
+}
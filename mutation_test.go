@@ -0,0 +1,461 @@
+package intervaltree
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// checkQueries compares the answers of the tree with a linear scan of the intervals it must hold, closed or
+// half-open as the tree, with their open sides
+func checkQueries(t *testing.T, rnd *rand.Rand, tree *IntervalTree, intervals []*Interval, maxCoord int) {
+	t.Helper()
+	if tree.Len() != len(intervals) {
+		t.Fatalf("EXPECTING LEN %d, GOT %d", len(intervals), tree.Len())
+	}
+	if got, want := tree.TotalCoveredLength(), bruteCoveredLength(closedCopies(intervals, tree.open)); got != want {
+		t.Fatalf("EXPECTING %d COVERED, GOT %d", want, got)
+	}
+	for q := 0; q < 20; q++ {
+		x := rnd.Intn(maxCoord)
+		query := &Interval{Start: x, End: x + rnd.Intn(maxCoord/10+1)}
+		containing, intersecting := 0, 0
+		for _, in := range intervals {
+			first, last, queryLast := in.first(), tree.last(in), query.End-tree.open
+			if first <= x && x <= last {
+				containing++
+			}
+			if first <= queryLast && query.Start <= last && query.Start <= queryLast {
+				intersecting++
+			}
+		}
+		if got := len(tree.Containing(x)); got != containing {
+			t.Fatalf("CONTAINING(%d): EXPECTING %d VALUES, GOT %d", x, containing, got)
+		}
+		if got := len(tree.Intersecting(query)); got != intersecting {
+			t.Fatalf("INTERSECTING(%s): EXPECTING %d VALUES, GOT %d", query, intersecting, got)
+		}
+	}
+}
+
+func TestIntervalTree_Insert(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(100), 1000, 100)
+		if i%5 == 0 {
+			intervals = nil
+		}
+		tree := MustNewIntervalTree(intervals, WithSequenceNumbers())
+		for k := 0; k < 300; k++ {
+			in := randomIntervals(rnd, 1, 1000, 100)[0]
+			if err := tree.Insert(in); err != nil {
+				t.Fatalf("UNEXPECTED ERROR %v", err)
+			}
+			intervals = append(intervals, in)
+			if seq, ok := tree.SeqOf(in); !ok || seq != uint64(len(intervals)-1) {
+				t.Fatalf("EXPECTING SEQUENCE %d, GOT %d", len(intervals)-1, seq)
+			}
+			if !tree.Has(in.Start, in.End) {
+				t.Fatalf("%s NOT FOUND AFTER INSERT", in)
+			}
+			if k%50 == 0 {
+				checkQueries(t, rnd, tree, intervals, 1100)
+			}
+		}
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, intervals, 1100)
+		if got := len(tree.Boundaries()); got != len(MustNewIntervalTree(intervals).Boundaries()) {
+			t.Fatalf("EXPECTING THE BOUNDARIES OF A FRESH TREE, GOT %d", got)
+		}
+	}
+}
+
+func TestIntervalTree_InsertInvalid(t *testing.T) {
+	tree := MustNewIntervalTree(nil)
+	if err := tree.Insert(nil); !errors.Is(err, ErrNilInterval) {
+		t.Fatalf("EXPECTING ErrNilInterval, GOT %v", err)
+	}
+	if err := tree.Insert(&Interval{Start: 5, End: 4}); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING ErrReversedInterval, GOT %v", err)
+	}
+	if !tree.IsEmpty() {
+		t.Fatalf("A REJECTED INTERVAL MUST NOT BE STORED")
+	}
+	var view NodeView
+	_ = tree.Insert(&Interval{Start: 1, End: 2})
+	tree.WalkViews(func(v NodeView) bool { view = v; return false })
+	_ = tree.Insert(&Interval{Start: 1, End: 3})
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("A VIEW CREATED BEFORE AN INSERT MUST BE INVALID")
+		}
+	}()
+	view.Len()
+}
+
+func TestIntervalTree_Delete(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(300)+1, 1000, 100)
+		tree := MustNewIntervalTree(intervals, WithSequenceNumbers(), WithKeyFunc(func(in *Interval) interface{} { return in.Start }))
+		for len(intervals) > 0 {
+			if rnd.Intn(4) == 0 {
+				in := randomIntervals(rnd, 1, 1000, 100)[0]
+				_ = tree.Insert(in)
+				intervals = append(intervals, in)
+				continue
+			}
+			k := rnd.Intn(len(intervals))
+			in := intervals[k]
+			intervals = append(intervals[:k], intervals[k+1:]...)
+			if !tree.Delete(in) {
+				t.Fatalf("%s NOT DELETED", in)
+			}
+			if tree.Delete(in) {
+				t.Fatalf("%s DELETED TWICE", in)
+			}
+			if _, ok := tree.SeqOf(in); ok {
+				t.Fatalf("%s STILL HAS A SEQUENCE NUMBER", in)
+			}
+			for _, other := range tree.FindByKey(in.Start) {
+				if other == in {
+					t.Fatalf("%s STILL IN THE KEY INDEX", in)
+				}
+			}
+			for _, other := range tree.Intersecting(in) {
+				if other == in {
+					t.Fatalf("%s STILL RETURNED AFTER DELETE", in)
+				}
+			}
+			if len(intervals)%40 == 0 {
+				checkStructure(t, tree)
+				checkQueries(t, rnd, tree, intervals, 1100)
+			}
+		}
+		if !tree.IsEmpty() || tree.TotalCoveredLength() != 0 || len(tree.Boundaries()) != 0 || tree.NodeCount() != 0 {
+			t.Fatalf("EXPECTING AN EMPTY TREE, GOT %s", tree.Stats())
+		}
+	}
+}
+
+func TestIntervalTree_DeleteShared(t *testing.T) {
+	a, b, c := &Interval{Start: 1, End: 9, Payload: "a"}, &Interval{Start: 1, End: 9, Payload: "b"}, &Interval{Start: 20, End: 21}
+	tree := MustNewIntervalTree([]*Interval{a, b, c})
+	if tree.Delete(&Interval{Start: 1, End: 9}) {
+		t.Fatalf("DELETE MUST MATCH BY POINTER")
+	}
+	if !tree.Delete(a) {
+		t.Fatalf("%s NOT DELETED", a)
+	}
+	if got := tree.Containing(5); len(got) != 1 || got[0] != b {
+		t.Fatalf("EXPECTING ONLY %v, GOT %v", b.Payload, got)
+	}
+	if got := tree.BoundariesIn(&Interval{Start: 1, End: 9}); len(got) != 2 {
+		t.Fatalf("THE SHARED ENDPOINTS MUST STAY, GOT %v", got)
+	}
+	// last interval of its node
+	if !tree.DeleteByValue(20, 21) || tree.DeleteByValue(20, 21) {
+		t.Fatalf("DELETEBYVALUE MUST REMOVE [ 20 - 21 ] ONCE")
+	}
+	if len(tree.Containing(20)) != 0 || len(tree.Boundaries()) != 2 || tree.Len() != 1 {
+		t.Fatalf("[ 20 - 21 ] NOT FULLY REMOVED: %v", tree.Boundaries())
+	}
+	if tree.Delete(nil) {
+		t.Fatalf("NIL IS NEVER STORED")
+	}
+}
+
+// checkStructure fails the test if an internal invariant of the tree does not hold
+func checkStructure(t *testing.T, tree *IntervalTree) {
+	t.Helper()
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("INVALID STRUCTURE: %v", err)
+	}
+	stored := make(map[*Interval]bool)
+	var check func(itr *iterator, lower, upper int)
+	check = func(itr *iterator, lower, upper int) {
+		if itr.isBottom() {
+			return
+		}
+		e := itr.consult()
+		if len(e.leftSorted) != len(e.byEnd) {
+			t.Fatalf("NODE %d: %d INTERVALS SORTED BY START, %d BY END", e.xMid, len(e.leftSorted), len(e.byEnd))
+		}
+		if len(e.leftSorted) == 0 && itr.isLeaf() {
+			t.Fatalf("NODE %d: EMPTY LEAF", e.xMid)
+		}
+		for i, in := range e.leftSorted {
+			if in.first() > e.xMid || tree.last(in) < e.xMid || in.first() < lower || tree.last(in) > upper {
+				t.Fatalf("NODE %d: %s IS MISPLACED", e.xMid, in)
+			}
+			if i > 0 && in.lessStart(e.leftSorted[i-1]) || i > 0 && e.endAt(i).lessEnd(e.endAt(i-1)) {
+				t.Fatalf("NODE %d: LISTS NOT SORTED", e.xMid)
+			}
+			stored[in] = true
+		}
+		for k := range e.byEnd {
+			if in := e.endAt(k); !stored[in] {
+				t.Fatalf("NODE %d: %s SORTED BY END ONLY", e.xMid, in)
+			}
+		}
+		check(itr.left(), lower, e.xMid-1)
+		check(itr.right(), e.xMid+1, upper)
+	}
+	check(tree.nodes().root(), math.MinInt, math.MaxInt)
+	if len(stored) != tree.Len() {
+		t.Fatalf("EXPECTING %d STORED INTERVALS, GOT %d", tree.Len(), len(stored))
+	}
+	references := 0
+	for _, p := range tree.pointsIn(math.MinInt, math.MaxInt) {
+		if len(p.ptrs) == 0 {
+			t.Fatalf("POINT %d WITHOUT INTERVAL", p.x)
+		}
+		for _, in := range p.ptrs {
+			if !stored[in] || in.first() != p.x && tree.last(in) != p.x {
+				t.Fatalf("POINT %d REFERENCES %s", p.x, in)
+			}
+		}
+		references += len(p.ptrs)
+	}
+	if references != 2*len(stored) {
+		t.Fatalf("EXPECTING %d POINT REFERENCES, GOT %d", 2*len(stored), references)
+	}
+}
+
+func TestIntervalTree_DeleteWhere(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(500), 1000, 100)
+		for k, in := range intervals {
+			in.Payload = k%(i%7+1) == 0 // expired
+		}
+		tree := MustNewIntervalTree(intervals)
+		checkStructure(t, tree)
+		expired := func(in *Interval) bool { return in.Payload.(bool) }
+		var kept []*Interval
+		for _, in := range intervals {
+			if !expired(in) {
+				kept = append(kept, in)
+			}
+		}
+		if got := tree.DeleteWhere(expired); got != len(intervals)-len(kept) {
+			t.Fatalf("EXPECTING %d REMOVED, GOT %d", len(intervals)-len(kept), got)
+		}
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, kept, 1100)
+		if got := tree.DeleteWhere(expired); got != 0 {
+			t.Fatalf("EXPECTING NOTHING LEFT TO REMOVE, GOT %d", got)
+		}
+	}
+}
+
+func TestIntervalTree_DeleteIntersecting(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(500), 1000, 100)
+		tree := MustNewIntervalTree(intervals)
+		x := rnd.Intn(1100)
+		window := &Interval{Start: x, End: x + rnd.Intn(300)}
+		switch i % 10 {
+		case 0:
+			window = &Interval{Start: -10, End: 2000} // everything
+		case 1:
+			window = &Interval{Start: 5000, End: 6000} // nothing
+		}
+		var kept []*Interval
+		victims := make(map[*Interval]bool)
+		for _, in := range intervals {
+			if in.Start <= window.End && window.Start <= in.End {
+				victims[in] = true
+			} else {
+				kept = append(kept, in)
+			}
+		}
+		removed := tree.DeleteIntersecting(window)
+		if len(removed) != len(victims) {
+			t.Fatalf("EXPECTING %d REMOVED, GOT %d", len(victims), len(removed))
+		}
+		for _, in := range removed {
+			if !victims[in] {
+				t.Fatalf("%s DOES NOT INTERSECT %s", in, window)
+			}
+		}
+		checkStructure(t, tree)
+		checkQueries(t, rnd, tree, kept, 1100)
+		if len(tree.Intersecting(window)) != 0 {
+			t.Fatalf("INTERVALS LEFT IN %s", window)
+		}
+	}
+}
+
+func TestIntervalTree_Replace(t *testing.T) {
+	a := &Interval{Start: 10, End: 20, Payload: "a"}
+	b := &Interval{Start: 15, End: 30, Payload: "b"}
+	tree := MustNewIntervalTree([]*Interval{a, b}, WithSequenceNumbers(), WithKeyFunc(func(in *Interval) interface{} { return in.Payload }))
+	if err := tree.Replace(a, 40, 50); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	if got := tree.Containing(12); len(got) != 0 {
+		t.Fatalf("THE OLD EXTENT MUST DISAPPEAR, GOT %v", got)
+	}
+	if got := tree.Containing(45); len(got) != 1 || got[0] != a || a.Payload != "a" {
+		t.Fatalf("THE NEW EXTENT MUST BE FOUND WITH ITS PAYLOAD, GOT %v", got)
+	}
+	if seq, _ := tree.SeqOf(a); seq != 0 {
+		t.Fatalf("REPLACE MUST KEEP THE SEQUENCE NUMBER, GOT %d", seq)
+	}
+	if err := tree.Replace(a, 60, 55); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING ErrReversedInterval, GOT %v", err)
+	}
+	if err := tree.Replace(&Interval{Start: 40, End: 50}, 0, 1); !errors.Is(err, ErrNotStored) {
+		t.Fatalf("EXPECTING ErrNotStored, GOT %v", err)
+	}
+	if a.Start != 40 || a.End != 50 || tree.Len() != 2 {
+		t.Fatalf("A FAILED REPLACE MUST NOT CHANGE ANYTHING")
+	}
+	checkStructure(t, tree)
+
+	if err := tree.UpdatePayload(b, "c"); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	if got := tree.FindByKey("c"); len(got) != 1 || got[0] != b || len(tree.FindByKey("b")) != 0 {
+		t.Fatalf("UPDATEPAYLOAD MUST UPDATE THE KEY INDEX, GOT %v", got)
+	}
+	if err := tree.UpdatePayload(&Interval{Start: 15, End: 30}, "d"); !errors.Is(err, ErrNotStored) {
+		t.Fatalf("EXPECTING ErrNotStored, GOT %v", err)
+	}
+}
+
+// sameStructure tells if both trees have the same nodes holding the same lists of intervals
+func sameStructure(a, b *IntervalTree) bool {
+	var nodes []*elt
+	walk(a.nodes(), func(e *elt) bool { nodes = append(nodes, e); return true })
+	i, same := 0, true
+	walk(
+		b.nodes(), func(e *elt) bool {
+			if i >= len(nodes) || nodes[i].xMid != e.xMid || len(nodes[i].leftSorted) != len(e.leftSorted) {
+				same = false
+				return false
+			}
+			for k := range e.leftSorted {
+				if nodes[i].leftSorted[k] != e.leftSorted[k] || nodes[i].endAt(k) != e.endAt(k) {
+					same = false
+					return false
+				}
+			}
+			i++
+			return true
+		},
+	)
+	return same && i == len(nodes)
+}
+
+func TestIntervalTree_Rebuild(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 2000, 100, 10)
+	fresh := MustNewIntervalTree(intervals)
+	tree := MustNewIntervalTree(intervals)
+	tree.Rebuild()
+	if !sameStructure(tree, fresh) {
+		t.Fatalf("REBUILDING AN UNMODIFIED TREE MUST GIVE THE SAME STRUCTURE")
+	}
+
+	// increasing disjoint intervals inserted one by one degenerate into a list
+	tree = MustNewIntervalTree(nil, WithSequenceNumbers())
+	for i := 0; i < 3000; i++ {
+		_ = tree.Insert(&Interval{Start: 2 * i, End: 2*i + 1})
+	}
+	degraded := tree.Height()
+	pointer := tree
+	tree.Rebuild()
+	fresh = MustNewIntervalTree(tree.All())
+	if tree.Height() != fresh.Height() || tree.Height() >= degraded || pointer != tree {
+		t.Fatalf("EXPECTING HEIGHT %d AFTER REBUILD (%d BEFORE), GOT %d", fresh.Height(), degraded, tree.Height())
+	}
+	checkStructure(t, tree)
+	checkQueries(t, rnd, tree, fresh.All(), 6000)
+	if seq, ok := tree.SeqOf(tree.Containing(100)[0]); !ok || seq != 50 {
+		t.Fatalf("REBUILD MUST KEEP THE SEQUENCE NUMBERS, GOT %d (%t)", seq, ok)
+	}
+}
+
+func TestIntervalTree_AutoRebuild(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	tree := MustNewIntervalTree(nil, WithAutoRebuild(100))
+	manual := MustNewIntervalTree(nil)
+	var intervals []*Interval
+	for i := 0; i < 1000; i++ {
+		in := &Interval{Start: 2 * i, End: 2*i + 1}
+		intervals = append(intervals, in)
+		_ = tree.Insert(in)
+		_ = manual.Insert(in)
+	}
+	if tree.mutations != 1000 {
+		t.Fatalf("EXPECTING 1000 MUTATIONS, GOT %d", tree.mutations)
+	}
+	// the rebuild waits for a query
+	degraded := tree.Height()
+	if degraded != manual.Height() {
+		t.Fatalf("NO REBUILD MUST HAPPEN BEFORE A QUERY, HEIGHT %d INSTEAD OF %d", degraded, manual.Height())
+	}
+	if res := tree.Containing(500); len(res) != 1 || res[0] != intervals[250] {
+		t.Fatalf("EXPECTING %s, GOT %v", intervals[250], res)
+	}
+	if tree.mutations != 0 || tree.Height() >= degraded {
+		t.Fatalf("EXPECTING A REBUILD, GOT %d MUTATIONS AND HEIGHT %d", tree.mutations, tree.Height())
+	}
+	checkStructure(t, tree)
+	checkQueries(t, rnd, tree, intervals, 2000)
+
+	// read-only workloads never rebuild
+	before := tree.nodes()
+	for i := 0; i < 1000; i++ {
+		tree.Intersecting(&Interval{Start: i, End: i + 10})
+	}
+	if tree.nodes() != before {
+		t.Fatalf("A READ-ONLY WORKLOAD MUST NOT REBUILD")
+	}
+	// below the threshold, nothing happens
+	for _, in := range intervals[:99] {
+		tree.Delete(in)
+	}
+	tree.Containing(0)
+	if tree.nodes() != before || tree.mutations != 99 {
+		t.Fatalf("EXPECTING NO REBUILD BEFORE 100 MUTATIONS, GOT %d MUTATIONS", tree.mutations)
+	}
+
+	// disabled, the tree is never rebuilt
+	for _, opt := range []Option{WithAutoRebuild(0), WithAutoRebuild(-1)} {
+		tree = MustNewIntervalTree(nil, opt)
+		for _, in := range intervals {
+			_ = tree.Insert(in)
+		}
+		before = tree.nodes()
+		tree.Containing(0)
+		if tree.nodes() != before || tree.Height() != manual.Height() {
+			t.Fatalf("A DISABLED AUTOMATIC REBUILD MUST KEEP THE TREE AS IS")
+		}
+	}
+}
+
+func TestIntervalTree_AutoRebuildDuringDelete(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 500, 1000, 50)
+	tree := MustNewIntervalTree(intervals, WithAutoRebuild(1))
+	// every mutation reaches the threshold, only the queries may rebuild
+	for len(intervals) > 250 {
+		before := tree.nodes()
+		victims := map[*Interval]bool{intervals[0]: true, intervals[1]: true}
+		tree.Delete(intervals[2])
+		tree.DeleteWhere(func(in *Interval) bool { return victims[in] })
+		if tree.nodes() != before {
+			t.Fatalf("A DELETION MUST NOT TRIGGER THE AUTOMATIC REBUILD")
+		}
+		intervals = intervals[3:]
+		tree.Containing(0)
+	}
+	checkStructure(t, tree)
+	checkQueries(t, rnd, tree, intervals, 1000)
+}
@@ -0,0 +1,182 @@
+package intervaltree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// -----------------------------------------------------
+// 				NAIVE REFERENCE
+// -----------------------------------------------------
+
+// ErrDivergence is returned by CheckAgainstNaive when the IntervalTree and the NaiveIntervalSet disagree
+var ErrDivergence = errors.New("intervaltree: divergence from the naive implementation")
+
+// NaiveIntervalSet stores intervals in a slice and answers the queries of the IntervalTree by scanning all of them.
+// It follows the same endpoint semantics and is meant as a reference for differential tests, see CheckAgainstNaive
+type NaiveIntervalSet struct {
+	intervals []*Interval
+	open      int  // 1 if the intervals are half-open, as in an IntervalTree built WithHalfOpenIntervals, else 0
+	swap      bool // reversed query intervals are swapped, as in an IntervalTree built WithSwappedQueries
+}
+
+// NewNaiveIntervalSet creates a NaiveIntervalSet holding the intervals given in parameter, each pointer once. Among
+// the options, only WithHalfOpenIntervals and WithSwappedQueries have an effect. The intervals are not validated
+func NewNaiveIntervalSet(intervals []*Interval, opts ...Option) *NaiveIntervalSet {
+	cfg := newConfig(opts)
+	n := &NaiveIntervalSet{open: cfg.open, swap: cfg.swap}
+	for _, in := range intervals {
+		n.Insert(in)
+	}
+	return n
+}
+
+// Insert adds the interval to the set if the pointer is not stored yet
+// Complexity: O(n), n = len(intervals in set)
+func (n *NaiveIntervalSet) Insert(in *Interval) {
+	for _, stored := range n.intervals {
+		if stored == in {
+			return
+		}
+	}
+	n.intervals = append(n.intervals, in)
+}
+
+// Delete removes the interval from the set and tells if it was stored
+// Complexity: O(n), n = len(intervals in set)
+func (n *NaiveIntervalSet) Delete(in *Interval) bool {
+	for i, stored := range n.intervals {
+		if stored == in {
+			n.intervals = append(n.intervals[:i], n.intervals[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of intervals stored in the set
+func (n *NaiveIntervalSet) Len() int {
+	return len(n.intervals)
+}
+
+// All returns the intervals stored in the set, in insertion order
+func (n *NaiveIntervalSet) All() []*Interval {
+	return append([]*Interval(nil), n.intervals...)
+}
+
+// Containing returns the intervals containing the value x, in insertion order
+// Complexity: O(n), n = len(intervals in set)
+func (n *NaiveIntervalSet) Containing(x int) []*Interval {
+	var res []*Interval
+	for _, in := range n.intervals {
+		if in.first() <= x && x <= lastPoint(in, n.open) {
+			res = append(res, in)
+		}
+	}
+	return res
+}
+
+// Intersecting returns the intervals intersecting the Interval given in parameter, in insertion order. As for
+// IntervalTree.Intersecting, a nil or reversed interval intersects nothing, unless the set was created
+// WithSwappedQueries which swaps a reversed one
+// Complexity: O(n), n = len(intervals in set)
+func (n *NaiveIntervalSet) Intersecting(interval *Interval) []*Interval {
+	interval, err := queryWindow(interval, n.swap)
+	if err != nil || vacant(interval, n.open) {
+		return nil
+	}
+	var res []*Interval
+	for _, in := range n.intervals {
+		if in.first() <= lastPoint(interval, n.open) && interval.first() <= lastPoint(in, n.open) {
+			res = append(res, in)
+		}
+	}
+	return res
+}
+
+// Query is a query run by CheckAgainstNaive: Intersecting(Window) if Window is not nil, else Containing(X)
+type Query struct {
+	Window *Interval
+	X      int
+}
+
+// String prints the query as the call it runs
+func (q Query) String() string {
+	if q.Window != nil {
+		return fmt.Sprintf("Intersecting(%s)", q.Window)
+	}
+	return fmt.Sprintf("Containing(%d)", q.X)
+}
+
+// CheckAgainstNaive runs the queries on the IntervalTree and on the NaiveIntervalSet and returns an error wrapping
+// ErrDivergence on the first one whose results are not the same set of intervals, the order being ignored. The
+// error tells the query, the expected and the returned intervals. The error of a query finding the tree
+// inconsistent is returned as is, wrapping ErrBrokenInvariant. A reversed window intersects nothing in both, unless
+// they swap it, and both must have been created with the same WithSwappedQueries and WithHalfOpenIntervals. Both
+// must hold the same intervals, each once, so a tree built WithMultiplicity cannot be checked
+// Complexity: O(q n log n), n = len(intervals) and q = len(queries)
+func CheckAgainstNaive(t *IntervalTree, n *NaiveIntervalSet, queries []Query) error {
+	if t.open != n.open {
+		return fmt.Errorf("%w: %w", ErrDivergence, ErrMixedModes)
+	}
+	if t.swap != n.swap {
+		return fmt.Errorf("%w: swapped queries: expecting %t, got %t", ErrDivergence, n.swap, t.swap)
+	}
+	if t.Len() != n.Len() {
+		return fmt.Errorf("%w: Len: expecting %d, got %d", ErrDivergence, n.Len(), t.Len())
+	}
+	for _, q := range queries {
+		var want, got []*Interval
+		var err error
+		if q.Window != nil {
+			want = n.Intersecting(q.Window)
+			if got, err = t.IntersectingE(q.Window); errors.Is(err, ErrReversedInterval) {
+				got, err = nil, nil // intersecting nothing, as Intersecting
+			}
+		} else {
+			want = n.Containing(q.X)
+			got, err = t.ContainingE(q.X)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", q, err)
+		}
+		if !sameSet(want, got) {
+			return fmt.Errorf("%w: %s: expecting %s, got %s", ErrDivergence, q, listOf(want), listOf(got))
+		}
+	}
+	return nil
+}
+
+// sameSet tells if a and b hold the same intervals the same number of times, in any order
+func sameSet(a, b []*Interval) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[*Interval]int, len(a))
+	for _, in := range a {
+		count[in]++
+	}
+	for _, in := range b {
+		if count[in]--; count[in] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// listOf prints the intervals sorted by Start, then End, so that two results can be compared by eye
+func listOf(intervals []*Interval) string {
+	sorted := append([]*Interval(nil), intervals...)
+	sort.SliceStable(
+		sorted, func(i, j int) bool {
+			return sorted[i].lessStart(sorted[j])
+		},
+	)
+	parts := make([]string, len(sorted))
+	for i, in := range sorted {
+		parts[i] = in.String()
+	}
+	return fmt.Sprintf("%d intervals [%s]", len(sorted), strings.Join(parts, " "))
+}
@@ -0,0 +1,132 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sortByEnd returns the positions of the intervals of the list in the order of rightSorted, see sortByEndIn
+func sortByEnd(list []*Interval, tie func(a, b *Interval) bool) []int32 {
+	return sortByEndIn(make([]int32, len(list)), list, tie)
+}
+
+// randomQueries generates n Containing and Intersecting queries in [0, maxCoord]
+func randomQueries(rnd *rand.Rand, n, maxCoord int) []Query {
+	queries := make([]Query, n)
+	for i := range queries {
+		x := rnd.Intn(maxCoord + 1)
+		switch i % 4 {
+		case 0, 2:
+			queries[i] = Query{X: x}
+		case 1:
+			queries[i] = Query{Window: &Interval{Start: x, End: x + rnd.Intn(50)}}
+		default:
+			queries[i] = Query{Window: &Interval{Start: x + 1 + rnd.Intn(50), End: x}} // reversed
+		}
+	}
+	return queries
+}
+
+func TestCheckAgainstNaive(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 20; i++ {
+		intervals := randomFlagged(rnd, rnd.Intn(1000), 1000, 100)
+		naive := NewNaiveIntervalSet(append(intervals, intervals...))
+		if naive.Len() != len(intervals) {
+			t.Fatalf("EXPECTING %d INTERVALS, GOT %d", len(intervals), naive.Len())
+		}
+		for _, tree := range treesOf(t, intervals) {
+			if err := CheckAgainstNaive(tree, naive, randomQueries(rnd, 200, 1100)); err != nil {
+				t.Fatalf("UNEXPECTED DIVERGENCE: %v", err)
+			}
+		}
+		// the same mutations on both
+		tree := MustNewIntervalTree(intervals)
+		for k := 0; k < 100; k++ {
+			if in := randomIntervals(rnd, 1, 1000, 100)[0]; k%2 == 0 || naive.Len() == 0 {
+				naive.Insert(in)
+				tree.Insert(in)
+			} else {
+				in := naive.All()[rnd.Intn(naive.Len())]
+				naive.Delete(in)
+				tree.Delete(in)
+			}
+		}
+		if err := CheckAgainstNaive(tree, naive, randomQueries(rnd, 200, 1100)); err != nil {
+			t.Fatalf("UNEXPECTED DIVERGENCE AFTER MUTATIONS: %v", err)
+		}
+	}
+
+	half := []*Interval{{Start: 0, End: 10}, {Start: 10, End: 20}, {Start: 5, End: 6}}
+	naive := NewNaiveIntervalSet(half, WithHalfOpenIntervals())
+	queries := []Query{{X: 10}, {X: 20}, {Window: &Interval{Start: 6, End: 10}}, {Window: &Interval{Start: 10, End: 10}}}
+	if err := CheckAgainstNaive(MustNewIntervalTree(half, WithHalfOpenIntervals()), naive, queries); err != nil {
+		t.Fatalf("UNEXPECTED DIVERGENCE: %v", err)
+	}
+	if got := naive.Containing(10); len(got) != 1 || got[0] != half[1] {
+		t.Fatalf("EXPECTING [ 10 - 20 ) ONLY, GOT %v", got)
+	}
+	if err := CheckAgainstNaive(MustNewIntervalTree(half), naive, queries); !errors.Is(err, ErrMixedModes) {
+		t.Fatalf("EXPECTING ErrMixedModes, GOT %v", err)
+	}
+
+	// a tree losing an interval diverges, the error telling the query
+	intervals := []*Interval{{Start: 1, End: 5}, {Start: 3, End: 9}, {Start: 20, End: 30}}
+	naive = NewNaiveIntervalSet(intervals)
+	tree := MustNewIntervalTree(intervals, WithSmallThreshold(0))
+	if err := CheckAgainstNaive(tree, naive, []Query{{X: 4}}); err != nil {
+		t.Fatalf("UNEXPECTED DIVERGENCE: %v", err)
+	}
+	naive.Delete(intervals[1])
+	if err := CheckAgainstNaive(tree, naive, nil); !errors.Is(err, ErrDivergence) || !strings.Contains(err.Error(), "Len") {
+		t.Fatalf("EXPECTING A DIVERGENCE OF Len, GOT %v", err)
+	}
+	naive.Insert(intervals[1])
+	e := tree.nodes().root().consult()
+	lost := func(in *Interval) bool { return in == intervals[1] }
+	e.leftSorted = slices.DeleteFunc(e.leftSorted, lost)
+	err := CheckAgainstNaive(tree, naive, []Query{{X: 25}, {X: 4}})
+	if !errors.Is(err, ErrBrokenInvariant) || !strings.HasPrefix(err.Error(), "Containing(") {
+		t.Fatalf("EXPECTING A BROKEN INVARIANT, GOT %v", err)
+	}
+	e.byEnd = sortByEnd(e.leftSorted, nil)
+	err = CheckAgainstNaive(tree, naive, []Query{{X: 25}, {X: 4}})
+	if !errors.Is(err, ErrDivergence) || !strings.Contains(err.Error(), "Containing(4): expecting 2 intervals") {
+		t.Fatalf("EXPECTING A DIVERGENCE ON Containing(4), GOT %v", err)
+	}
+}
+
+func TestNaiveIntervalSet_Windows(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, opts := range [][]Option{nil, {WithSwappedQueries()}, {WithHalfOpenIntervals(), WithSwappedQueries()}} {
+		intervals := randomFlagged(rnd, 1+rnd.Intn(500), 1000, 100)
+		if newConfig(opts).open == 1 {
+			intervals = randomHalfOpen(rnd, 1+rnd.Intn(500), 1000, 100)
+		}
+		tree, naive := MustNewIntervalTree(intervals, opts...), NewNaiveIntervalSet(intervals, opts...)
+		// nil, reversed and straight windows intersect the same intervals in both
+		windows := []*Interval{nil, {Start: 9, End: 1}, {Start: 9, End: 1, StartOpen: true}}
+		for q := 0; q < 200; q++ {
+			x := rnd.Intn(1100)
+			windows = append(windows, &Interval{Start: x, End: x + rnd.Intn(50)}, &Interval{Start: x + 1 + rnd.Intn(50), End: x})
+		}
+		for _, window := range windows {
+			if got, want := tree.Intersecting(window), naive.Intersecting(window); !sameSet(got, want) {
+				t.Fatalf("INTERSECTING %v: EXPECTING %s, GOT %s", window, listOf(want), listOf(got))
+			}
+		}
+		if err := CheckAgainstNaive(tree, naive, randomQueries(rnd, 200, 1100)); err != nil {
+			t.Fatalf("UNEXPECTED DIVERGENCE: %v", err)
+		}
+	}
+	// the swap of the reversed windows must be the same in both
+	intervals := []*Interval{{Start: 1, End: 5}}
+	err := CheckAgainstNaive(MustNewIntervalTree(intervals, WithSwappedQueries()), NewNaiveIntervalSet(intervals), nil)
+	if !errors.Is(err, ErrDivergence) {
+		t.Fatalf("EXPECTING A DIVERGENCE OF THE SWAPPED QUERIES, GOT %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package intervaltree
+
+import "slices"
+
+// -----------------------------------------------------
+// 				NODE STORAGE
+// -----------------------------------------------------
+
+// nodeTree is the binary tree of the elements of an IntervalTree. Every element is a node, linked to its children
+// only so that a subtree can be shared with a snapshot: reaching the element of a node follows no pointer, and the
+// arena carves the nodes with the elements. The mutations keep the path they went down to prune the empty leaves,
+// see IntervalTree.edit, and only modify in place the nodes stamped with the owner of the tree
+type nodeTree struct {
+	top   *elt
+	owner *owner // stamped on the nodes the tree may modify in place, nil for the ones it was built with
+}
+
+// iterator is a position in a nodeTree: on the arc from the node above to the node below it, which is nil at the
+// bottom of the tree, so that a node can be inserted there. It holds no interface value and is small enough to be
+// copied, the traversals moving it by value
+type iterator struct {
+	whole   *nodeTree
+	down    *elt
+	above   *elt // nil on the root
+	leftArc bool // down is the left child of above
+}
+
+// root returns a new iterator on the root of the tree
+func (t *nodeTree) root() *iterator {
+	return &iterator{whole: t, down: t.top}
+}
+
+// isRoot tells if the iterator is on the root of the tree
+func (i *iterator) isRoot() bool {
+	return i.above == nil
+}
+
+// isBottom tells if the iterator is under a leaf, on no node
+func (i *iterator) isBottom() bool {
+	return i.down == nil
+}
+
+// isLeaf tells if the iterator is on a node without children
+func (i *iterator) isLeaf() bool {
+	return i.down != nil && i.down.left == nil && i.down.right == nil
+}
+
+// consult returns the element of the node under the iterator
+// PRE: !isBottom
+func (i *iterator) consult() *elt {
+	return i.down
+}
+
+// left returns a new iterator on the left child of the node under the iterator
+// PRE: !isBottom
+func (i *iterator) left() *iterator {
+	return &iterator{whole: i.whole, down: i.down.left, above: i.down, leftArc: true}
+}
+
+// right returns a new iterator on the right child of the node under the iterator
+// PRE: !isBottom
+func (i *iterator) right() *iterator {
+	return &iterator{whole: i.whole, down: i.down.right, above: i.down}
+}
+
+// insert replaces the subtree under the iterator by the element, as a leaf
+func (i *iterator) insert(e *elt) {
+	e.left, e.right = nil, nil
+	i.link(e)
+}
+
+// cut removes the subtree under the iterator from the tree
+func (i *iterator) cut() {
+	i.link(nil)
+}
+
+// own makes the node under the iterator one the tree may modify in place, replacing it by a copy stamped with the
+// owner of the tree if it is shared with a snapshot. The node above must be owned already
+// PRE: !isBottom
+func (i *iterator) own() {
+	if e := i.down; e.owner != i.whole.owner {
+		c := *e
+		c.leftSorted, c.byEnd, c.owner = slices.Clone(e.leftSorted), slices.Clone(e.byEnd), i.whole.owner
+		i.link(&c)
+	}
+}
+
+// link hangs the node under the iterator, in place of the subtree there
+func (i *iterator) link(n *elt) {
+	switch {
+	case i.isRoot():
+		i.whole.top = n
+	case i.leftArc:
+		i.above.left = n
+	default:
+		i.above.right = n
+	}
+	i.down = n
+}
+
+// -----------------------------------------------------
+// 				POINT STORAGE
+// -----------------------------------------------------
+
+// pointNode is a node of the BST of the endpoints, holding its Point so that both are allocated at once
+type pointNode struct {
+	Point
+	left, right *pointNode
+	owner       *owner // see pointTree
+}
+
+// pointTree is the binary search tree of the endpoints of an IntervalTree, ordered by their coordinate. It is
+// built balanced, the points added later being inserted at the bottom. As the nodeTree, it only modifies in place
+// the nodes stamped with its owner, the others being shared with a snapshot
+type pointTree struct {
+	top   *pointNode
+	count int
+	owner *owner // see nodeTree
+}
+
+// newPointTree creates a balanced BST holding the points, all the nodes being allocated at once
+// PRE: the points are sorted by coordinate, without duplicates
+func newPointTree(points []Point) *pointTree {
+	nodes := make([]pointNode, len(points))
+	for i := range points {
+		nodes[i].Point = points[i]
+	}
+	return &pointTree{top: balanced(nodes), count: len(points)}
+}
+
+// balanced links the sorted nodes into a balanced BST and returns its root, the median of the nodes. The depth of
+// the recursion is logarithmic
+func balanced(nodes []pointNode) *pointNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := (len(nodes) - 1) / 2
+	n := &nodes[mid]
+	n.left, n.right = balanced(nodes[:mid]), balanced(nodes[mid+1:])
+	return n
+}
+
+// size returns the number of points
+func (b *pointTree) size() int {
+	return b.count
+}
+
+// locate returns the link to the node of the point at x, or to the nil child where it must be added
+// Complexity: O(h), h = height of the BST
+func (b *pointTree) locate(x int) **pointNode {
+	link := &b.top
+	for *link != nil && (*link).x != x {
+		if x < (*link).x {
+			link = &(*link).left
+		} else {
+			link = &(*link).right
+		}
+	}
+	return link
+}
+
+// get returns the point at x, nil if there is none
+func (b *pointTree) get(x int) *Point {
+	if n := *b.locate(x); n != nil {
+		return &n.Point
+	}
+	return nil
+}
+
+// edit returns the point at x as locate finds it, nil if there is none, the nodes on its path being copied first
+// if they are shared with a snapshot so that the point can be modified in place
+// Complexity: O(h), h = height of the BST
+func (b *pointTree) edit(x int) *Point {
+	link := &b.top
+	for *link != nil {
+		b.own(link)
+		if (*link).x == x {
+			return &(*link).Point
+		}
+		if x < (*link).x {
+			link = &(*link).left
+		} else {
+			link = &(*link).right
+		}
+	}
+	return nil
+}
+
+// own replaces the node of the link by a copy stamped with the owner of the BST if it is shared with a snapshot,
+// the node holding the link being owned already
+// PRE: *link != nil
+func (b *pointTree) own(link **pointNode) {
+	if n := *link; n.owner != b.owner {
+		c := *n
+		c.ptrs, c.owner = slices.Clone(n.ptrs), b.owner
+		*link = &c
+	}
+}
+
+// add inserts the point at the bottom of the BST, unless there is already one at its coordinate
+func (b *pointTree) add(p *Point) {
+	link := &b.top
+	for *link != nil && (*link).x != p.x {
+		b.own(link)
+		if p.x < (*link).x {
+			link = &(*link).left
+		} else {
+			link = &(*link).right
+		}
+	}
+	if *link != nil {
+		return
+	}
+	*link = &pointNode{Point: *p, owner: b.owner}
+	b.count++
+}
+
+// remove deletes the point at x, if any. The node is rotated down the right spine of its subtree until it has no
+// right child, which keeps the order, then replaced by its left subtree. The nodes on the path and the ones rotated
+// are copied first if they are shared with a snapshot
+func (b *pointTree) remove(x int) {
+	if b.edit(x) == nil {
+		return
+	}
+	link := b.locate(x) // owned down to the point by edit
+	b.count--
+	for (*link).right != nil {
+		b.own(&(*link).right)
+		n, r := *link, (*link).right
+		n.right, r.left = r.left, n
+		*link = r
+		link = &r.left
+	}
+	*link = (*link).left
+}
+
+// search returns the points in [min, max], in ascending order. The in-order traversal keeps an explicit stack, so
+// it does not depend on the height of the BST
+// Output sensitive: Complexity of O(h + k), h = height of the BST and k = returned points
+func (b *pointTree) search(min, max int) []*Point {
+	var res []*Point
+	var stack []*pointNode
+	n := b.top
+	for {
+		// the nodes before min and their left subtrees are skipped
+		for n != nil {
+			if n.x < min {
+				n = n.right
+			} else {
+				stack = append(stack, n)
+				n = n.left
+			}
+		}
+		if len(stack) == 0 {
+			return res
+		}
+		n = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.x > max {
+			return res
+		}
+		res = append(res, &n.Point)
+		n = n.right
+	}
+}
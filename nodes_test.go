@@ -0,0 +1,126 @@
+package intervaltree
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+// endpointsIn returns the distinct first and last points of the intervals in [min, max], in ascending order
+func endpointsIn(intervals []*Interval, open, min, max int) []int {
+	var res []int
+	for _, in := range intervals {
+		for _, x := range []int{in.first(), lastPoint(in, open)} {
+			if min <= x && x <= max {
+				res = append(res, x)
+			}
+		}
+	}
+	slices.Sort(res)
+	return slices.Compact(res)
+}
+
+// coordinates returns the coordinates of the points
+func coordinates(points []*Point) []int {
+	var res []int
+	for _, p := range points {
+		res = append(res, p.x)
+	}
+	return res
+}
+
+func TestNodeStorage(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomIntervals(rnd, 1+rnd.Intn(2000), 1000, 1+rnd.Intn(100))
+		tree := MustNewIntervalTree(intervals, WithSmallThreshold(0))
+		for m := rnd.Intn(500); m > 0; m-- {
+			if all := tree.All(); rnd.Intn(2) == 0 && len(all) > 0 {
+				tree.Delete(all[rnd.Intn(len(all))])
+			} else {
+				in := &Interval{Start: rnd.Intn(1000), End: 0}
+				in.End = in.Start + rnd.Intn(100)
+				_ = tree.Insert(in)
+			}
+		}
+		naive := NewNaiveIntervalSet(tree.All())
+		for q := 0; q < 100; q++ {
+			x := rnd.Intn(1200) - 100
+			if got, want := tree.Containing(x), naive.Containing(x); !sameIntervals(got, want) {
+				t.Fatalf("%d: EXPECTING %d INTERVALS AS THE NAIVE SET, GOT %d", x, len(want), len(got))
+			}
+			end := x + rnd.Intn(300)
+			if got, want := coordinates(tree.pointsIn(x, end)), endpointsIn(tree.All(), 0, x, end); !slices.Equal(got, want) {
+				t.Fatalf("[%d, %d]: EXPECTING THE POINTS %v, GOT %v", x, end, want, got)
+			}
+		}
+	}
+}
+
+func TestPointTree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		// the points added at the bottom and removed by rotations must be found as in a set
+		points, set := newPointTree(nil), make(map[int]bool)
+		for m := 0; m < 2000; m++ {
+			x := rnd.Intn(500)
+			if rnd.Intn(3) == 0 {
+				points.remove(x)
+				delete(set, x)
+			} else {
+				points.add(&Point{x: x})
+				set[x] = true
+			}
+			if points.size() != len(set) {
+				t.Fatalf("EXPECTING %d POINTS, GOT %d", len(set), points.size())
+			}
+			if (points.get(x) != nil) != set[x] {
+				t.Fatalf("%d: EXPECTING TO FIND IT: %v", x, set[x])
+			}
+		}
+		for q := 0; q < 100; q++ {
+			min := rnd.Intn(600) - 50
+			max := min + rnd.Intn(200)
+			var want []int
+			for x := range set {
+				if min <= x && x <= max {
+					want = append(want, x)
+				}
+			}
+			slices.Sort(want)
+			if got := coordinates(points.search(min, max)); !slices.Equal(got, want) {
+				t.Fatalf("[%d, %d]: EXPECTING THE POINTS %v, GOT %v", min, max, want, got)
+			}
+		}
+	}
+	// the whole range is searched in order
+	tree := MustNewIntervalTree(randomIntervals(rnd, 1000, 1000, 100), WithEagerIndex())
+	if got := coordinates(tree.pointsIn(math.MinInt, math.MaxInt)); !slices.IsSorted(got) || len(got) != tree.points().size() {
+		t.Fatalf("EXPECTING THE %d POINTS IN ASCENDING ORDER, GOT %d", tree.points().size(), len(got))
+	}
+}
+
+func BenchmarkNodeStorage(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 1_000_000, 10_000_000, 1000))
+	count := func(*Interval) bool { return true }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stab(tree.nodes().root(), rnd.Intn(10_000_000), tree.open, count)
+	}
+}
+
+// BenchmarkPointStorage searches small windows of the points of a large tree, as the endpoint queries do
+func BenchmarkPointStorage(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 1_000_000, 10_000_000, 1000), WithEagerIndex())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := rnd.Intn(10_000_000)
+		tree.pointsIn(x, x+100)
+	}
+}
@@ -0,0 +1,188 @@
+package intervaltree
+
+// -----------------------------------------------------
+// 				CONSTRUCTION OPTIONS
+// -----------------------------------------------------
+
+// Option configures an IntervalTree when it is created
+type Option func(*config)
+
+// config holds the construction settings of an IntervalTree
+type config struct {
+	sequence  bool
+	keyFunc   func(*Interval) interface{}
+	rebuildAt int
+	dedup     bool
+	equal     func(a, b *Interval) bool
+	counted   bool
+	normalize bool
+	inPlace   bool
+	copied    bool
+	capacity  int
+	tie       func(a, b *Interval) bool
+	open      int
+	swap      bool
+	checked   bool
+	eager     bool
+
+	smallThreshold int
+}
+
+// newConfig applies the options given in parameter over the default settings
+func newConfig(opts []Option) *config {
+	cfg := &config{smallThreshold: DefaultSmallThreshold}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithSequenceNumbers records for every interval its position in the constructor input, which can then be read
+// with IntervalTree.SeqOf and filtered on with the SeqRange query option
+func WithSequenceNumbers() Option {
+	return func(c *config) {
+		c.sequence = true
+	}
+}
+
+// WithKeyFunc builds a secondary index from the key computed by keyFunc for every interval, usually read from its
+// Payload, to IntervalTree.FindByKey. A nil keyFunc disables the index
+func WithKeyFunc(keyFunc func(*Interval) interface{}) Option {
+	return func(c *config) {
+		c.keyFunc = keyFunc
+	}
+}
+
+// WithAutoRebuild makes the IntervalTree rebuild itself on the first query following threshold mutations, so that
+// a long series of Insert and Delete does not degrade the queries for good. Only that query pays for the rebuild
+// and its results are unchanged. As such a query modifies the tree, queries following mutations must not run
+// concurrently. A threshold <= 0 disables the automatic rebuild
+func WithAutoRebuild(threshold int) Option {
+	return func(c *config) {
+		c.rebuildAt = threshold
+	}
+}
+
+// WithDeduplication collapses the intervals of the constructor input having the same endpoints, and equal under eq
+// if not nil, into their first occurrence. The equality is kept by the IntervalTree for DeduplicateExact, the
+// intervals inserted afterwards are not deduplicated
+func WithDeduplication(eq func(a, b *Interval) bool) Option {
+	return func(c *config) {
+		c.dedup = true
+		c.equal = eq
+	}
+}
+
+// WithEquality collapses the intervals of the constructor input that are true duplicates, having the same endpoints
+// and equal under eq, into their first occurrence: eq is applied pairwise within every group of intervals with the
+// same endpoints, so the ones it tells apart are all stored. It is WithDeduplication(eq), except that a nil eq
+// disables the deduplication instead of collapsing on the endpoints alone
+func WithEquality(eq func(a, b *Interval) bool) Option {
+	return func(c *config) {
+		c.dedup = eq != nil
+		c.equal = eq
+	}
+}
+
+// WithMultiplicity stores the intervals having the same endpoints once, as the first of them, with the number of
+// times they were given. The queries return that representative once, ContainingWithCount and CountContaining
+// report the multiplicities. Inserting an interval with the endpoints of a stored one increments its count and
+// deleting decrements it, the representative being removed at zero
+func WithMultiplicity() Option {
+	return func(c *config) {
+		c.counted = true
+	}
+}
+
+// WithNormalization accepts the reversed intervals of the constructor input, with Start > End, by storing copies of
+// them with the endpoints swapped and the same payload. The caller's intervals are left untouched, the valid ones
+// being stored as is
+func WithNormalization() Option {
+	return func(c *config) {
+		c.normalize = true
+	}
+}
+
+// WithInPlaceNormalization is like WithNormalization but swaps Start and End in the reversed intervals of the
+// constructor input themselves, which are then stored as is
+func WithInPlaceNormalization() Option {
+	return func(c *config) {
+		c.normalize = true
+		c.inPlace = true
+	}
+}
+
+// WithCopiedIntervals makes the IntervalTree store copies of the intervals of the constructor input, allocated at once,
+// so that modifying them afterwards cannot corrupt the tree. The queries return the copies
+func WithCopiedIntervals() Option {
+	return func(c *config) {
+		c.copied = true
+	}
+}
+
+// WithCapacity preallocates in a Builder the room for n intervals and the buffers to build a tree of that size, see
+// NewBuilder. It has no effect on the other constructors
+func WithCapacity(n int) Option {
+	return func(c *config) {
+		c.capacity = n
+	}
+}
+
+// WithTieBreaker orders the intervals having the same endpoints by less, a strict weak order usually comparing their
+// payloads, instead of by input order. Given a less telling any two of them apart, the order of the results of
+// Containing and of the stored intervals depends on the set of intervals only, not on the order of the input nor
+// on the inserts and deletes that led to it. A nil less keeps the input order
+func WithTieBreaker(less func(a, b *Interval) bool) Option {
+	return func(c *config) {
+		c.tie = less
+	}
+}
+
+// WithHalfOpenIntervals makes the IntervalTree hold half-open intervals [Start, End), as byte ranges: End is not part
+// of the interval, so [0, 10) and [10, 20) do not intersect and Containing(10) does not return [0, 10). The
+// intervals must have Start < End. Every method follows these semantics, the query intervals being half-open too,
+// and the intervals computed by the tree, as Gaps or the clipped intervals, are half-open. The operations on
+// several trees panic with ErrMixedModes if they do not all hold the same kind of intervals. Interval.EndOpen is
+// implied, and Interval.StartOpen still excludes Start
+func WithHalfOpenIntervals() Option {
+	return func(c *config) {
+		c.open = 1
+	}
+}
+
+// WithSwappedQueries makes Intersecting and its variants swap the endpoints and the boundary flags of a reversed
+// query interval, [9, 1] being searched as [1, 9], instead of returning nothing for it, IntersectingE failing with
+// ErrReversedInterval
+func WithSwappedQueries() Option {
+	return func(c *config) {
+		c.swap = true
+	}
+}
+
+// WithIntegrityChecks makes Containing, Intersecting and their variants run CheckIntegrity before searching, the
+// E variants returning its error and the others panicking with it. It is meant for debugging the code modifying the
+// stored intervals, as every query then costs O(n log n). The tree is indexed at construction, even if small
+func WithIntegrityChecks() Option {
+	return func(c *config) {
+		c.checked = true
+	}
+}
+
+// WithSmallThreshold sets the number of intervals under which the IntervalTree is stored as a slice sorted by Start,
+// DefaultSmallThreshold if not given. Containing and Intersecting scan that slice, returning the intervals sorted by
+// Start, the nodes and the BST being built on the first call of another method. The first mutation leaves that mode
+// for good, the queries then returning the order of the nodes. A threshold <= 0 disables it
+func WithSmallThreshold(threshold int) Option {
+	return func(c *config) {
+		c.smallThreshold = threshold
+	}
+}
+
+// WithEagerIndex builds the BST of the endpoints at construction. By default it is built on the first call of a
+// method needing it, as the endpoint queries, Stats or the mutations, so that a tree only serving Containing and
+// Intersecting never pays for it. A small tree still builds it on first need, see WithSmallThreshold
+func WithEagerIndex() Option {
+	return func(c *config) {
+		c.eager = true
+	}
+}
@@ -0,0 +1,138 @@
+package intervaltree
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// -----------------------------------------------------
+// 				PARALLEL QUERIES
+// -----------------------------------------------------
+
+// parallelChunk is the number of intervals a worker copies at once
+const parallelChunk = 4096
+
+// scan is a range of the intervals of a node returned by a query: positions [from, to) of leftSorted, or of the order
+// by end if byEnd is set
+type scan struct {
+	e        *elt
+	byEnd    bool
+	from, to int
+}
+
+// ContainingParallel returns the intervals containing the value x, as Containing and in the same order, copied by
+// workers goroutines. The ranges of the node lists holding them are found by binary search on the path of x, then
+// split in chunks copied in parallel into the result, so it pays off on results of hundreds of thousands of
+// intervals. A workers <= 0 defaults to GOMAXPROCS. The queries only read the tree, so they can still run
+// concurrently with the other queries
+// Complexity: O(ln² n + k / workers), n = len(intervals in struct) and k = returned intervals
+func (t *IntervalTree) ContainingParallel(x int, workers int) []*Interval {
+	t = t.orEmpty()
+	t.heal()
+	t.checkIntegrity()
+	if t.small != nil {
+		return t.collectSmall(x, x)
+	}
+	return gather(scansContaining(t.nodes().root(), x, t.open, nil), workers)
+}
+
+// IntersectingParallel returns the intervals intersecting the Interval given in parameter, as Intersecting and in the
+// same order, copied by workers goroutines, see ContainingParallel. A nil or reversed query returns nothing, see
+// WithSwappedQueries
+// Complexity: O(ln² n + m ln n + k / workers), n = len(intervals in struct), m = number of nodes holding intervals
+// starting in the interval and k = returned intervals
+func (t *IntervalTree) IntersectingParallel(interval *Interval, workers int) []*Interval {
+	t = t.orEmpty()
+	t.heal()
+	t.checkIntegrity()
+	interval, err := t.window(interval)
+	if err != nil || t.empty(interval) {
+		return nil
+	}
+	start, end := interval.first(), t.last(interval)
+	if t.small != nil {
+		return t.collectSmall(start, end)
+	}
+	root := t.nodes().root()
+	scans := scansContaining(root, start, t.open, nil)
+	q := borrowScratch()
+	defer q.release()
+	nodesAfter(
+		root, start, end, &q.stack, func(e *elt) bool {
+			// the intervals starting in (start, end], contiguous in leftSorted
+			if from, to := e.starting(start, end); from < to {
+				scans = append(scans, scan{e: e, from: from, to: to})
+			}
+			return true
+		},
+	)
+	return gather(scans, workers)
+}
+
+// scansContaining appends to scans the ranges of the nodes on the path of x holding the intervals containing it, in
+// the order stab reports them: a prefix of leftSorted before xMid, a prefix of the order by end after it
+func scansContaining(root *iterator, x, open int, scans []scan) []scan {
+	stabCuts(
+		root, x, open, func(e *elt, byEnd bool, n int) bool {
+			if n > 0 {
+				scans = append(scans, scan{e: e, byEnd: byEnd, to: n})
+			}
+			return true
+		},
+	)
+	return scans
+}
+
+// gather copies the intervals of the scans, in their order, into a new slice, split in chunks of parallelChunk
+// intervals shared by the workers
+func gather(scans []scan, workers int) []*Interval {
+	type chunk struct {
+		s        scan
+		from, to int // the positions of the chunk in the scan
+		at       int // the position of its first interval in the result
+	}
+	var chunks []chunk
+	total := 0
+	for _, s := range scans {
+		for from := s.from; from < s.to; from += parallelChunk {
+			to := minInt(from+parallelChunk, s.to)
+			chunks = append(chunks, chunk{s, from, to, total})
+			total += to - from
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	res := make([]*Interval, total)
+	copyChunk := func(c chunk) {
+		if c.s.byEnd {
+			for k := c.from; k < c.to; k++ {
+				res[c.at+k-c.from] = c.s.e.endAt(k)
+			}
+		} else {
+			copy(res[c.at:], c.s.e.leftSorted[c.from:c.to])
+		}
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = minInt(workers, len(chunks))
+	var next atomic.Int64 // the next chunk to copy
+	var wg sync.WaitGroup
+	wg.Add(workers - 1)
+	work := func() {
+		for c := next.Add(1) - 1; c < int64(len(chunks)); c = next.Add(1) - 1 {
+			copyChunk(chunks[c])
+		}
+	}
+	for w := 1; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	work() // the calling goroutine is a worker too
+	wg.Wait()
+	return res
+}
@@ -0,0 +1,67 @@
+package intervaltree
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Parallel(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 20; i++ {
+		intervals, mode := randomFlagged(rnd, rnd.Intn(20_000), 10_000, 1+rnd.Intn(5_000)), []Option(nil)
+		if i%2 == 1 {
+			intervals, mode = randomHalfOpen(rnd, rnd.Intn(20_000), 10_000, 1+rnd.Intn(5_000)), []Option{WithHalfOpenIntervals()}
+		}
+		for _, tree := range append(treesOf(t, intervals, mode...), MustNewIntervalTree(intervals[:len(intervals)/100], mode...)) {
+			for q := 0; q < 20; q++ {
+				x, workers := rnd.Intn(12_000)-1_000, rnd.Intn(9)
+				if got, want := tree.ContainingParallel(x, workers), tree.Containing(x); !slices.Equal(got, want) {
+					t.Fatalf("%d WITH %d WORKERS: EXPECTING %d INTERVALS AS CONTAINING, GOT %d", x, workers, len(want), len(got))
+				}
+				window := &Interval{Start: x, End: x + rnd.Intn(3_000)}
+				if got, want := tree.IntersectingParallel(window, workers), tree.Intersecting(window); !slices.Equal(got, want) {
+					t.Fatalf("%s WITH %d WORKERS: EXPECTING %d INTERVALS AS INTERSECTING, GOT %d", window, workers, len(want), len(got))
+				}
+			}
+		}
+	}
+	var nilTree *IntervalTree
+	if nilTree.ContainingParallel(1, 4) != nil || nilTree.IntersectingParallel(&Interval{Start: 1, End: 2}, 4) != nil {
+		t.Fatalf("A NIL TREE MUST RETURN NOTHING")
+	}
+	tree := MustNewIntervalTree([]*Interval{{Start: 0, End: 10}}, WithSmallThreshold(0))
+	if tree.IntersectingParallel(nil, 4) != nil || tree.IntersectingParallel(&Interval{Start: 4, End: 1}, 4) != nil {
+		t.Fatalf("A NIL OR REVERSED QUERY MUST RETURN NOTHING")
+	}
+}
+
+// BenchmarkContainingParallel runs a query returning about 500k intervals with an increasing number of workers,
+// 0 using GOMAXPROCS
+func BenchmarkContainingParallel(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 1_400_000, 10_000_000, 10_000_000))
+	if k := len(tree.Containing(5_000_000)); k < 400_000 {
+		b.Fatalf("EXPECTING ABOUT 500K INTERVALS, GOT %d", k)
+	}
+	b.Run(
+		"Sequential", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tree.Containing(5_000_000)
+			}
+		},
+	)
+	for _, workers := range []int{0, 1, 2, 4, 8} {
+		b.Run(
+			fmt.Sprintf("Workers%d", workers), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					tree.ContainingParallel(5_000_000, workers)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,166 @@
+package intervaltree
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+// randomPoints generates n single point intervals [x, x] with x in [0, maxCoord]
+func randomPoints(rnd *rand.Rand, n, maxCoord int) []*Interval {
+	intervals := make([]*Interval, n)
+	for i := range intervals {
+		x := rnd.Intn(maxCoord + 1)
+		intervals[i] = &Interval{Start: x, End: x, Payload: i}
+	}
+	return intervals
+}
+
+func TestPointIntervals_Queries(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomPoints(rnd, rnd.Intn(500), 300)
+		if i%2 == 0 {
+			intervals = append(intervals, randomIntervals(rnd, rnd.Intn(100), 300, 20)...)
+		}
+		for _, tree := range treesOf(t, intervals) {
+			checkStructure(t, tree)
+			checkQueries(t, rnd, tree, intervals, 320)
+			for q := 0; q < 20; q++ {
+				x := rnd.Intn(320)
+				var want []*Interval
+				for _, in := range intervals {
+					if in.Start <= x && x <= in.End {
+						want = append(want, in)
+					}
+				}
+				if got := tree.Containing(x); !sameIntervals(got, want) {
+					t.Fatalf("CONTAINING(%d): EXPECTING %v, GOT %v", x, want, got)
+				}
+				if got := slices.Collect(tree.ContainingSeq(x)); !sameIntervals(got, want) {
+					t.Fatalf("CONTAININGSEQ(%d): EXPECTING %v, GOT %v", x, want, got)
+				}
+				if got := tree.CountContaining(x); got != len(want) {
+					t.Fatalf("COUNTCONTAINING(%d): EXPECTING %d, GOT %d", x, len(want), got)
+				}
+				query := &Interval{Start: x, End: x + rnd.Intn(30)}
+				if q%4 == 0 {
+					query.End = x // a single point query
+				}
+				want = nil
+				for _, in := range intervals {
+					if in.Start <= query.End && query.Start <= in.End {
+						want = append(want, in)
+					}
+				}
+				if got := tree.Intersecting(query); !sameIntervals(got, want) {
+					t.Fatalf("INTERSECTING(%s): EXPECTING %v, GOT %v", query, want, got)
+				}
+				if got := slices.Collect(tree.IntersectingSeq(query)); !sameIntervals(got, want) {
+					t.Fatalf("INTERSECTINGSEQ(%s): EXPECTING %v, GOT %v", query, want, got)
+				}
+				if got := tree.IntersectingWith(query); !sameIntervals(got, want) {
+					t.Fatalf("INTERSECTINGWITH(%s): EXPECTING %v, GOT %v", query, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestPointIntervals_OnlyPoints(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomPoints(rnd, 3000, 1000)
+	tree := MustNewIntervalTree(intervals)
+	checkStructure(t, tree)
+	distinct := make(map[int]int)
+	for _, in := range intervals {
+		distinct[in.Start]++
+	}
+	if got := tree.TotalCoveredLength(); got != len(distinct) {
+		t.Fatalf("EXPECTING %d COVERED COORDINATES, GOT %d", len(distinct), got)
+	}
+	if got := len(tree.Boundaries()); got != len(distinct) {
+		t.Fatalf("EXPECTING %d BOUNDARIES, GOT %d", len(distinct), got)
+	}
+	x, depth := tree.MaxOverlapPoint()
+	if depth != distinct[x] {
+		t.Fatalf("MAXOVERLAPPOINT: %d HOLDS %d POINTS, NOT %d", x, distinct[x], depth)
+	}
+	for y, n := range distinct {
+		if n > depth || n == depth && y < x {
+			t.Fatalf("MAXOVERLAPPOINT: %d HOLDS %d POINTS, MORE THAN %d AT %d", y, n, depth, x)
+		}
+	}
+	pairs, want := 0, 0
+	for _, n := range distinct {
+		want += n * (n - 1) / 2
+	}
+	tree.OverlappingPairs(func(a, b *Interval) bool { pairs++; return true })
+	if pairs != want {
+		t.Fatalf("EXPECTING %d OVERLAPPING PAIRS, GOT %d", want, pairs)
+	}
+	if got := len(tree.ConnectedComponents()); got != len(distinct) {
+		t.Fatalf("EXPECTING %d COMPONENTS, GOT %d", len(distinct), got)
+	}
+	if got := len(tree.MinStabbingPoints()); got != len(distinct) {
+		t.Fatalf("EXPECTING %d STABBING POINTS, GOT %d", len(distinct), got)
+	}
+	profile := tree.CoverageProfile(&Interval{Start: 0, End: 1000})
+	for _, s := range profile {
+		for y := s.Start; y <= s.End; y++ {
+			if distinct[y] != s.Depth {
+				t.Fatalf("SEGMENT %v DOES NOT MATCH THE %d POINTS AT %d", s, distinct[y], y)
+			}
+		}
+	}
+	for len(intervals) > 0 {
+		k := rnd.Intn(len(intervals))
+		if !tree.Delete(intervals[k]) {
+			t.Fatalf("CANNOT DELETE %s", intervals[k])
+		}
+		intervals = slices.Delete(intervals, k, k+1)
+		if len(intervals)%500 == 0 {
+			checkStructure(t, tree)
+			checkQueries(t, rnd, tree, intervals, 1000)
+		}
+	}
+}
+
+func TestPointIntervals_Identical(t *testing.T) {
+	intervals := make([]*Interval, 5000)
+	for i := range intervals {
+		intervals[i] = &Interval{Start: 42, End: 42, Payload: i}
+	}
+	for _, tree := range treesOf(t, intervals) {
+		checkStructure(t, tree)
+		if tree.NodeCount() != 1 || tree.Height() != 1 {
+			t.Fatalf("IDENTICAL POINTS MUST SHARE A SINGLE NODE, GOT %s", tree.Stats())
+		}
+		if got := tree.Containing(42); len(got) != len(intervals) {
+			t.Fatalf("EXPECTING %d INTERVALS AT 42, GOT %d", len(intervals), len(got))
+		}
+		if len(tree.Containing(41)) != 0 || len(tree.Containing(43)) != 0 {
+			t.Fatalf("NOTHING BUT 42 IS COVERED")
+		}
+		if got := tree.Intersecting(&Interval{Start: 0, End: 42}); len(got) != len(intervals) {
+			t.Fatalf("EXPECTING %d INTERVALS IN [ 0 - 42 ], GOT %d", len(intervals), len(got))
+		}
+		if got := slices.Collect(tree.IntersectingSeq(&Interval{Start: 42, End: 100})); len(got) != len(intervals) {
+			t.Fatalf("EXPECTING %d INTERVALS IN [ 42 - 100 ], GOT %d", len(intervals), len(got))
+		}
+		if got := tree.Boundaries(); !slices.Equal(got, []int{42}) {
+			t.Fatalf("EXPECTING THE SINGLE BOUNDARY 42, GOT %v", got)
+		}
+		if len(tree.EndingAt(42)) != len(intervals) || len(tree.StartingAt(42)) != len(intervals) {
+			t.Fatalf("EVERY INTERVAL STARTS AND ENDS AT 42")
+		}
+	}
+	for _, x := range []int{math.MinInt, math.MaxInt} {
+		tree := MustNewIntervalTree([]*Interval{{Start: x, End: x}, {Start: x, End: x}})
+		if len(tree.Containing(x)) != 2 || tree.TotalCoveredLength() != 1 {
+			t.Fatalf("EXPECTING THE 2 POINTS AT %d, GOT %v", x, tree.Containing(x))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package intervaltree
+
+import (
+	"slices"
+	"sync"
+)
+
+// -----------------------------------------------------
+// 				QUERY SCRATCH
+// -----------------------------------------------------
+
+// maxPooledScratch is the capacity above which the buffers of a queryScratch are dropped rather than pooled, so
+// that a single huge query does not pin its memory
+const maxPooledScratch = 1 << 16
+
+// queryScratch holds the buffers of a query, borrowed from scratchPool so that the queries in steady state only
+// allocate the slice they return. Each query borrows its own, so the concurrent queries on a tree share nothing
+type queryScratch struct {
+	found []*Interval // the intervals found, copied into the returned slice
+	stack []iterator  // the traversal stack of startingIn
+}
+
+var scratchPool = sync.Pool{
+	New: func() interface{} {
+		return &queryScratch{}
+	},
+}
+
+// borrowScratch returns an empty queryScratch, to be given back with release
+func borrowScratch() *queryScratch {
+	return scratchPool.Get().(*queryScratch)
+}
+
+// release empties the queryScratch and puts it back in the pool. It must not be used afterwards
+func (q *queryScratch) release() {
+	clear(q.found) // the stored intervals must not be kept alive by the pool
+	clear(q.stack)
+	q.found, q.stack = q.found[:0], q.stack[:0]
+	if cap(q.found) > maxPooledScratch {
+		q.found = nil
+	}
+	if cap(q.stack) > maxPooledScratch {
+		q.stack = nil
+	}
+	scratchPool.Put(q)
+}
+
+// collect adds the interval to the found intervals, to be passed as the fn of the traversals
+func (q *queryScratch) collect(in *Interval) bool {
+	q.found = append(q.found, in)
+	return true
+}
+
+// result returns the found intervals in a new slice of their exact length, nil if there are none
+func (q *queryScratch) result() []*Interval {
+	if len(q.found) == 0 {
+		return nil
+	}
+	return slices.Clone(q.found)
+}
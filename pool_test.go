@@ -0,0 +1,104 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_ConcurrentQueries(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 5000, 100_000, 1000)
+	for _, tree := range []*IntervalTree{MustNewIntervalTree(intervals), MustNewIntervalTree(intervals[:100])} {
+		type query struct {
+			x                        int
+			window                   *Interval
+			containing, intersecting []*Interval
+		}
+		queries := make([]query, 200)
+		for i := range queries {
+			x := rnd.Intn(101_000)
+			window := &Interval{Start: x, End: x + rnd.Intn(2000)}
+			queries[i] = query{x, window, tree.Containing(x), tree.Intersecting(window)}
+		}
+		var wg sync.WaitGroup
+		errs := make(chan string, 32)
+		for g := 0; g < 32; g++ {
+			wg.Add(1)
+			go func(seed int64) {
+				defer wg.Done()
+				rnd := rand.New(rand.NewSource(seed))
+				for i := 0; i < 500; i++ {
+					q := queries[rnd.Intn(len(queries))]
+					if !slices.Equal(tree.Containing(q.x), q.containing) {
+						errs <- "CONTAINING"
+						return
+					}
+					if !slices.Equal(tree.Intersecting(q.window), q.intersecting) {
+						errs <- "INTERSECTING"
+						return
+					}
+					for in := range tree.IntersectingSeq(q.window) {
+						if in != q.intersecting[0] {
+							errs <- "INTERSECTINGSEQ"
+							return
+						}
+						break
+					}
+				}
+			}(rnd.Int63())
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatalf("%s: A CONCURRENT QUERY MUST RETURN THE RESULT OF A SEQUENTIAL ONE", err)
+		}
+	}
+}
+
+// benchmarkQueryAllocs runs random Containing and Intersecting queries with the functions given
+func benchmarkQueryAllocs(b *testing.B, containing func(*IntervalTree, int) []*Interval, intersecting func(*IntervalTree, *Interval) []*Interval) {
+	tree := MustNewIntervalTree(randomIntervals(rand.New(rand.NewSource(1)), 100_000, 10_000_000, 10_000))
+	rnd := rand.New(rand.NewSource(2))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := rnd.Intn(10_000_000)
+		_ = containing(tree, x)
+		_ = intersecting(tree, &Interval{Start: x, End: x + 1000})
+	}
+}
+
+func BenchmarkQueryAllocs_Pooled(b *testing.B) {
+	benchmarkQueryAllocs(b, (*IntervalTree).Containing, (*IntervalTree).Intersecting)
+}
+
+// BenchmarkQueryAllocs_Unpooled appends to the returned slices and gives startingIn a new stack, as the queries did
+// before borrowing a queryScratch
+func BenchmarkQueryAllocs_Unpooled(b *testing.B) {
+	benchmarkQueryAllocs(
+		b, func(tree *IntervalTree, x int) []*Interval {
+			var res []*Interval
+			stab(
+				tree.nodes().root(), x, tree.open, func(in *Interval) bool {
+					res = append(res, in)
+					return true
+				},
+			)
+			return res
+		}, func(tree *IntervalTree, window *Interval) []*Interval {
+			var res []*Interval
+			var stack []iterator
+			collect := func(in *Interval) bool {
+				res = append(res, in)
+				return true
+			}
+			root := tree.nodes().root()
+			stab(root, window.first(), tree.open, collect)
+			startingIn(root, window.first(), tree.last(window), &stack, collect)
+			return res
+		},
+	)
+}
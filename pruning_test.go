@@ -0,0 +1,119 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// unprunedStab is stab going down to the bottom of the tree whatever the bounds of the nodes, as it did before them
+func unprunedStab(root *iterator, x, open int, fn func(*Interval) bool) bool {
+	itr := *root
+	for !itr.isBottom() {
+		e := itr.consult()
+		if !e.stab(x, open, fn) {
+			return false
+		}
+		if x > e.xMid {
+			itr = *itr.right()
+		} else if x < e.xMid {
+			itr = *itr.left()
+		} else {
+			break
+		}
+	}
+	return true
+}
+
+// visitedNodes returns the number of nodes stabCuts goes through for the value x
+func visitedNodes(tree *IntervalTree, x int) int {
+	visited := 0
+	stabCuts(
+		tree.nodes().root(), x, tree.open, func(*elt, bool, int) bool {
+			visited++
+			return true
+		},
+	)
+	return visited
+}
+
+func TestIntervalTree_Pruning(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals, mode := randomFlagged(rnd, 1+rnd.Intn(2000), 1000, 1+rnd.Intn(100)), []Option{WithSmallThreshold(0)}
+		if i%2 == 1 {
+			intervals, mode = randomHalfOpen(rnd, 1+rnd.Intn(2000), 1000, 1+rnd.Intn(100)), append(mode, WithHalfOpenIntervals())
+		}
+		tree := MustNewIntervalTree(intervals, mode...)
+		steps := map[string]func(){
+			"INSERT": func() {
+				for m := 0; m < 100; m++ {
+					in := &Interval{Start: rnd.Intn(1500) - 250, End: 0}
+					in.End = in.Start + 1 + rnd.Intn(300)
+					_ = tree.Insert(in)
+				}
+			},
+			"DELETE": func() {
+				tree.DeleteWhere(func(*Interval) bool { return rnd.Intn(3) == 0 })
+			},
+			"TRANSLATE": func() { _ = tree.Translate(rnd.Intn(200) - 100) },
+			"SCALE":     func() { _ = tree.Scale(2, 1, RoundFloor) },
+			"CLONE":     func() { tree = tree.Clone() },
+			"REBUILD":   func() { tree.Rebuild() },
+		}
+		for name, step := range steps {
+			step()
+			checkStructure(t, tree)
+			all := tree.All()
+			for q := 0; q < 100; q++ {
+				x := rnd.Intn(5000) - 2500
+				want := 0
+				for _, in := range all {
+					if in.first() <= x && x <= tree.last(in) {
+						want++
+					}
+				}
+				if got := len(tree.Containing(x)); got != want {
+					t.Fatalf("%s: CONTAINING(%d): EXPECTING %d VALUES, GOT %d", name, x, want, got)
+				}
+				if got := tree.AnyContaining(x); got != (want > 0) {
+					t.Fatalf("%s: ANYCONTAINING(%d): EXPECTING %v, GOT %v", name, x, want > 0, got)
+				}
+				window, intersecting := &Interval{Start: x, End: x + 1 + rnd.Intn(500)}, 0
+				for _, in := range all {
+					if in.first() <= tree.last(window) && x <= tree.last(in) {
+						intersecting++
+					}
+				}
+				if got := len(tree.Intersecting(window)); got != intersecting {
+					t.Fatalf("%s: INTERSECTING %s: EXPECTING %d VALUES, GOT %d", name, window, intersecting, got)
+				}
+			}
+		}
+		// a point outside of every interval is rejected at the root
+		root := tree.nodes().root().consult()
+		if root != nil && (visitedNodes(tree, root.hi+1) != 0 || visitedNodes(tree, root.lo-1) != 0) {
+			t.Fatalf("A POINT OUTSIDE OF [%d, %d] MUST VISIT NO NODE", root.lo, root.hi)
+		}
+	}
+}
+
+// benchmarkOutside runs stabbing queries far after the populated range of a large tree
+func benchmarkOutside(b *testing.B, stabbing func(root *iterator, x, open int, fn func(*Interval) bool) bool) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 1_000_000, 10_000_000, 1000))
+	count := func(*Interval) bool { return true }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stabbing(tree.nodes().root(), 20_000_000+rnd.Intn(10_000_000), tree.open, count)
+	}
+}
+
+func BenchmarkContaining_Outside(b *testing.B) {
+	benchmarkOutside(b, stab)
+}
+
+func BenchmarkContaining_Outside_Unpruned(b *testing.B) {
+	benchmarkOutside(b, unprunedStab)
+}
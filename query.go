@@ -0,0 +1,207 @@
+package intervaltree
+
+import "sort"
+
+// -----------------------------------------------------
+// 				QUERY OPTIONS
+// -----------------------------------------------------
+
+// SortOrder tells how the intervals returned by a query are ordered
+type SortOrder int
+
+const (
+	Unsorted   SortOrder = iota // order of the traversal
+	ByStart                     // ascending Start, then ascending End
+	ByEnd                       // ascending End, then ascending Start
+	BySequence                  // ascending sequence number, see WithSequenceNumbers
+)
+
+// QueryOption configures a single query made with ContainingWith or IntersectingWith
+type QueryOption func(*query)
+
+// query holds the settings of a single query
+type query struct {
+	seqFilter    bool
+	seqLo, seqHi uint64
+	order        SortOrder
+	limit        int  // 0 means no limit
+	noTouching   bool // intervals only touching the query interval are left out
+}
+
+// SeqRange keeps only the intervals whose sequence number is in [lo, hi]. The filter is applied during the
+// traversal, before sorting and limiting. Intervals without sequence number are never kept
+func SeqRange(lo, hi uint64) QueryOption {
+	return func(q *query) {
+		q.seqFilter, q.seqLo, q.seqHi = true, lo, hi
+	}
+}
+
+// SortBy sorts the result in the order given in parameter before the limit is applied
+func SortBy(order SortOrder) QueryOption {
+	return func(q *query) {
+		q.order = order
+	}
+}
+
+// Limit returns at most n intervals. Without sorting the traversal stops as soon as n intervals are found
+func Limit(n int) QueryOption {
+	return func(q *query) {
+		q.limit = n
+	}
+}
+
+// ExcludeTouching leaves out of IntersectingWith the intervals only touching the query interval, sharing a single
+// point with it at one of its ends as [1, 5] and [5, 9]: back-to-back intervals do not overlap. The points are
+// compared, so the open sides count: [1, 6) touches [5, 9] at 5 while [1, 5) does not intersect it. It has no effect
+// on ContainingWith, nor on a single point query interval, and back-to-back half-open intervals never intersect
+func ExcludeTouching() QueryOption {
+	return func(q *query) {
+		q.noTouching = true
+	}
+}
+
+// touching tells if the interval, intersecting the window, only shares the first or the last point of the window
+// while the window holds more than a single point. An interval goes from its first to its last point, see lastPoint
+func touching(in, window *Interval, open int) bool {
+	first, last := window.first(), lastPoint(window, open)
+	return first < last && (lastPoint(in, open) == first || in.first() == last)
+}
+
+// newQuery applies the options given in parameter over the default settings
+func newQuery(opts []QueryOption) *query {
+	q := &query{}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// SeqOf returns the sequence number of the interval, false if the tree does not record sequence numbers or does
+// not hold the interval
+func (t *IntervalTree) SeqOf(interval *Interval) (uint64, bool) {
+	t = t.orEmpty()
+	seq, ok := t.seq[interval]
+	return seq, ok
+}
+
+// ContainingWith returns the intervals containing the value x, filtered, sorted and limited by the options
+func (t *IntervalTree) ContainingWith(x int, opts ...QueryOption) []*Interval {
+	t = t.orEmpty()
+	t.heal()
+	t.checkIntegrity()
+	q := newQuery(opts)
+	var res []*Interval
+	stab(t.nodes().root(), x, t.open, t.collector(q, &res))
+	return t.finish(q, res)
+}
+
+// IntersectingWith returns the intervals intersecting the Interval given in parameter, filtered, sorted and limited
+// by the options
+func (t *IntervalTree) IntersectingWith(interval *Interval, opts ...QueryOption) []*Interval {
+	t = t.orEmpty()
+	t.heal()
+	t.checkIntegrity()
+	q := newQuery(opts)
+	var res []*Interval
+	interval, err := t.window(interval)
+	if err != nil || t.empty(interval) {
+		return res
+	}
+	collect := t.collector(q, &res)
+	t.overlap(interval, func(in *Interval) bool {
+		if q.noTouching && touching(in, interval, t.open) {
+			return true
+		}
+		return collect(in)
+	})
+	return t.finish(q, res)
+}
+
+// IntersectingStrict returns the intervals overlapping the Interval given in parameter by more than a shared
+// endpoint, as IntersectingWith with ExcludeTouching
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = intervals intersecting the query
+func (t *IntervalTree) IntersectingStrict(interval *Interval) []*Interval {
+	return t.IntersectingWith(interval, ExcludeTouching())
+}
+
+// IntersectingOpenEnd returns the intervals intersecting the Interval given in parameter with its End left out, as
+// [a, b): the intervals starting at b are not returned. The stored intervals keep their own bounds
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = intervals intersecting the query
+func (t *IntervalTree) IntersectingOpenEnd(interval *Interval) []*Interval {
+	return t.intersectingOpened(interval, false, true)
+}
+
+// IntersectingOpenStart returns the intervals intersecting the Interval given in parameter with its Start left out,
+// as (a, b]: the intervals ending at a are not returned, nor the ones only containing a
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = intervals intersecting the query
+func (t *IntervalTree) IntersectingOpenStart(interval *Interval) []*Interval {
+	return t.intersectingOpened(interval, true, false)
+}
+
+// IntersectingOpen returns the intervals intersecting the Interval given in parameter with both its endpoints left
+// out, as (a, b). The query (x, x + 1) holds no point and returns nothing
+// Output sensitive: Complexity of O(ln n + k), n = len(intervals in struct) and k = intervals intersecting the query
+func (t *IntervalTree) IntersectingOpen(interval *Interval) []*Interval {
+	return t.intersectingOpened(interval, true, true)
+}
+
+// intersectingOpened returns the intervals intersecting the Interval given in parameter with its Start left out if
+// start and its End if end, nothing for a nil or reversed query as Intersecting
+func (t *IntervalTree) intersectingOpened(interval *Interval, start, end bool) []*Interval {
+	interval, err := t.orEmpty().window(interval)
+	if err != nil {
+		return nil
+	}
+	window := *interval
+	window.StartOpen, window.EndOpen = window.StartOpen || start, window.EndOpen || end
+	return t.Intersecting(&window)
+}
+
+// collector returns the traversal callback appending to res the intervals kept by the query filters. It stops the
+// traversal once the limit is reached when the result does not need to be sorted
+func (t *IntervalTree) collector(q *query, res *[]*Interval) func(*Interval) bool {
+	return func(in *Interval) bool {
+		if q.seqFilter {
+			seq, ok := t.seq[in]
+			if !ok || seq < q.seqLo || seq > q.seqHi {
+				return true
+			}
+		}
+		*res = append(*res, in)
+		return q.order != Unsorted || q.limit <= 0 || len(*res) < q.limit
+	}
+}
+
+// finish sorts and truncates the result of a query
+func (t *IntervalTree) finish(q *query, res []*Interval) []*Interval {
+	t.sortIntervals(res, q.order)
+	if q.limit > 0 && len(res) > q.limit {
+		res = res[:q.limit]
+	}
+	return res
+}
+
+// sortIntervals sorts the intervals in place in the order given in parameter
+func (t *IntervalTree) sortIntervals(intervals []*Interval, order SortOrder) {
+	var less func(a, b *Interval) bool
+	switch order {
+	case ByStart:
+		less = func(a, b *Interval) bool { return a.lessStart(b) }
+	case ByEnd:
+		less = func(a, b *Interval) bool {
+			if a.End == b.End {
+				return a.Start < b.Start
+			}
+			return a.End < b.End
+		}
+	case BySequence:
+		less = func(a, b *Interval) bool { return t.seq[a] < t.seq[b] }
+	default:
+		return
+	}
+	sort.SliceStable(
+		intervals, func(i, j int) bool {
+			return less(intervals[i], intervals[j])
+		},
+	)
+}
@@ -0,0 +1,262 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_SeqRange(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 2000, 1000, 100)
+	tree := MustNewIntervalTree(intervals, WithSequenceNumbers())
+	for i, in := range intervals {
+		if seq, ok := tree.SeqOf(in); !ok || seq != uint64(i) {
+			t.Fatalf("EXPECTING SEQUENCE %d, GOT %d (%t)", i, seq, ok)
+		}
+	}
+	if _, ok := tree.SeqOf(&Interval{}); ok {
+		t.Fatalf("AN UNKNOWN INTERVAL MUST NOT HAVE A SEQUENCE NUMBER")
+	}
+	if _, ok := MustNewIntervalTree(intervals).SeqOf(intervals[0]); ok {
+		t.Fatalf("SEQUENCE NUMBERS MUST ONLY BE RECORDED WITH THE OPTION")
+	}
+
+	for i := 0; i < 100; i++ {
+		lo := uint64(rnd.Intn(len(intervals)))
+		hi := lo + uint64(rnd.Intn(300))
+		x := rnd.Intn(1100)
+		query := &Interval{Start: x, End: x + rnd.Intn(50)}
+		want := 0
+		for k, in := range intervals {
+			if uint64(k) >= lo && uint64(k) <= hi && in.Start <= query.End && in.End >= query.Start {
+				want++
+			}
+		}
+		got := tree.IntersectingWith(query, SeqRange(lo, hi))
+		if len(got) != want {
+			t.Fatalf("EXPECTING %d VALUES, GOT %d", want, len(got))
+		}
+		for _, in := range got {
+			if seq, _ := tree.SeqOf(in); seq < lo || seq > hi {
+				t.Fatalf("SEQUENCE %d OUT OF [%d, %d]", seq, lo, hi)
+			}
+		}
+		for _, in := range tree.ContainingWith(x, SeqRange(lo, hi)) {
+			if seq, _ := tree.SeqOf(in); seq < lo || seq > hi || in.Start > x || in.End < x {
+				t.Fatalf("%s WITH SEQUENCE %d MUST NOT BE RETURNED", in, seq)
+			}
+		}
+	}
+}
+
+func TestIntervalTree_QueryOptions(t *testing.T) {
+	var intervals []*Interval
+	for i := 0; i < 20; i++ {
+		// [19 - i, 20 + i]: later intervals start earlier and end later
+		intervals = append(intervals, &Interval{Start: 19 - i, End: 20 + i})
+	}
+	tree := MustNewIntervalTree(intervals, WithSequenceNumbers())
+
+	got := tree.ContainingWith(20, SeqRange(5, 14), SortBy(BySequence), Limit(3))
+	if len(got) != 3 || got[0] != intervals[5] || got[1] != intervals[6] || got[2] != intervals[7] {
+		t.Fatalf("EXPECTING THE SEQUENCES 5, 6, 7, GOT %v", got)
+	}
+	got = tree.IntersectingWith(&Interval{Start: 0, End: 40}, SeqRange(5, 14), SortBy(ByStart), Limit(2))
+	if len(got) != 2 || got[0] != intervals[14] || got[1] != intervals[13] {
+		t.Fatalf("EXPECTING THE SEQUENCES 14, 13, GOT %v", got)
+	}
+	got = tree.IntersectingWith(&Interval{Start: 0, End: 40}, SortBy(ByEnd))
+	for i := 1; i < len(got); i++ {
+		if got[i-1].End > got[i].End {
+			t.Fatalf("RESULT NOT SORTED BY END: %v", got)
+		}
+	}
+	if got = tree.ContainingWith(20, Limit(4)); len(got) != 4 {
+		t.Fatalf("EXPECTING 4 VALUES, GOT %d", len(got))
+	}
+	if got = tree.IntersectingWith(&Interval{Start: 0, End: 40}, SeqRange(30, 40)); len(got) != 0 {
+		t.Fatalf("EXPECTING NO VALUE, GOT %v", got)
+	}
+}
+
+func TestIntervalTree_ExcludeTouching(t *testing.T) {
+	// chain of back-to-back bookings, [10 k, 10 (k + 1)], and single point ones at every boundary
+	var chain []*Interval
+	for k := 0; k < 10; k++ {
+		chain = append(chain, &Interval{Start: 10 * k, End: 10 * (k + 1)}, &Interval{Start: 10 * k, End: 10 * k})
+	}
+	for _, tree := range []*IntervalTree{MustNewIntervalTree(chain), MustNewIntervalTree(chain, WithSmallThreshold(0))} {
+		for k := 0; k < 10; k++ {
+			booking := chain[2*k]
+			got := tree.IntersectingStrict(booking)
+			if len(got) != 1 || got[0] != booking {
+				t.Fatalf("%s MUST ONLY OVERLAP ITSELF, GOT %v", booking, got)
+			}
+			if got := tree.Intersecting(booking); len(got) < 3 {
+				t.Fatalf("%s MUST TOUCH ITS NEIGHBOURS, GOT %v", booking, got)
+			}
+		}
+		got := tree.IntersectingWith(&Interval{Start: 15, End: 35}, ExcludeTouching(), SortBy(ByStart))
+		if want := []*Interval{chain[2], chain[5], chain[4], chain[7], chain[6]}; !slices.Equal(got, want) {
+			t.Fatalf("EXPECTING %v, GOT %v", want, got)
+		}
+		if got := tree.IntersectingStrict(&Interval{Start: 20, End: 20}); len(got) != 3 {
+			t.Fatalf("A SINGLE POINT QUERY MUST KEEP THE INTERVALS CONTAINING IT, GOT %v", got)
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(300), 500, 20)
+		tree := MustNewIntervalTree(intervals)
+		x := rnd.Intn(520)
+		query := &Interval{Start: x, End: x + rnd.Intn(40)}
+		var want []*Interval
+		for _, in := range intervals {
+			overlap := minInt(in.End, query.End) - maxInt(in.Start, query.Start)
+			if overlap > 0 || overlap == 0 && (query.Start == query.End || in.Start > query.Start && in.End < query.End) {
+				want = append(want, in)
+			}
+		}
+		if got := tree.IntersectingStrict(query); !sameIntervals(got, want) {
+			t.Fatalf("INTERSECTINGSTRICT(%s): EXPECTING %v, GOT %v", query, want, got)
+		}
+		if got := tree.IntersectingWith(query, ExcludeTouching(), Limit(3)); len(got) != minInt(3, len(want)) {
+			t.Fatalf("EXPECTING %d VALUES, GOT %d", minInt(3, len(want)), len(got))
+		}
+	}
+	// the open sides are compared by their points
+	flagged := []*Interval{{Start: 1, End: 6, EndOpen: true}, {Start: 1, End: 5, EndOpen: true}, {Start: 9, End: 12, StartOpen: true}}
+	for _, tree := range []*IntervalTree{MustNewIntervalTree(flagged), MustNewIntervalTree(flagged, WithSmallThreshold(0))} {
+		query := &Interval{Start: 5, End: 9}
+		if got := tree.Intersecting(query); !sameIntervals(got, flagged[:1]) {
+			t.Fatalf("EXPECTING ONLY %s TO INTERSECT %s, GOT %v", flagged[0], query, got)
+		}
+		if got := tree.IntersectingStrict(query); len(got) != 0 {
+			t.Fatalf("%s ONLY TOUCHES %s AT 5, GOT %v", flagged[0], query, got)
+		}
+		if got := tree.IntersectingStrict(&Interval{Start: 3, End: 10}); !sameIntervals(got, flagged[:2]) {
+			t.Fatalf("[3, 10] ONLY TOUCHES %s AT 10, GOT %v", flagged[2], got)
+		}
+	}
+	half := MustNewIntervalTree([]*Interval{{Start: 0, End: 10}, {Start: 10, End: 20}}, WithHalfOpenIntervals())
+	if got := half.IntersectingStrict(&Interval{Start: 5, End: 15}); len(got) != 2 {
+		t.Fatalf("HALF-OPEN INTERVALS OVERLAPPING THE QUERY MUST BE KEPT, GOT %v", got)
+	}
+}
+
+func TestIntervalTree_IntersectingOpen(t *testing.T) {
+	left, right, point := &Interval{Start: 1, End: 5}, &Interval{Start: 5, End: 9}, &Interval{Start: 5, End: 5}
+	intervals := []*Interval{left, right, point}
+	for _, tree := range treesOf(t, intervals) {
+		if got := tree.Intersecting(&Interval{Start: 1, End: 5}); len(got) != 3 {
+			t.Fatalf("[ 1 - 5 ] MUST INTERSECT EVERY INTERVAL, GOT %v", got)
+		}
+		if got := tree.IntersectingOpenEnd(&Interval{Start: 1, End: 5}); !sameIntervals(got, []*Interval{left}) {
+			t.Fatalf("[ 1 - 5 ) MUST ONLY INTERSECT %s, GOT %v", left, got)
+		}
+		if got := tree.IntersectingOpenStart(&Interval{Start: 5, End: 9}); !sameIntervals(got, []*Interval{right}) {
+			t.Fatalf("( 5 - 9 ] MUST ONLY INTERSECT %s, GOT %v", right, got)
+		}
+		if got := tree.IntersectingOpen(&Interval{Start: 4, End: 6}); len(got) != 3 {
+			t.Fatalf("( 4 - 6 ) MUST INTERSECT EVERY INTERVAL, GOT %v", got)
+		}
+		if got := tree.IntersectingOpen(&Interval{Start: 4, End: 5}); len(got) != 0 {
+			t.Fatalf("( 4 - 5 ) HOLDS NO POINT, GOT %v", got)
+		}
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 50; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(300), 500, 20)
+		intervals = append(intervals, randomPoints(rnd, rnd.Intn(50), 500)...)
+		for _, tree := range treesOf(t, intervals) {
+			for q := 0; q < 20; q++ {
+				x := rnd.Intn(520)
+				query := &Interval{Start: x, End: x + rnd.Intn(40)}
+				var closed, openEnd, openStart, open []*Interval
+				for _, in := range intervals {
+					if in.Start > query.End || in.End < query.Start {
+						continue
+					}
+					closed = append(closed, in)
+					if in.Start < query.End && query.Start < query.End {
+						openEnd = append(openEnd, in)
+					}
+					if in.End > query.Start && query.Start < query.End {
+						openStart = append(openStart, in)
+					}
+					if in.Start < query.End && in.End > query.Start && query.End-query.Start > 1 {
+						open = append(open, in)
+					}
+				}
+				if got := tree.Intersecting(query); !sameIntervals(got, closed) {
+					t.Fatalf("INTERSECTING(%s): EXPECTING %v, GOT %v", query, closed, got)
+				}
+				if got := tree.IntersectingOpenEnd(query); !sameIntervals(got, openEnd) {
+					t.Fatalf("INTERSECTINGOPENEND(%s): EXPECTING %v, GOT %v", query, openEnd, got)
+				}
+				if got := tree.IntersectingOpenStart(query); !sameIntervals(got, openStart) {
+					t.Fatalf("INTERSECTINGOPENSTART(%s): EXPECTING %v, GOT %v", query, openStart, got)
+				}
+				if got := tree.IntersectingOpen(query); !sameIntervals(got, open) {
+					t.Fatalf("INTERSECTINGOPEN(%s): EXPECTING %v, GOT %v", query, open, got)
+				}
+				if query.EndOpen || query.StartOpen {
+					t.Fatalf("THE QUERY INTERVAL MUST NOT BE MODIFIED")
+				}
+			}
+		}
+	}
+}
+
+func TestIntervalTree_QueryValidation(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for open := 0; open <= 1; open++ {
+		intervals, mode := randomIntervals(rnd, 500, 1000, 100), []Option(nil)
+		if open == 1 {
+			intervals, mode = randomHalfOpen(rnd, 500, 1000, 100), []Option{WithHalfOpenIntervals()}
+		}
+		swapping := MustNewIntervalTree(intervals, append(mode, WithSwappedQueries())...)
+		for _, tree := range append(treesOf(t, intervals, mode...), swapping) {
+			if res, err := tree.IntersectingE(nil); !errors.Is(err, ErrNilInterval) || len(tree.Intersecting(nil)) != 0 {
+				t.Fatalf("A NIL QUERY MUST FAIL WITH ErrNilInterval, GOT %v %v", res, err)
+			}
+			for i := 0; i < 200; i++ {
+				// the single point query is the stabbing query, in the same order
+				x := rnd.Intn(1100) - 50
+				if got, want := tree.Intersecting(&Interval{Start: x, End: x + open}), tree.Containing(x); !slices.Equal(got, want) {
+					t.Fatalf("INTERSECTING [%d, %d]: EXPECTING %v, GOT %v", x, x+open, want, got)
+				}
+				start := rnd.Intn(1100) - 50
+				reversed := &Interval{Start: start + 1 + rnd.Intn(100), End: start, StartOpen: rnd.Intn(2) == 0}
+				swapped := &Interval{Start: reversed.End, End: reversed.Start, EndOpen: reversed.StartOpen}
+				res, err := tree.IntersectingE(reversed)
+				if tree.swap {
+					if want := tree.Intersecting(swapped); err != nil || !slices.Equal(res, want) {
+						t.Fatalf("QUERY %s MUST BE SWAPPED: EXPECTING %v, GOT %v %v", reversed, want, res, err)
+					}
+					if got := slices.Collect(tree.IntersectingSeq(reversed)); !sameSet(got, res) {
+						t.Fatalf("SEQUENCE OF %s: EXPECTING %v, GOT %v", reversed, res, got)
+					}
+					continue
+				}
+				if !errors.Is(err, ErrReversedInterval) {
+					t.Fatalf("QUERY %s MUST FAIL WITH ErrReversedInterval, GOT %v %v", reversed, res, err)
+				}
+				if got := tree.Intersecting(reversed); len(got) != 0 {
+					t.Fatalf("QUERY %s MUST RETURN NOTHING, GOT %v", reversed, got)
+				}
+				if got := slices.Collect(tree.IntersectingSeq(reversed)); len(got) != 0 {
+					t.Fatalf("SEQUENCE OF %s MUST BE EMPTY, GOT %v", reversed, got)
+				}
+				if got := tree.IntersectingWith(reversed, ExcludeTouching()); len(got) != 0 {
+					t.Fatalf("SORTED QUERY %s MUST RETURN NOTHING, GOT %v", reversed, got)
+				}
+			}
+		}
+	}
+}
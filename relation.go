@@ -0,0 +1,85 @@
+package intervaltree
+
+import "fmt"
+
+// -----------------------------------------------------
+// 				ALLEN RELATIONS
+// -----------------------------------------------------
+
+// Relation is one of the thirteen relations of Allen's interval algebra, exactly one of them holds between two
+// intervals
+type Relation int
+
+const (
+	Before       Relation = iota // a ends before b starts
+	Meets                        // a ends where b starts
+	Overlaps                     // a starts first and ends inside b
+	Starts                       // a starts with b and ends first
+	During                       // a is strictly inside b
+	Finishes                     // a starts after b and ends with it
+	Equals                       // same endpoints
+	FinishedBy                   // inverse of Finishes
+	Contains                     // inverse of During
+	StartedBy                    // inverse of Starts
+	OverlappedBy                 // inverse of Overlaps
+	MetBy                        // inverse of Meets
+	After                        // inverse of Before
+)
+
+var relationNames = [...]string{
+	"Before", "Meets", "Overlaps", "Starts", "During", "Finishes", "Equals",
+	"FinishedBy", "Contains", "StartedBy", "OverlappedBy", "MetBy", "After",
+}
+
+// String returns the name of the relation
+func (r Relation) String() string {
+	if r < Before || r > After {
+		return fmt.Sprintf("Relation(%d)", int(r))
+	}
+	return relationNames[r]
+}
+
+// Inverse returns the relation holding between b and a when r holds between a and b
+func (r Relation) Inverse() Relation {
+	return After - r
+}
+
+// Relation returns the Allen relation holding between the interval and the other one. It is computed on the raw
+// endpoints, as Allen's algebra over continuous time: the boundary flags are ignored, so [1, 5] and [5, 9] meet as
+// [1, 5) and [5, 9) do. A single point interval [x, x] starts the intervals beginning at x, finishes the ones
+// ending at x and is during the ones containing x. Both intervals must have Start <= End
+// Complexity: O(1)
+func (interval *Interval) Relation(other *Interval) Relation {
+	switch {
+	case interval.End < other.Start:
+		return Before
+	case other.End < interval.Start:
+		return After
+	case interval.Start == other.Start && interval.End == other.End:
+		return Equals
+	case interval.Start == other.Start:
+		if interval.End < other.End {
+			return Starts
+		}
+		return StartedBy
+	case interval.End == other.End:
+		if interval.Start > other.Start {
+			return Finishes
+		}
+		return FinishedBy
+	case interval.End == other.Start:
+		return Meets
+	case interval.Start == other.End:
+		return MetBy
+	case interval.Start < other.Start:
+		if interval.End < other.End {
+			return Overlaps
+		}
+		return Contains
+	default:
+		if interval.End < other.End {
+			return During
+		}
+		return OverlappedBy
+	}
+}
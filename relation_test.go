@@ -0,0 +1,63 @@
+package intervaltree
+
+import "testing"
+
+func TestInterval_Relation(t *testing.T) {
+	// Allen's definitions, written independently of Relation. A single point interval only meets nothing as it
+	// would also start or finish the other one
+	definitions := map[Relation]func(as, ae, bs, be int) bool{
+		Before:   func(as, ae, bs, be int) bool { return ae < bs },
+		Meets:    func(as, ae, bs, be int) bool { return as < ae && ae == bs && bs < be },
+		Overlaps: func(as, ae, bs, be int) bool { return as < bs && bs < ae && ae < be },
+		Starts:   func(as, ae, bs, be int) bool { return as == bs && ae < be },
+		During:   func(as, ae, bs, be int) bool { return bs < as && ae < be },
+		Finishes: func(as, ae, bs, be int) bool { return bs < as && ae == be },
+		Equals:   func(as, ae, bs, be int) bool { return as == bs && ae == be },
+	}
+	for r := Before; r < Equals; r++ {
+		definition := definitions[r]
+		definitions[r.Inverse()] = func(as, ae, bs, be int) bool { return definition(bs, be, as, ae) }
+	}
+	if len(definitions) != 13 {
+		t.Fatalf("EXPECTING 13 RELATIONS, GOT %d", len(definitions))
+	}
+
+	// four endpoint values in [0, 3] cover every ordering of the endpoints, equalities included
+	seen := make(map[Relation]bool)
+	for as := 0; as < 4; as++ {
+		for ae := as; ae < 4; ae++ {
+			for bs := 0; bs < 4; bs++ {
+				for be := bs; be < 4; be++ {
+					a, b := &Interval{Start: as, End: ae}, &Interval{Start: bs, End: be}
+					var holding []Relation
+					for r, definition := range definitions {
+						if definition(as, ae, bs, be) {
+							holding = append(holding, r)
+						}
+					}
+					if len(holding) != 1 {
+						t.Fatalf("EXACTLY ONE RELATION MUST HOLD BETWEEN %s AND %s, GOT %v", a, b, holding)
+					}
+					got := a.Relation(b)
+					if got != holding[0] {
+						t.Fatalf("%s RELATION %s: EXPECTING %s, GOT %s", a, b, holding[0], got)
+					}
+					if inverse := b.Relation(a); inverse != got.Inverse() {
+						t.Fatalf("%s RELATION %s: EXPECTING %s, GOT %s", b, a, got.Inverse(), inverse)
+					}
+					seen[got] = true
+				}
+			}
+		}
+	}
+	if len(seen) != 13 {
+		t.Fatalf("EVERY RELATION MUST BE REACHED, GOT %v", seen)
+	}
+
+	if got := (&Interval{Start: 1, End: 5, EndOpen: true}).Relation(&Interval{Start: 5, End: 9}); got != Meets {
+		t.Fatalf("[ 1 - 5 ) MUST MEET [ 5 - 9 ], GOT %s", got)
+	}
+	if Overlaps.String() != "Overlaps" || MetBy.String() != "MetBy" || Relation(13).String() != "Relation(13)" {
+		t.Fatalf("WRONG RELATION NAMES")
+	}
+}
@@ -0,0 +1,75 @@
+package intervaltree
+
+import "iter"
+
+// -----------------------------------------------------
+// 				ITERATORS
+// -----------------------------------------------------
+
+// ContainingSeq returns an iterator over the intervals containing the value x, in the same order as Containing.
+// Breaking out of the loop stops the traversal. Each range over the sequence runs the query again, and the
+// IntervalTree must not be mutated while ranging over it
+func (t *IntervalTree) ContainingSeq(x int) iter.Seq[*Interval] {
+	t = t.orEmpty()
+	return func(yield func(*Interval) bool) {
+		t.heal()
+		t.checkIntegrity()
+		if t.small != nil {
+			t.scanSmall(x, x, t.guard(yield))
+			return
+		}
+		stab(t.nodes().root(), x, t.open, t.guard(yield))
+	}
+}
+
+// IntersectingSeq returns an iterator over the intervals intersecting the Interval given in parameter, each once.
+// The intervals containing interval.Start come first, then the ones starting after it inside the interval, so no
+// set is needed to remove the duplicates. Each range over the sequence runs the query again, and the IntervalTree
+// must not be mutated while ranging over it
+func (t *IntervalTree) IntersectingSeq(interval *Interval) iter.Seq[*Interval] {
+	t = t.orEmpty()
+	interval, err := t.window(interval)
+	return func(yield func(*Interval) bool) {
+		t.heal()
+		t.checkIntegrity()
+		yield = t.guard(yield)
+		if err != nil || t.empty(interval) {
+			return
+		}
+		if t.small != nil {
+			t.scanSmall(interval.first(), t.last(interval), yield)
+			return
+		}
+		t.overlap(interval, yield)
+	}
+}
+
+// AllSeq returns an iterator over every interval stored in the IntervalTree, in the same order as All.
+// The IntervalTree must not be mutated while ranging over it
+func (t *IntervalTree) AllSeq() iter.Seq[*Interval] {
+	t = t.orEmpty()
+	return func(yield func(*Interval) bool) {
+		yield = t.guard(yield)
+		walk(
+			t.nodes(), func(e *elt) bool {
+				for _, in := range e.leftSorted {
+					if !yield(in) {
+						return false
+					}
+				}
+				return true
+			},
+		)
+	}
+}
+
+// guard wraps yield to panic if the IntervalTree is mutated while an iterator is running
+func (t *IntervalTree) guard(yield func(*Interval) bool) func(*Interval) bool {
+	epoch := t.epoch
+	return func(in *Interval) bool {
+		if t.epoch != epoch {
+			panic("intervaltree: IntervalTree mutated while iterating over it")
+		}
+		return yield(in)
+	}
+}
@@ -0,0 +1,84 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Seq(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 100; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(500), 2000, 200)
+		intervals = append(intervals, &Interval{Start: 1000, End: 1000}, &Interval{Start: 1000, End: 1200})
+		tree := MustNewIntervalTree(intervals)
+		x := rnd.Intn(2200)
+		query := &Interval{Start: x, End: x + rnd.Intn(300)}
+		if i%3 == 0 {
+			query = &Interval{Start: 990, End: 1000}
+		}
+
+		var containing []*Interval
+		for in := range tree.ContainingSeq(x) {
+			containing = append(containing, in)
+		}
+		want := tree.Containing(x)
+		if len(containing) != len(want) {
+			t.Fatalf("CONTAININGSEQ: EXPECTING %d VALUES, GOT %d", len(want), len(containing))
+		}
+		for k := range want {
+			if containing[k] != want[k] {
+				t.Fatalf("CONTAININGSEQ MUST FOLLOW THE ORDER OF CONTAINING")
+			}
+		}
+
+		expected := make(map[*Interval]bool)
+		for _, in := range tree.Intersecting(query) {
+			expected[in] = true
+		}
+		seen := make(map[*Interval]bool)
+		for in := range tree.IntersectingSeq(query) {
+			if seen[in] || !expected[in] {
+				t.Fatalf("INTERSECTINGSEQ YIELDED %s TWICE OR WRONGLY", in)
+			}
+			seen[in] = true
+		}
+		if len(seen) != len(expected) {
+			t.Fatalf("INTERSECTINGSEQ: EXPECTING %d VALUES, GOT %d", len(expected), len(seen))
+		}
+
+		count := 0
+		for range tree.AllSeq() {
+			count++
+		}
+		if count != len(intervals) {
+			t.Fatalf("ALLSEQ: EXPECTING %d VALUES, GOT %d", len(intervals), count)
+		}
+	}
+}
+
+func TestIntervalTree_SeqBreak(t *testing.T) {
+	var intervals []*Interval
+	for i := 0; i < 100; i++ {
+		intervals = append(intervals, &Interval{Start: i, End: 200})
+	}
+	tree := MustNewIntervalTree(intervals)
+	count := 0
+	for range tree.IntersectingSeq(&Interval{Start: 0, End: 300}) {
+		count++
+		if count == 5 {
+			break
+		}
+	}
+	if count != 5 {
+		t.Fatalf("EXPECTING TO STOP AFTER 5 VALUES, GOT %d", count)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("MUTATING THE TREE WHILE ITERATING MUST PANIC")
+		}
+	}()
+	for range tree.AllSeq() {
+		tree.epoch++ // what any mutation does
+	}
+}
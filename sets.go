@@ -0,0 +1,50 @@
+package intervaltree
+
+// -----------------------------------------------------
+// 				SET OPERATIONS
+// -----------------------------------------------------
+
+// Equality tells when the set operations between trees consider two intervals equal
+type Equality int
+
+const (
+	// EqualPointers matches intervals by pointer identity
+	EqualPointers Equality = iota
+	// EqualEndpoints matches intervals with the same Start, End and boundary flags, whatever their payloads
+	EqualEndpoints
+)
+
+// UnionTree returns a new IntervalTree holding the intervals of a and the ones of b having no equal in a, the
+// *Interval pointers being shared. It takes the settings of a, see Merge. The set operations panic with
+// ErrMixedModes if one tree holds closed intervals and the other half-open ones.
+// Complexity: O(n log n), n = a.Len() + b.Len()
+func UnionTree(a, b *IntervalTree, eq Equality) *IntervalTree {
+	if eq == EqualPointers || b == nil {
+		return Merge(a, b)
+	}
+	return Merge(a, b.Filter(func(in *Interval) bool { return len(a.FindLike(in)) == 0 }))
+}
+
+// IntersectionTree returns a new IntervalTree holding the intervals of a having an equal in b, see Filter
+// Complexity: O(n log m), n = a.Len() and m = b.Len()
+func IntersectionTree(a, b *IntervalTree, eq Equality) *IntervalTree {
+	sameMode(a, b)
+	b = b.orEmpty()
+	return a.Filter(func(in *Interval) bool { return b.hasEqual(in, eq) })
+}
+
+// DifferenceTree returns a new IntervalTree holding the intervals of a having no equal in b, see Filter
+// Complexity: O(n log m), n = a.Len() and m = b.Len()
+func DifferenceTree(a, b *IntervalTree, eq Equality) *IntervalTree {
+	sameMode(a, b)
+	b = b.orEmpty()
+	return a.Filter(func(in *Interval) bool { return !b.hasEqual(in, eq) })
+}
+
+// hasEqual tells if the IntervalTree stores an interval equal to in
+func (t *IntervalTree) hasEqual(in *Interval, eq Equality) bool {
+	if eq == EqualEndpoints {
+		return len(t.FindLike(in)) > 0
+	}
+	return t.holds(in)
+}
@@ -0,0 +1,83 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSetOperations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	shared := randomIntervals(rnd, 300, 1000, 50)
+	onlyA := randomIntervals(rnd, 300, 1000, 50)
+	onlyB := randomIntervals(rnd, 300, 1000, 50)
+	a := MustNewIntervalTree(append(append([]*Interval(nil), shared...), onlyA...))
+	b := MustNewIntervalTree(append(append([]*Interval(nil), shared...), onlyB...))
+	member := func(list []*Interval) func(*Interval) bool {
+		set := make(map[*Interval]bool)
+		for _, in := range list {
+			set[in] = true
+		}
+		return func(in *Interval) bool { return set[in] }
+	}
+
+	union := UnionTree(a, b, EqualPointers)
+	checkStructure(t, union)
+	checkQueries(t, rnd, union, append(append(append([]*Interval(nil), shared...), onlyA...), onlyB...), 1000)
+	intersection := IntersectionTree(a, b, EqualPointers)
+	checkStructure(t, intersection)
+	checkQueries(t, rnd, intersection, shared, 1000)
+	for _, x := range intersection.All() {
+		if !member(shared)(x) {
+			t.Fatalf("%s IS NOT IN BOTH TREES", x)
+		}
+	}
+	difference := DifferenceTree(a, b, EqualPointers)
+	checkStructure(t, difference)
+	checkQueries(t, rnd, difference, onlyA, 1000)
+
+	// copies of the same endpoints never share pointers
+	copies := make([]*Interval, len(shared))
+	for i, in := range shared {
+		copies[i] = &Interval{Start: in.Start, End: in.End, Payload: "copy"}
+	}
+	c := MustNewIntervalTree(append(append([]*Interval(nil), copies...), onlyB...))
+	if got := IntersectionTree(a, c, EqualPointers).Len(); got != 0 {
+		t.Fatalf("EXPECTING NO INTERVAL IN COMMON BY POINTER, GOT %d", got)
+	}
+	// intervals of a with the same endpoints as an interval of c
+	var want []*Interval
+	for _, x := range a.All() {
+		if c.Has(x.Start, x.End) {
+			want = append(want, x)
+		}
+	}
+	intersection = IntersectionTree(a, c, EqualEndpoints)
+	checkStructure(t, intersection)
+	checkQueries(t, rnd, intersection, want, 1000)
+	for _, x := range intersection.All() {
+		if x.Payload == "copy" {
+			t.Fatalf("THE INTERSECTION MUST HOLD THE INTERVALS OF A")
+		}
+	}
+	difference = DifferenceTree(a, c, EqualEndpoints)
+	checkStructure(t, difference)
+	if difference.Len()+intersection.Len() != a.Len() {
+		t.Fatalf("EXPECTING %d INTERVALS IN THE DIFFERENCE, GOT %d", a.Len()-intersection.Len(), difference.Len())
+	}
+	for _, x := range difference.All() {
+		if c.Has(x.Start, x.End) {
+			t.Fatalf("%s IS IN BOTH TREES", x)
+		}
+	}
+	union = UnionTree(a, c, EqualEndpoints)
+	checkStructure(t, union)
+	for _, x := range c.All() {
+		if a.Has(x.Start, x.End) == union.holds(x) {
+			t.Fatalf("THE UNION MUST HOLD %s ONLY IF A HAS NO EQUAL", x)
+		}
+	}
+	if union.Len() != a.Len()+DifferenceTree(c, a, EqualEndpoints).Len() {
+		t.Fatalf("EXPECTING %d INTERVALS IN THE UNION, GOT %d", a.Len()+DifferenceTree(c, a, EqualEndpoints).Len(), union.Len())
+	}
+}
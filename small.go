@@ -0,0 +1,96 @@
+package intervaltree
+
+import (
+	"sort"
+	"sync"
+)
+
+// -----------------------------------------------------
+// 				SMALL TREES
+// -----------------------------------------------------
+
+// DefaultSmallThreshold is the number of intervals under which an IntervalTree starts as a sorted slice, see
+// WithSmallThreshold
+const DefaultSmallThreshold = 32
+
+// setSmall makes the IntervalTree answer Containing and Intersecting from the intervals sorted by Start, the nodes
+// and the BST being built only when another method needs them
+// Build complexity: O(n log n), n = len(intervals)
+func (t *IntervalTree) setSmall(intervals []*Interval) {
+	t.small = make([]*Interval, len(intervals))
+	copy(t.small, intervals)
+	sort.SliceStable(
+		t.small, func(i, j int) bool {
+			return startOrder(t.small[i], t.small[j], t.tie)
+		},
+	)
+	t.lazy, t.index = new(sync.Once), new(sync.Once)
+	t.cover = newCoverage(intervals, t.open)
+}
+
+// materialize builds the nodes of a small IntervalTree if they are not built yet. Concurrent queries may call it,
+// the sync.Once making them wait for a single build
+func (t *IntervalTree) materialize() {
+	if t.lazy == nil {
+		return
+	}
+	t.lazy.Do(
+		func() {
+			t.tree, _ = fromIntervals(t.small, t.tie, t.open) // cannot fail, the intervals are valid
+		},
+	)
+}
+
+// indexPoints builds the BST of the endpoints from the stored intervals if it is not built yet, see
+// WithEagerIndex. Concurrent queries may call it, as materialize. The mutations call it before modifying anything,
+// so that the BST holds the intervals they update
+func (t *IntervalTree) indexPoints() {
+	if t.index == nil {
+		return
+	}
+	t.index.Do(
+		func() {
+			intervals := t.small
+			if intervals == nil {
+				intervals = t.intervals()
+			}
+			t.bst = buildBST(intervals, t.open)
+		},
+	)
+}
+
+// nodes returns the binary tree holding the nodes, built first if the IntervalTree is small
+func (t *IntervalTree) nodes() *nodeTree {
+	t.materialize()
+	return t.tree
+}
+
+// points returns the BST of the endpoints, built first if it is not built yet
+func (t *IntervalTree) points() *pointTree {
+	t.materialize()
+	t.indexPoints()
+	return t.bst
+}
+
+// scanSmall calls fn on the intervals of a small IntervalTree having a point in [start, end], in ascending order of
+// Start up to the first one starting after end. It stops as soon as fn returns false and tells if it went to the end
+// Complexity: O(n), n = len(intervals in struct)
+func (t *IntervalTree) scanSmall(start, end int, fn func(*Interval) bool) bool {
+	for _, in := range t.small {
+		if in.first() > end {
+			break
+		}
+		if t.last(in) >= start && !fn(in) {
+			return false
+		}
+	}
+	return true
+}
+
+// collectSmall returns the intervals of a small IntervalTree having a point in [start, end], sorted by Start
+func (t *IntervalTree) collectSmall(start, end int) []*Interval {
+	q := borrowScratch()
+	defer q.release()
+	t.scanSmall(start, end, q.collect)
+	return q.result()
+}
@@ -0,0 +1,191 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Small(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 500; i++ {
+		intervals := randomIntervals(rnd, rnd.Intn(DefaultSmallThreshold), 200, 30)
+		small := MustNewIntervalTree(intervals)
+		full := MustNewIntervalTree(intervals, WithSmallThreshold(0))
+		if small.small == nil || small.tree != nil || small.bst != nil {
+			t.Fatalf("EXPECTING A SMALL TREE FOR %d INTERVALS", len(intervals))
+		}
+		if full.small != nil {
+			t.Fatalf("A THRESHOLD OF 0 MUST DISABLE THE SMALL TREES")
+		}
+		checkQueries(t, rnd, small, intervals, 200)
+		for x := -1; x < 232; x++ {
+			query := &Interval{Start: x, End: x + rnd.Intn(20)}
+			if !sameIntervals(small.Containing(x), full.Containing(x)) {
+				t.Fatalf("CONTAINING(%d): EXPECTING %v, GOT %v", x, full.Containing(x), small.Containing(x))
+			}
+			if !sameIntervals(small.Intersecting(query), full.Intersecting(query)) {
+				t.Fatalf("INTERSECTING(%s): EXPECTING %v, GOT %v", query, full.Intersecting(query), small.Intersecting(query))
+			}
+		}
+		if small.tree != nil {
+			t.Fatalf("CONTAINING AND INTERSECTING MUST NOT BUILD THE NODES")
+		}
+
+		// the other methods build the nodes once, concurrently if needed
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = small.AllSorted(ByStart)
+				_ = small.Stats()
+			}()
+		}
+		wg.Wait()
+		checkStructure(t, small)
+		if !sameStructure(small, full) || small.small == nil {
+			t.Fatalf("EXPECTING THE STRUCTURE OF THE FULL TREE, KEEPING THE SORTED SLICE")
+		}
+
+		// a mutation leaves the small mode
+		in := &Interval{Start: rnd.Intn(200), End: 250}
+		_ = small.Insert(in)
+		_ = full.Insert(in)
+		if small.small != nil {
+			t.Fatalf("A MUTATION MUST DROP THE SORTED SLICE")
+		}
+		if x := rnd.Intn(250); !sameIntervals(small.Containing(x), full.Containing(x)) {
+			t.Fatalf("CONTAINING(%d) AFTER INSERT: EXPECTING %v, GOT %v", x, full.Containing(x), small.Containing(x))
+		}
+	}
+	if tree := MustNewIntervalTree(randomIntervals(rnd, DefaultSmallThreshold, 200, 30)); tree.small != nil {
+		t.Fatalf("EXPECTING A FULL TREE AT THE THRESHOLD")
+	}
+	if tree := MustNewIntervalTree(randomIntervals(rnd, 100, 200, 30), WithSmallThreshold(101)); tree.small == nil {
+		t.Fatalf("THE THRESHOLD MUST BE OVERRIDABLE")
+	}
+}
+
+func TestIntervalTree_SmallOrder(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 500; i++ {
+		// few coordinates, so that many intervals share their endpoints
+		intervals, mode := randomFlagged(rnd, rnd.Intn(DefaultSmallThreshold), 40, 1+rnd.Intn(20)), []Option(nil)
+		if i%2 == 1 {
+			intervals, mode = randomHalfOpen(rnd, rnd.Intn(DefaultSmallThreshold), 40, 1+rnd.Intn(20)), []Option{WithHalfOpenIntervals()}
+		}
+		small := MustNewIntervalTree(intervals, mode...)
+		full := MustNewIntervalTree(intervals, append(mode, WithSmallThreshold(0))...)
+		byStart := func(a, b *Interval) int { return compareBy(a, b, (*Interval).lessStart) }
+		var points []int
+		for x := -2; x < 65; x++ {
+			points = append(points, x)
+			want := small.Containing(x)
+			if !sameIntervals(want, full.Containing(x)) || !slices.IsSortedFunc(want, byStart) {
+				t.Fatalf("CONTAINING(%d): EXPECTING %v SORTED BY START, GOT %v", x, full.Containing(x), want)
+			}
+			if got := slices.Collect(small.ContainingSeq(x)); !slices.Equal(got, want) {
+				t.Fatalf("CONTAININGSEQ(%d): EXPECTING %v, GOT %v", x, want, got)
+			}
+			if got := small.ContainingParallel(x, 2); !slices.Equal(got, want) {
+				t.Fatalf("CONTAININGPARALLEL(%d): EXPECTING %v, GOT %v", x, want, got)
+			}
+			query := &Interval{Start: x, End: x + rnd.Intn(30)}
+			want = small.Intersecting(query)
+			if !sameIntervals(want, full.Intersecting(query)) || !slices.IsSortedFunc(want, byStart) {
+				t.Fatalf("INTERSECTING %s: EXPECTING %v SORTED BY START, GOT %v", query, full.Intersecting(query), want)
+			}
+			if got := slices.Collect(small.IntersectingSeq(query)); !slices.Equal(got, want) {
+				t.Fatalf("INTERSECTINGSEQ %s: EXPECTING %v, GOT %v", query, want, got)
+			}
+			if got := small.IntersectingParallel(query, 2); !slices.Equal(got, want) {
+				t.Fatalf("INTERSECTINGPARALLEL %s: EXPECTING %v, GOT %v", query, want, got)
+			}
+		}
+		for j, res := range small.AnswerAll(points) {
+			if want := small.Containing(points[j]); !slices.Equal(res, want) {
+				t.Fatalf("ANSWERALL(%d): EXPECTING %v, GOT %v", points[j], want, res)
+			}
+		}
+		if small.small == nil || small.tree != nil {
+			t.Fatalf("THE QUERIES OF A SMALL TREE MUST NOT BUILD THE NODES")
+		}
+		// leaving the small mode returns the order of the nodes
+		_ = small.Insert(&Interval{Start: 1000, End: 1001})
+		_ = full.Insert(&Interval{Start: 1000, End: 1001})
+		for j, res := range small.AnswerAll(points) {
+			if want := full.Containing(points[j]); !sameIntervals(res, want) {
+				t.Fatalf("CONTAINING(%d) AFTER A MUTATION: EXPECTING %v, GOT %v", points[j], want, res)
+			}
+		}
+	}
+}
+
+// sameIntervals tells if both lists hold the same intervals, in any order
+func sameIntervals(a, b []*Interval) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[*Interval]int)
+	for _, in := range a {
+		count[in]++
+	}
+	for _, in := range b {
+		if count[in]--; count[in] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func benchmarkSmall(b *testing.B, n int, opts ...Option) {
+	rnd := rand.New(rand.NewSource(1))
+	intervals := randomIntervals(rnd, n, 1000, 100)
+	b.Run(
+		"Build", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = NewIntervalTree(intervals, opts...)
+			}
+		},
+	)
+	b.Run(
+		"BuildQuery", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tree := MustNewIntervalTree(intervals, opts...)
+				_ = tree.Containing(i % 1000)
+				_ = tree.Intersecting(&Interval{Start: i % 1000, End: i%1000 + 50})
+			}
+		},
+	)
+	tree := MustNewIntervalTree(intervals, opts...)
+	b.Run(
+		"Query", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = tree.Containing(i % 1000)
+				_ = tree.Intersecting(&Interval{Start: i % 1000, End: i%1000 + 50})
+			}
+		},
+	)
+}
+
+func BenchmarkSmall(b *testing.B) {
+	benchmarkSmall(b, 20)
+}
+
+func BenchmarkSmall_Tree(b *testing.B) {
+	benchmarkSmall(b, 20, WithSmallThreshold(0))
+}
+
+func BenchmarkSmall_AboveThreshold(b *testing.B) {
+	benchmarkSmall(b, 1000)
+}
+
+func BenchmarkSmall_AboveThreshold_Tree(b *testing.B) {
+	benchmarkSmall(b, 1000, WithSmallThreshold(0))
+}
@@ -0,0 +1,101 @@
+package intervaltree
+
+import (
+	"maps"
+	"slices"
+	"sync/atomic"
+)
+
+// -----------------------------------------------------
+// 				SNAPSHOTS
+// -----------------------------------------------------
+
+// owner stamps the nodes a structure may modify in place. The nodeTree, the pointTree and the coverage hold the
+// owner of their nodes, nil when built, and a mutation copies every node on its path that holds another one, see
+// nodeTree. The IntervalTree also holds one, shared with its snapshots until it is modified: the unmodified nodes
+// stay shared by all of them
+type owner struct {
+	shared atomic.Bool // a snapshot holds the same structure, it must be forked before the next mutation
+}
+
+// Snapshot returns a point-in-time view of the IntervalTree sharing its whole structure, so it is O(1) to take.
+// The structure is copy-on-write: a mutation of either tree afterwards copies the nodes on its path only, from
+// the root to the nodes it modifies, in the nodes, the BST and the coverage, the other nodes staying shared. The
+// snapshot never observes a later mutation of the tree nor the tree one of the snapshot, and taking one costs
+// nothing until a mutation. As the *Interval pointers stay shared, UpdatePayload and Replace replace the interval
+// they modify by a copy, and Translate and Scale work on copies of all of them, see Freeze, so the other tree
+// keeps the original ones.
+// Complexity: O(1)
+func (t *IntervalTree) Snapshot() *IntervalTree {
+	t = t.orEmpty()
+	t.materialize() // the copy must not share the pending builds
+	t.indexPoints()
+	if t.owner == nil {
+		t.owner = new(owner) // a tree built as a literal
+	}
+	t.owner.shared.Store(true)
+	s := *t
+	return &s
+}
+
+// unshare forks the structure shared with a snapshot before it is modified, and builds the BST the mutation
+// updates. The headers get a new owner, so the nodes are copied when reached by a mutation. The optional maps, the
+// sequence numbers, the multiplicities and the key index, are copied whole
+// Complexity: O(1) if not shared or without the optional maps, else O(n), n = len(intervals in struct)
+func (t *IntervalTree) unshare() {
+	t.indexPoints()
+	if t.owner == nil {
+		t.owner = new(owner) // a tree built as a literal
+	}
+	if !t.owner.shared.Load() {
+		return
+	}
+	t.owner, t.snapshotted = new(owner), true
+	t.tree = &nodeTree{top: t.nodes().top, owner: t.owner}
+	t.bst = &pointTree{top: t.bst.top, count: t.bst.count, owner: t.owner}
+	t.cover = &coverage{top: t.cover.top, count: t.cover.count, total: t.cover.total, owner: t.owner}
+	if t.seq != nil {
+		t.seq = maps.Clone(t.seq)
+	}
+	if t.counts != nil {
+		t.counts = maps.Clone(t.counts)
+	}
+	if t.keys != nil {
+		keys := make(map[interface{}][]*Interval, len(t.keys))
+		for key, list := range t.keys {
+			keys[key] = slices.Clone(list) // modified in place by the key index
+		}
+		t.keys = keys
+	}
+}
+
+// sharesIntervals tells if the stored intervals may be shared with a snapshot, so they must not be modified in
+// place
+func (t *IntervalTree) sharesIntervals() bool {
+	return t.snapshotted || t.owner != nil && t.owner.shared.Load()
+}
+
+// WithInterval returns a new IntervalTree holding the intervals of the IntervalTree plus in, which is left
+// untouched. The new version is a Snapshot in which in is inserted: it only copies the nodes on the path of the
+// insertion and shares all the others with the IntervalTree, so every version costs O(ln n) nodes, plus the
+// optional maps, see unshare.
+// Complexity: O(ln n + m), n = len(intervals in struct) and m = number of intervals in the receiving node
+func (t *IntervalTree) WithInterval(in *Interval) (*IntervalTree, error) {
+	t = t.orEmpty()
+	if err := validate(in, t.open); err != nil {
+		return nil, err
+	}
+	v := t.Snapshot()
+	_ = v.Insert(in) // cannot fail, in is valid
+	return v, nil
+}
+
+// WithoutInterval returns a new IntervalTree holding the intervals of the IntervalTree but in, matched by pointer
+// identity, which is left untouched. The new version is a Snapshot from which in is deleted, sharing the nodes off
+// the path of the deletion as WithInterval, and holds the same intervals as the IntervalTree if in is not stored.
+// Complexity: O(ln n + m + k), as a Delete
+func (t *IntervalTree) WithoutInterval(in *Interval) *IntervalTree {
+	v := t.Snapshot()
+	v.Delete(in)
+	return v
+}
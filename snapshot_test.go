@@ -0,0 +1,296 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Snapshot(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 1000, 1000, 50)
+	tree := MustNewIntervalTree(intervals, WithSequenceNumbers(), WithKeyFunc(func(in *Interval) interface{} { return in.Start }))
+	snapshot := tree.Snapshot()
+	if snapshot.nodes() != tree.nodes() || snapshot.points() != tree.points() {
+		t.Fatalf("A SNAPSHOT MUST SHARE THE STRUCTURE")
+	}
+	all := snapshot.All()
+	values := make([]Interval, len(all))
+	for i, in := range all {
+		values[i] = *in
+	}
+	containing := make([][]*Interval, 1000)
+	for x := range containing {
+		containing[x] = snapshot.Containing(x)
+	}
+
+	// mutate the tree heavily
+	for _, in := range intervals[:500] {
+		if !tree.Delete(in) {
+			t.Fatalf("CANNOT DELETE %s", in)
+		}
+	}
+	added := randomIntervals(rnd, 1000, 1000, 50)
+	for _, in := range added {
+		_ = tree.Insert(in)
+	}
+	tree.DeleteWhere(func(in *Interval) bool { return in.Start%7 == 0 })
+	checkStructure(t, tree)
+	checkStructure(t, snapshot)
+
+	after := snapshot.All()
+	if len(after) != len(all) {
+		t.Fatalf("EXPECTING %d INTERVALS IN THE SNAPSHOT, GOT %d", len(all), len(after))
+	}
+	for i, in := range after {
+		if in != all[i] || *in != values[i] {
+			t.Fatalf("THE SNAPSHOT CHANGED: EXPECTING %s, GOT %s", &values[i], in)
+		}
+	}
+	for x := range containing {
+		got := snapshot.Containing(x)
+		if len(got) != len(containing[x]) {
+			t.Fatalf("CONTAINING(%d) OF THE SNAPSHOT CHANGED", x)
+		}
+		for i := range got {
+			if got[i] != containing[x][i] {
+				t.Fatalf("CONTAINING(%d) OF THE SNAPSHOT CHANGED", x)
+			}
+		}
+	}
+	if seq, ok := snapshot.SeqOf(intervals[0]); !ok || seq != 0 || len(snapshot.FindByKey(intervals[0].Start)) == 0 {
+		t.Fatalf("THE SNAPSHOT MUST KEEP ITS SEQUENCE NUMBERS AND KEYS")
+	}
+
+	// the snapshot can be mutated without affecting the tree
+	live := tree.All()
+	for _, in := range all[:100] {
+		snapshot.Delete(in)
+	}
+	checkStructure(t, snapshot)
+	if len(tree.All()) != len(live) {
+		t.Fatalf("MUTATING THE SNAPSHOT CHANGED THE TREE")
+	}
+
+	// the shared intervals are copied before being modified, again and again on both trees
+	for round := 0; round < 2; round++ {
+		stored, kept := tree.All()[round], snapshot.All()
+		value := *stored
+		if err := tree.Replace(stored, 2000+10*round, 2001+10*round); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		if moved := tree.Containing(2000 + 10*round); *stored != value || len(moved) != 1 || moved[0] == stored || moved[0].Payload != value.Payload {
+			t.Fatalf("REPLACE MUST MOVE A COPY OF %s, GOT %v", &value, moved)
+		}
+		if err := tree.UpdatePayload(tree.All()[0], "updated"); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		if err := snapshot.Translate(1); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		if err := snapshot.Scale(2, 1, RoundFloor); err != nil {
+			t.Fatalf("UNEXPECTED ERROR %v", err)
+		}
+		for k, in := range snapshot.All() {
+			if in.Start != 2*(kept[k].Start+1) || in == kept[k] {
+				t.Fatalf("THE SNAPSHOT MUST SCALE COPIES OF %s, GOT %s", kept[k], in)
+			}
+		}
+		for _, in := range tree.All() {
+			if in.Start < 0 || in.Start > 2010 {
+				t.Fatalf("TRANSLATING THE SNAPSHOT MOVED %s IN THE TREE", in)
+			}
+		}
+		checkStructure(t, tree)
+		checkStructure(t, snapshot)
+		snapshot = tree.Snapshot()
+	}
+}
+
+// sharedNodes returns the number of nodes, BST points and coverage runs of the tree that the other one holds too
+func sharedNodes(tree, other *IntervalTree) (nodes, points, runs int) {
+	seen := make(map[interface{}]bool)
+	var visit func(n *pointNode, fn func(n *pointNode))
+	visit = func(n *pointNode, fn func(n *pointNode)) {
+		if n != nil {
+			fn(n)
+			visit(n.left, fn)
+			visit(n.right, fn)
+		}
+	}
+	walk(other.nodes(), func(e *elt) bool { seen[e] = true; return true })
+	visit(other.points().top, func(n *pointNode) { seen[n] = true })
+	other.cover.top.all(func(r *runNode) { seen[r] = true })
+	walk(
+		tree.nodes(), func(e *elt) bool {
+			if seen[e] {
+				nodes++
+			}
+			return true
+		},
+	)
+	visit(
+		tree.points().top, func(n *pointNode) {
+			if seen[n] {
+				points++
+			}
+		},
+	)
+	tree.cover.top.all(
+		func(r *runNode) {
+			if seen[r] {
+				runs++
+			}
+		},
+	)
+	return nodes, points, runs
+}
+
+func TestIntervalTree_SnapshotSharing(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 10_000, 100_000, 20), WithSmallThreshold(0))
+	snapshot := tree.Snapshot()
+	s, all := tree.Stats(), tree.All()
+	for _, mutate := range []func(){
+		func() { _ = tree.Insert(&Interval{Start: 50_000, End: 50_010}) },
+		func() { tree.Delete(all[0]) },
+		func() { _ = tree.UpdatePayload(all[1], 1) },
+		func() { _ = tree.Replace(all[2], 90_000, 90_001) },
+	} {
+		before := tree.Stats()
+		mutate()
+		// a mutation copies its paths only, whatever the size of the tree
+		nodes, points, runs := sharedNodes(tree, snapshot)
+		if copied := before.Nodes - nodes; copied > 4*s.Height {
+			t.Fatalf("EXPECTING A PATH OF NODES COPIED, GOT %d OF %d", copied, before.Nodes)
+		}
+		if copied := before.Points - points; copied > 256 {
+			t.Fatalf("EXPECTING A FEW PATHS OF BST POINTS COPIED, GOT %d OF %d", copied, before.Points)
+		}
+		if copied := snapshot.cover.count - runs; copied > 256 {
+			t.Fatalf("EXPECTING A FEW PATHS OF RUNS COPIED, GOT %d OF %d", copied, snapshot.cover.count)
+		}
+		checkStructure(t, tree)
+		checkStructure(t, snapshot)
+		snapshot = tree.Snapshot()
+	}
+}
+
+func TestIntervalTree_WithInterval(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 200, 500, 50)
+	versions := []*IntervalTree{MustNewIntervalTree(nil)}
+	contents := [][]*Interval{nil}
+	answers := [][][]*Interval{answersOf(versions[0])}
+	for i, in := range intervals {
+		last := versions[len(versions)-1]
+		var next *IntervalTree
+		var content []*Interval
+		if i%3 == 2 {
+			// remove a random interval of the last version
+			stored := contents[len(contents)-1]
+			victim := stored[rnd.Intn(len(stored))]
+			next = last.WithoutInterval(victim)
+			for _, s := range stored {
+				if s != victim {
+					content = append(content, s)
+				}
+			}
+		} else {
+			var err error
+			if next, err = last.WithInterval(in); err != nil {
+				t.Fatalf("UNEXPECTED ERROR %v", err)
+			}
+			content = append(append(content, contents[len(contents)-1]...), in)
+		}
+		versions = append(versions, next)
+		contents = append(contents, content)
+		answers = append(answers, answersOf(next))
+	}
+	// every version answers according to its own contents, exactly as when it was made
+	for i, version := range versions {
+		checkStructure(t, version)
+		checkQueries(t, rnd, version, contents[i], 500)
+		for x, want := range answersOf(version) {
+			if !slices.Equal(want, answers[i][x]) {
+				t.Fatalf("VERSION %d CHANGED AFTER THE LATER ONES WERE MADE: EXPECTING %v, GOT %v", i, answers[i][x], want)
+			}
+		}
+	}
+	if _, err := versions[0].WithInterval(&Interval{Start: 2, End: 1}); !errors.Is(err, ErrReversedInterval) {
+		t.Fatalf("EXPECTING ErrReversedInterval, GOT %v", err)
+	}
+}
+
+// answersOf returns All and then Containing(x) for every x in [0, 550) of the version, in the order they come
+func answersOf(version *IntervalTree) [][]*Interval {
+	res := [][]*Interval{version.All()}
+	for x := 0; x < 550; x++ {
+		res = append(res, version.Containing(x))
+	}
+	return res
+}
+
+// versionBytes returns the heap bytes held by every version made by WithInterval from a tree of n intervals, the
+// coordinates growing with n so that the nodes hold as many intervals whatever n. The BST is built first, as it
+// would be by the first version
+func versionBytes(n int) float64 {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, n, 10*n, 100), WithSmallThreshold(0), WithEagerIndex())
+	added := randomIntervals(rnd, 200, 10*n, 100)
+	versions := make([]*IntervalTree, 0, len(added))
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for _, in := range added {
+		v, _ := tree.WithInterval(in)
+		versions = append(versions, v)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(versions)
+	return float64(after.HeapAlloc-before.HeapAlloc) / float64(len(added))
+}
+
+func TestIntervalTree_WithIntervalMemory(t *testing.T) {
+	// a version copies a path of nodes, whose length grows as ln n: far less than the 100 times more intervals
+	small, large := versionBytes(1000), versionBytes(100_000)
+	if large > 4*small {
+		t.Fatalf("EXPECTING THE BYTES PER VERSION NOT TO GROW WITH THE TREE, GOT %.0f AT 1K AND %.0f AT 100K", small, large)
+	}
+}
+
+// benchmarkVersions makes versions of a large tree holding one more interval
+func benchmarkVersions(b *testing.B, with func(tree *IntervalTree, in *Interval) *IntervalTree) {
+	rnd := rand.New(rand.NewSource(1))
+	tree := MustNewIntervalTree(randomIntervals(rnd, 100_000, 1_000_000, 100))
+	added := randomIntervals(rnd, 1000, 1_000_000, 100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		with(tree, added[i%len(added)])
+	}
+}
+
+func BenchmarkWithInterval(b *testing.B) {
+	benchmarkVersions(
+		b, func(tree *IntervalTree, in *Interval) *IntervalTree {
+			v, _ := tree.WithInterval(in)
+			return v
+		},
+	)
+}
+
+// BenchmarkWithInterval_Clone copies the whole tree before inserting, as WithInterval did
+func BenchmarkWithInterval_Clone(b *testing.B) {
+	benchmarkVersions(
+		b, func(tree *IntervalTree, in *Interval) *IntervalTree {
+			v := tree.CloneShallow()
+			_ = v.Insert(in)
+			return v
+		},
+	)
+}
@@ -0,0 +1,83 @@
+package intervaltree
+
+import (
+	"fmt"
+	"math"
+)
+
+// -----------------------------------------------------
+// 				STRUCTURE STATISTICS
+// -----------------------------------------------------
+
+// Height returns the number of levels of the IntervalTree, 0 for an empty tree. Fully nested intervals all
+// contain the first median point and give a height of 1.
+// Complexity: O(m), m = number of nodes, with an iterative traversal
+func (t *IntervalTree) Height() int {
+	t = t.orEmpty()
+	height := 0
+	level := []*iterator{t.nodes().root()}
+	for {
+		var next []*iterator
+		for _, itr := range level {
+			if !itr.isBottom() {
+				next = append(next, itr.left(), itr.right())
+			}
+		}
+		if len(next) == 0 {
+			return height
+		}
+		height++
+		level = next
+	}
+}
+
+// NodeCount returns the number of nodes of the IntervalTree, each node holding the intervals containing its median
+// Complexity: O(m), m = number of nodes
+func (t *IntervalTree) NodeCount() int {
+	t = t.orEmpty()
+	count := 0
+	walk(
+		t.nodes(), func(e *elt) bool {
+			count++
+			return true
+		},
+	)
+	return count
+}
+
+// Stats summarizes the shape and the memory layout of an IntervalTree
+type Stats struct {
+	Intervals        int // number of stored intervals
+	Nodes            int // number of nodes of the tree
+	Height           int // number of levels of the tree
+	Points           int // number of distinct endpoints in the BST
+	MaxNodeIntervals int // size of the biggest per-node interval list
+	Pointers         int // number of Interval pointers held by the node lists and the BST
+}
+
+// Stats walks both internal structures to summarize the IntervalTree
+// Complexity: O(n + p log p), n = number of stored intervals and p = number of distinct endpoints
+func (t *IntervalTree) Stats() Stats {
+	t = t.orEmpty()
+	s := Stats{Intervals: t.Len(), Height: t.Height(), Points: t.points().size()}
+	walk(
+		t.nodes(), func(e *elt) bool {
+			s.Nodes++
+			s.MaxNodeIntervals = maxInt(s.MaxNodeIntervals, len(e.leftSorted))
+			s.Pointers += len(e.leftSorted)
+			return true
+		},
+	)
+	for _, p := range t.pointsIn(math.MinInt, math.MaxInt) {
+		s.Pointers += len(p.ptrs)
+	}
+	return s
+}
+
+// String prints the statistics on a single line
+func (s Stats) String() string {
+	return fmt.Sprintf(
+		"intervals: %d, nodes: %d, height: %d, points: %d, max node intervals: %d, pointers: %d",
+		s.Intervals, s.Nodes, s.Height, s.Points, s.MaxNodeIntervals, s.Pointers,
+	)
+}
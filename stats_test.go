@@ -0,0 +1,98 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+func TestIntervalTree_Height(t *testing.T) {
+	var nested, disjoint, chain []*Interval
+	for i := 0; i < 100; i++ {
+		nested = append(nested, &Interval{Start: i, End: 200 - i})
+	}
+	for i := 0; i < 7; i++ {
+		disjoint = append(disjoint, &Interval{Start: 2 * i, End: 2*i + 1})
+	}
+	for i := 0; i < 3; i++ {
+		chain = append(chain, &Interval{Start: 2 * i, End: 2*i + 1})
+	}
+	tests := []struct {
+		name      string
+		intervals []*Interval
+		height    int
+		nodeCount int
+	}{
+		{"EMPTY", nil, 0, 0},
+		{"SINGLE", []*Interval{{Start: 3, End: 3}}, 1, 1},
+		{"NESTED", nested, 1, 1},
+		// 7 disjoint intervals give a complete tree of 3 levels
+		{"DISJOINT", disjoint, 3, 7},
+		// [0, 1] [2, 3] [4, 5]: the median endpoint 3 keeps [2, 3] at the root
+		{"THREE DISJOINT", chain, 2, 3},
+	}
+	for _, test := range tests {
+		tree := MustNewIntervalTree(test.intervals)
+		if tree.Height() != test.height || tree.NodeCount() != test.nodeCount {
+			t.Errorf(
+				"%s: EXPECTING HEIGHT %d WITH %d NODES, GOT %d WITH %d", test.name, test.height, test.nodeCount,
+				tree.Height(), tree.NodeCount(),
+			)
+		}
+	}
+}
+
+func TestIntervalTree_Stats(t *testing.T) {
+	// [0, 10] and [2, 8] contain the median 8, [12, 15] and [12, 13] go right with the median 13
+	tree := MustNewIntervalTree([]*Interval{{Start: 0, End: 10}, {Start: 2, End: 8}, {Start: 12, End: 15}, {Start: 12, End: 13}})
+	// the nodes hold every interval once and the BST at both its endpoints
+	want := Stats{Intervals: 4, Nodes: 2, Height: 2, Points: 7, MaxNodeIntervals: 2, Pointers: 12}
+	if got := tree.Stats(); got != want {
+		t.Fatalf("EXPECTING %s, GOT %s", want, got)
+	}
+	if got := MustNewIntervalTree(nil).Stats(); got != (Stats{}) {
+		t.Fatalf("EXPECTING EMPTY STATS, GOT %s", got)
+	}
+	want = Stats{Intervals: 1, Nodes: 1, Height: 1, Points: 1, MaxNodeIntervals: 1, Pointers: 3}
+	if got := MustNewIntervalTree([]*Interval{{Start: 5, End: 5}}).Stats(); got != want {
+		t.Fatalf("EXPECTING %s, GOT %s", want, got)
+	}
+	if s := want.String(); s != "intervals: 1, nodes: 1, height: 1, points: 1, max node intervals: 1, pointers: 3" {
+		t.Fatalf("UNEXPECTED STRING %q", s)
+	}
+}
+
+// benchmarkMemory reports the heap held by a tree of 5M intervals, alongside the intervals themselves. With
+// rightSorted, the nodes also held a second slice of pointers, the layout measured by BenchmarkMemory_RightSorted
+func benchmarkMemory(b *testing.B, rightSorted bool) {
+	intervals := randomIntervals(rand.New(rand.NewSource(1)), 5_000_000, 1_000_000_000, 10_000)
+	var before, after runtime.MemStats
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		tree := MustNewIntervalTree(intervals)
+		var copies [][]*Interval
+		if rightSorted {
+			walk(
+				tree.nodes(), func(e *elt) bool {
+					copies = append(copies, append([]*Interval(nil), e.leftSorted...))
+					return true
+				},
+			)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(len(intervals)), "heap-bytes/interval")
+		runtime.KeepAlive(tree)
+		runtime.KeepAlive(copies)
+	}
+}
+
+func BenchmarkMemory(b *testing.B) {
+	benchmarkMemory(b, false)
+}
+
+func BenchmarkMemory_RightSorted(b *testing.B) {
+	benchmarkMemory(b, true)
+}
@@ -0,0 +1,494 @@
+package intervaltree
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// -----------------------------------------------------
+// 				DERIVED TREES
+// -----------------------------------------------------
+
+// Filter returns a new IntervalTree built from the stored intervals satisfying pred, sharing the *Interval
+// pointers but with its own structure. The new tree keeps the settings of the original and the sequence numbers
+// of the kept intervals, the original is left untouched.
+// Complexity: O(n log n), n = len(intervals in struct)
+func (t *IntervalTree) Filter(pred func(*Interval) bool) *IntervalTree {
+	t = t.orEmpty()
+	var kept []*Interval
+	walk(
+		t.nodes(), func(e *elt) bool {
+			for _, in := range e.leftSorted {
+				if pred(in) {
+					kept = append(kept, in)
+				}
+			}
+			return true
+		},
+	)
+	return t.derive(kept, nil)
+}
+
+// FilterByWindow returns a new IntervalTree holding the stored intervals intersecting the window, see Filter
+// Complexity: O(ln n + k log k), n = len(intervals in struct) and k = kept intervals
+func (t *IntervalTree) FilterByWindow(window *Interval) *IntervalTree {
+	t = t.orEmpty()
+	var kept []*Interval
+	for in := range t.IntersectingSeq(window) {
+		kept = append(kept, in)
+	}
+	return t.derive(kept, nil)
+}
+
+// derive builds a new IntervalTree from intervals taken from t, with the same settings as t. The sequence numbers
+// of the intervals are kept and the new ones continue after the ones of t. origin maps the copies made from
+// intervals of t to them, if any
+func (t *IntervalTree) derive(intervals []*Interval, origin map[*Interval]*Interval) *IntervalTree {
+	if t.counts != nil {
+		return t.deriveCounted(intervals, origin)
+	}
+	d := MustNewIntervalTree(intervals, WithKeyFunc(t.keyFunc), WithAutoRebuild(t.rebuildAt), WithTieBreaker(t.tie), t.mode()) // cannot fail, they are valid
+	d.equal = t.equal
+	if t.seq != nil {
+		d.seq = make(map[*Interval]uint64, len(intervals))
+		for _, in := range intervals {
+			if seq, ok := t.seq[in]; ok {
+				d.seq[in] = seq
+			}
+		}
+		d.nextSeq = t.nextSeq
+	}
+	return d
+}
+
+// deriveCounted is derive for a tree with multiplicities: the intervals with the same endpoints are collapsed again
+// and the count of every representative is the sum of the counts in t of the intervals it stands for, a copy
+// counting as its origin
+func (t *IntervalTree) deriveCounted(intervals []*Interval, origin map[*Interval]*Interval) *IntervalTree {
+	d := MustNewIntervalTree(intervals, WithKeyFunc(t.keyFunc), WithAutoRebuild(t.rebuildAt), WithTieBreaker(t.tie), t.mode(), WithMultiplicity()) // cannot fail
+	d.equal = t.equal
+	for in := range d.counts {
+		d.counts[in] = 0
+	}
+	for _, in := range intervals {
+		src := in
+		if o, ok := origin[in]; ok {
+			src = o
+		}
+		d.counts[d.FindLike(in)[0]] += t.count(src)
+	}
+	if t.seq != nil {
+		d.seq = make(map[*Interval]uint64, d.size)
+		for in := range d.counts {
+			if seq, ok := t.seq[in]; ok {
+				d.seq[in] = seq
+			}
+		}
+		d.nextSeq = t.nextSeq
+	}
+	return d
+}
+
+// MapPayload returns a new IntervalTree holding new intervals with the same endpoints as the stored ones and the
+// payloads computed by fn. As the endpoints do not change, the structure is copied rather than built again, see
+// CloneShallow. The key index is computed again from the new payloads and the sequence numbers are kept.
+// Complexity: O(n), n = len(intervals in struct)
+func (t *IntervalTree) MapPayload(fn func(*Interval) interface{}) *IntervalTree {
+	t = t.orEmpty()
+	mapped := make(map[*Interval]*Interval, t.size)
+	m := t.clone(
+		func(in *Interval) *Interval {
+			c, ok := mapped[in]
+			if !ok {
+				c = in.copyWith(fn(in))
+				mapped[in] = c
+			}
+			return c
+		},
+	)
+	if m.keyFunc != nil {
+		m.keys = make(map[interface{}][]*Interval, len(m.keys))
+		for _, in := range m.AllSorted(BySequence) {
+			m.indexKey(in)
+		}
+	}
+	return m
+}
+
+// SplitPolicy tells Split where the intervals containing the split coordinate go
+type SplitPolicy int
+
+const (
+	// SplitAssignLeft puts the intervals containing x in the left tree
+	SplitAssignLeft SplitPolicy = iota
+	// SplitAssignRight puts the intervals containing x in the right tree
+	SplitAssignRight
+	// SplitClip puts in both trees a copy of the intervals containing x clipped to the side, [Start, x] on the left
+	// and [x, End] on the right, with the same payload and open sides. Half-open intervals are cut into [Start, x) and [x, End),
+	// the ones starting at x going whole to the right
+	SplitClip
+)
+
+// Split partitions the IntervalTree at x into two new trees: left holds the intervals ending before x, right holds
+// the ones starting after x and the intervals containing x are dispatched according to the policy. The *Interval
+// pointers are shared, except for the copies made by SplitClip, and the original tree stays valid.
+// Complexity: O(n log n), n = len(intervals in struct)
+func (t *IntervalTree) Split(x int, policy SplitPolicy) (left, right *IntervalTree) {
+	t = t.orEmpty()
+	var lefts, rights []*Interval
+	origin := make(map[*Interval]*Interval) // clipped copy to original interval
+	walk(
+		t.nodes(), func(e *elt) bool {
+			for _, in := range e.leftSorted {
+				switch {
+				case t.last(in) < x:
+					lefts = append(lefts, in)
+				case in.first() > x:
+					rights = append(rights, in)
+				case policy == SplitAssignLeft:
+					lefts = append(lefts, in)
+				case policy == SplitAssignRight, t.open == 1 && in.first() == x:
+					rights = append(rights, in)
+				default:
+					l := &Interval{Start: in.Start, End: x, StartOpen: in.StartOpen, Payload: in.Payload}
+					r := &Interval{Start: x, End: in.End, EndOpen: in.EndOpen, Payload: in.Payload}
+					origin[l], origin[r] = in, in
+					lefts, rights = append(lefts, l), append(rights, r)
+				}
+			}
+			return true
+		},
+	)
+	left, right = t.derive(lefts, origin), t.derive(rights, origin)
+	if t.seq != nil {
+		// the copies keep the sequence number of their original, the half-open ones on the left ending at x
+		for i, side := range []*IntervalTree{left, right} {
+			if i == 0 && t.open == 1 && x == math.MinInt {
+				continue // no copy, nothing ends before x
+			}
+			for _, in := range side.Containing(x - (1-i)*t.open) {
+				if seq, ok := t.seq[origin[in]]; ok {
+					side.seq[in] = seq
+				}
+			}
+		}
+	}
+	return left, right
+}
+
+// Translate shifts the IntervalTree by delta: the Start and End of every stored interval, the xMid of every node
+// and the BST points are moved in place. The relative order of all the coordinates is kept, so nothing is sorted
+// nor allocated again. It fails with ErrOverflow, leaving the tree untouched, if a coordinate would leave the int
+// range. The stored intervals are modified, which affects every other tree sharing them, except for a Snapshot:
+// the tree then works on copies of its intervals, see Freeze, and the snapshot keeps the original ones.
+// Complexity: O(n), n = len(intervals in struct)
+func (t *IntervalTree) Translate(delta int) error {
+	t = t.orEmpty()
+	if delta == 0 {
+		return nil
+	}
+	t.indexPoints() // the points are moved with the intervals
+	// the xMid of a node emptied by deletions may lie outside of the stored intervals
+	lo, hi := math.MaxInt, math.MinInt
+	walk(
+		t.nodes(), func(e *elt) bool {
+			lo, hi = minInt(lo, e.xMid), maxInt(hi, e.xMid)
+			for _, in := range e.leftSorted {
+				lo, hi = minInt(lo, in.Start), maxInt(hi, in.End)
+			}
+			return true
+		},
+	)
+	if lo <= hi && (delta > 0 && hi > math.MaxInt-delta || delta < 0 && lo < math.MinInt-delta) {
+		return fmt.Errorf("%w: translating [ %d - %d ] by %d", ErrOverflow, lo, hi, delta)
+	}
+	if t.sharesIntervals() {
+		t.Freeze()
+	}
+	moved := make(map[*Interval]bool, t.size) // an interval stored twice moves once
+	walk(
+		t.nodes(), func(e *elt) bool {
+			e.xMid += delta
+			for _, in := range e.leftSorted {
+				if !moved[in] {
+					in.Start += delta
+					in.End += delta
+					moved[in] = true
+				}
+			}
+			return true
+		},
+	)
+	for _, p := range t.pointsIn(math.MinInt, math.MaxInt) {
+		p.x += delta
+	}
+	runs := t.cover.runs()
+	for i := range runs {
+		runs[i].Start += delta
+		runs[i].End += delta
+	}
+	t.cover = runsOf(runs)   // the priorities of the runs depend on their Start
+	spans(t.nodes(), t.open) // the loose bounds may not be translatable
+	t.mutated()
+	return nil
+}
+
+// RoundingMode tells how Scale rounds the scaled coordinates to integers
+type RoundingMode int
+
+const (
+	// RoundFloor rounds toward negative infinity
+	RoundFloor RoundingMode = iota
+	// RoundCeil rounds toward positive infinity
+	RoundCeil
+	// RoundHalfUp rounds to the nearest integer, the halves toward positive infinity
+	RoundHalfUp
+)
+
+// ScaleOption configures what Scale does with the intervals reversed by a negative factor
+type ScaleOption func(*scaling)
+
+// scaling holds the settings of a Scale
+type scaling struct {
+	clamp bool // reversed intervals become a single point at their scaled Start
+	swap  bool // reversed intervals get their scaled endpoints exchanged
+}
+
+// ClampReversed makes Scale clamp the End of an interval reversed by a negative factor to its scaled Start
+func ClampReversed() ScaleOption {
+	return func(s *scaling) {
+		s.clamp = true
+	}
+}
+
+// SwapReversed makes Scale exchange the scaled endpoints of an interval reversed by a negative factor, so that it
+// covers the scaled range
+func SwapReversed() ScaleOption {
+	return func(s *scaling) {
+		s.swap = true
+	}
+}
+
+// Scale maps every coordinate x of the IntervalTree to x * num / den, rounded as asked. A positive factor keeping
+// the coordinates distinct only moves them, so the structure is updated in place; when coordinates collapse onto
+// the same value, or for a negative factor, the tree is rebuilt so that the BST points are fused again. A negative
+// factor reverses the intervals: Scale then fails with ErrReversedInterval unless ClampReversed or SwapReversed is
+// given. Half-open intervals are always rebuilt, and Scale fails with ErrEmptyInterval if one would become empty.
+// It fails with ErrInvalidScale for a zero den and with ErrOverflow if a coordinate leaves the int range,
+// leaving the tree untouched. The stored intervals are modified, which affects every other tree sharing them, except
+// for a Snapshot: the tree then works on copies of its intervals, see Freeze, and the snapshot keeps the original
+// ones.
+// Complexity: O(n log n), n = len(intervals in struct)
+func (t *IntervalTree) Scale(num, den int, rounding RoundingMode, opts ...ScaleOption) error {
+	t = t.orEmpty()
+	if den == 0 {
+		return fmt.Errorf("%w: %d / %d", ErrInvalidScale, num, den)
+	}
+	if den < 0 {
+		if den == math.MinInt || num == math.MinInt {
+			return fmt.Errorf("%w: scaling by %d / %d", ErrOverflow, num, den)
+		}
+		num, den = -num, -den
+	}
+	t.indexPoints() // the points are scaled with the intervals
+	cfg := &scaling{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	// compute everything before modifying anything
+	intervals := make(map[*Interval]Interval, t.size)
+	flagged := false // an open side makes the BST hold a point next to the endpoint
+	for _, in := range t.intervals() {
+		scaled := *in
+		var ok1, ok2 bool
+		scaled.Start, ok1 = scaleCoord(in.Start, num, den, rounding)
+		scaled.End, ok2 = scaleCoord(in.End, num, den, rounding)
+		if !ok1 || !ok2 {
+			return fmt.Errorf("%w: scaling %s by %d / %d", ErrOverflow, in, num, den)
+		}
+		if scaled.Start > scaled.End {
+			switch {
+			case cfg.swap:
+				scaled.Start, scaled.End = scaled.End, scaled.Start
+				scaled.StartOpen, scaled.EndOpen = scaled.EndOpen, scaled.StartOpen
+			case cfg.clamp:
+				if t.open == 1 && scaled.Start == math.MaxInt {
+					return fmt.Errorf("%w: scaling %s by %d / %d", ErrOverflow, in, num, den)
+				}
+				scaled.End = scaled.Start + t.open // a single point
+				scaled.StartOpen, scaled.EndOpen = false, false
+			default:
+				return fmt.Errorf("%w: scaling %s by %d / %d", ErrReversedInterval, in, num, den)
+			}
+		}
+		if vacant(&scaled, t.open) {
+			return fmt.Errorf("%w: scaling %s by %d / %d", ErrEmptyInterval, in, num, den)
+		}
+		flagged = flagged || in.StartOpen || in.EndOpen
+		intervals[in] = scaled
+	}
+	if t.sharesIntervals() {
+		// the copies are found at the same place in the nodes
+		shared := t.intervals()
+		t.Freeze()
+		copies := make(map[*Interval]Interval, len(intervals))
+		for i, in := range t.intervals() {
+			copies[in] = intervals[shared[i]]
+		}
+		intervals = copies
+	}
+	// the BST holds the last points of the half-open intervals, which do not scale as their End
+	coords, inPlace := t.scaledCoords(num, den, rounding)
+	inPlace = inPlace && t.open == 0 && !flagged
+	for in, scaled := range intervals {
+		in.Start, in.End, in.StartOpen, in.EndOpen = scaled.Start, scaled.End, scaled.StartOpen, scaled.EndOpen
+	}
+	if !inPlace {
+		t.Rebuild()
+		return nil
+	}
+	walk(
+		t.nodes(), func(e *elt) bool {
+			e.xMid = coords[e.xMid]
+			return true
+		},
+	)
+	for _, p := range t.pointsIn(math.MinInt, math.MaxInt) {
+		p.x = coords[p.x]
+	}
+	spans(t.nodes(), t.open)
+	t.cover = newCoverage(t.intervals(), t.open)
+	t.mutated()
+	return nil
+}
+
+// scaledCoords returns the scaled value of every BST point and xMid, and tells if the scaling keeps them distinct
+// and in the same order, which leaves the structure valid
+func (t *IntervalTree) scaledCoords(num, den int, rounding RoundingMode) (map[int]int, bool) {
+	if num <= 0 {
+		return nil, false
+	}
+	var xs []int
+	for _, p := range t.pointsIn(math.MinInt, math.MaxInt) {
+		xs = append(xs, p.x)
+	}
+	walk(
+		t.nodes(), func(e *elt) bool {
+			xs = append(xs, e.xMid)
+			return true
+		},
+	)
+	sort.Ints(xs)
+	coords := make(map[int]int, len(xs))
+	for i, x := range xs {
+		if i > 0 && x == xs[i-1] {
+			continue
+		}
+		scaled, ok := scaleCoord(x, num, den, rounding)
+		if !ok || i > 0 && scaled <= coords[xs[i-1]] {
+			return nil, false
+		}
+		coords[x] = scaled
+	}
+	return coords, true
+}
+
+// scaleCoord returns x * num / den rounded as asked, computed on 128 bits, false if the result does not fit in an
+// int
+// PRE: den > 0
+func scaleCoord(x, num, den int, rounding RoundingMode) (int, bool) {
+	negative := (x < 0) != (num < 0)
+	hi, lo := bits.Mul64(abs64(x), abs64(num))
+	if hi >= uint64(den) {
+		return 0, false
+	}
+	q, r := bits.Div64(hi, lo, uint64(den))
+	// round the magnitude q + r / den
+	if r > 0 {
+		switch rounding {
+		case RoundFloor:
+			if negative {
+				q++
+			}
+		case RoundCeil:
+			if !negative {
+				q++
+			}
+		case RoundHalfUp:
+			if 2*r > uint64(den) || 2*r == uint64(den) && !negative {
+				q++
+			}
+		}
+	}
+	if negative {
+		if q > 1<<63 {
+			return 0, false
+		}
+		return int(-q), true
+	}
+	if q > math.MaxInt {
+		return 0, false
+	}
+	return int(q), true
+}
+
+// abs64 returns the magnitude of x, math.MinInt included
+func abs64(x int) uint64 {
+	if x < 0 {
+		return -uint64(x)
+	}
+	return uint64(x)
+}
+
+// ClipOption configures Clip
+type ClipOption func(*clipping)
+
+// clipping holds the settings of a Clip
+type clipping struct {
+	dropTouching bool
+}
+
+// DropTouching makes Clip drop the intervals only touching the window, which would give a single point interval on
+// its boundary, comparing their points as ExcludeTouching. It has no effect on a single point window, nor on
+// back-to-back half-open intervals which do not intersect
+func DropTouching() ClipOption {
+	return func(c *clipping) {
+		c.dropTouching = true
+	}
+}
+
+// Clip returns a new IntervalTree holding, for every stored interval intersecting the window, a copy clipped to
+// [max(Start, window.Start), min(End, window.End)] with the same payload, keeping the open sides of the bounds. An
+// interval sharing a single coordinate with the window gives a single point interval, unless DropTouching is given.
+// The stored intervals are left untouched and the new tree has default settings, holding half-open intervals if the
+// IntervalTree does. A nil, reversed or empty window gives an empty tree, unless the tree was built
+// WithSwappedQueries which swaps a reversed one, the copies being clipped to the swapped window.
+// Complexity: O(ln n + k log k), n = len(intervals in struct) and k = clipped intervals
+func (t *IntervalTree) Clip(window *Interval, opts ...ClipOption) *IntervalTree {
+	t = t.orEmpty()
+	cfg := &clipping{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	window, err := t.window(window)
+	if err != nil || t.empty(window) {
+		return MustNewIntervalTree(nil, t.mode())
+	}
+	var clipped []*Interval
+	for in := range t.IntersectingSeq(window) {
+		if cfg.dropTouching && touching(in, window, t.open) {
+			continue
+		}
+		c := in.copyWith(in.Payload)
+		if window.Start > c.Start || window.Start == c.Start && window.StartOpen {
+			c.Start, c.StartOpen = window.Start, window.StartOpen
+		}
+		if window.End < c.End || window.End == c.End && window.EndOpen {
+			c.End, c.EndOpen = window.End, window.EndOpen
+		}
+		clipped = append(clipped, c)
+	}
+	return MustNewIntervalTree(clipped, t.mode()) // cannot fail, the clipped intervals are valid
+}
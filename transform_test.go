@@ -0,0 +1,384 @@
+package intervaltree
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Filter(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 1000, 1000, 100)
+	for i, in := range intervals {
+		if i%3 == 0 {
+			in.Payload = "kept"
+		} else {
+			in.Payload = i
+		}
+	}
+	tree := MustNewIntervalTree(intervals, WithSequenceNumbers())
+	isString := func(in *Interval) bool {
+		_, ok := in.Payload.(string)
+		return ok
+	}
+	var want []*Interval
+	for _, in := range intervals {
+		if isString(in) {
+			want = append(want, in)
+		}
+	}
+	filtered := tree.Filter(isString)
+	checkStructure(t, filtered)
+	checkQueries(t, rnd, filtered, want, 1000)
+	checkQueries(t, rnd, tree, intervals, 1000)
+	for _, in := range filtered.All() {
+		if seq, _ := filtered.SeqOf(in); intervals[seq] != in {
+			t.Fatalf("FILTER MUST KEEP THE SEQUENCE NUMBER OF %s", in)
+		}
+	}
+
+	window := &Interval{Start: rnd.Intn(1000), End: 0}
+	window.End = window.Start + rnd.Intn(100)
+	want = want[:0]
+	for _, in := range intervals {
+		if in.Start <= window.End && window.Start <= in.End {
+			want = append(want, in)
+		}
+	}
+	byWindow := tree.FilterByWindow(window)
+	checkStructure(t, byWindow)
+	checkQueries(t, rnd, byWindow, want, 1000)
+	if empty := tree.Filter(func(*Interval) bool { return false }); !empty.IsEmpty() || len(empty.Containing(0)) != 0 {
+		t.Fatalf("EXPECTING AN EMPTY TREE")
+	}
+}
+
+func TestIntervalTree_MapPayload(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 1000, 1000, 100)
+	for i, in := range intervals {
+		in.Payload = i
+	}
+	tree := MustNewIntervalTree(intervals, WithSequenceNumbers(), WithKeyFunc(func(in *Interval) interface{} { return in.Payload }))
+	mapped := tree.MapPayload(func(in *Interval) interface{} { return in.Payload.(int) % 10 })
+	checkStructure(t, mapped)
+	checkQueries(t, rnd, mapped, intervals, 1000)
+	for _, in := range mapped.All() {
+		seq, _ := mapped.SeqOf(in)
+		original := intervals[seq]
+		if in == original || in.Start != original.Start || in.End != original.End || in.Payload != original.Payload.(int)%10 {
+			t.Fatalf("EXPECTING A NEW COPY OF %s WITH PAYLOAD %d, GOT %s (%v)", original, original.Payload.(int)%10, in, in.Payload)
+		}
+	}
+	if got := len(mapped.FindByKey(3)); got != 100 {
+		t.Fatalf("EXPECTING 100 INTERVALS WITH KEY 3, GOT %d", got)
+	}
+	for i, in := range intervals {
+		if in.Payload != i {
+			t.Fatalf("MAPPAYLOAD MUST NOT MODIFY THE ORIGINAL INTERVALS")
+		}
+	}
+}
+
+func BenchmarkMapPayload(b *testing.B) {
+	tree := MustNewIntervalTree(randomIntervals(rand.New(rand.NewSource(42)), 100_000, 1_000_000, 1000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.MapPayload(func(in *Interval) interface{} { return in.Start })
+	}
+}
+
+func BenchmarkMapPayload_Rebuild(b *testing.B) {
+	tree := MustNewIntervalTree(randomIntervals(rand.New(rand.NewSource(42)), 100_000, 1_000_000, 1000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var mapped []*Interval
+		for _, in := range tree.All() {
+			mapped = append(mapped, &Interval{Start: in.Start, End: in.End, Payload: in.Start})
+		}
+		MustNewIntervalTree(mapped)
+	}
+}
+
+func TestIntervalTree_Split(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 1000, 1000, 100)
+	tree := MustNewIntervalTree(intervals, WithSequenceNumbers())
+	x := rnd.Intn(1000)
+	for _, policy := range []SplitPolicy{SplitAssignLeft, SplitAssignRight, SplitClip} {
+		left, right := tree.Split(x, policy)
+		checkStructure(t, left)
+		checkStructure(t, right)
+		checkQueries(t, rnd, tree, intervals, 1000)
+		for _, side := range []*IntervalTree{left, right} {
+			for _, in := range side.All() {
+				if _, ok := side.SeqOf(in); !ok {
+					t.Fatalf("%s HAS NO SEQUENCE NUMBER", in)
+				}
+			}
+		}
+		// away from x, the halves answer as the original
+		for q := 0; q < 100; q++ {
+			y := rnd.Intn(1100) - 50
+			if y == x {
+				continue
+			}
+			want := len(tree.Containing(y))
+			if got := len(left.Containing(y)) + len(right.Containing(y)); got != want {
+				t.Fatalf("CONTAINING(%d) AFTER SPLITTING AT %d: EXPECTING %d VALUES, GOT %d", y, x, want, got)
+			}
+			if policy == SplitClip && (y < x && len(right.Containing(y)) != 0 || y > x && len(left.Containing(y)) != 0) {
+				t.Fatalf("AN INTERVAL CONTAINING %d IS ON THE WRONG SIDE OF %d", y, x)
+			}
+		}
+		// at x, the policy is honored
+		atX := len(tree.Containing(x))
+		l, r := len(left.Containing(x)), len(right.Containing(x))
+		switch policy {
+		case SplitAssignLeft:
+			if l != atX || r != 0 {
+				t.Fatalf("SPLITASSIGNLEFT: EXPECTING %d VALUES ON THE LEFT AND 0 ON THE RIGHT, GOT %d AND %d", atX, l, r)
+			}
+		case SplitAssignRight:
+			if l != 0 || r != atX {
+				t.Fatalf("SPLITASSIGNRIGHT: EXPECTING 0 VALUES ON THE LEFT AND %d ON THE RIGHT, GOT %d AND %d", atX, l, r)
+			}
+		case SplitClip:
+			if l != atX || r != atX {
+				t.Fatalf("SPLITCLIP: EXPECTING %d VALUES ON BOTH SIDES, GOT %d AND %d", atX, l, r)
+			}
+			if end, _ := left.MaxEnd(); left.Len() > 0 && end > x {
+				t.Fatalf("SPLITCLIP: THE LEFT TREE ENDS AT %d, AFTER %d", end, x)
+			}
+			if start, _ := right.MinStart(); right.Len() > 0 && start < x {
+				t.Fatalf("SPLITCLIP: THE RIGHT TREE STARTS AT %d, BEFORE %d", start, x)
+			}
+		}
+	}
+}
+
+func TestIntervalTree_Translate(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 1000, 1000, 100)
+	single := &Interval{Start: 500, End: 500}
+	intervals = append(intervals, single)
+	tree := MustNewIntervalTree(intervals)
+	before := make([][]*Interval, 1000)
+	for x := range before {
+		before[x] = tree.Containing(x)
+	}
+	delta := rnd.Intn(10_000) - 5000
+	if err := tree.Translate(delta); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	checkStructure(t, tree)
+	checkQueries(t, rnd, tree, intervals, 1000)
+	if single.Start != 500+delta || single.End != 500+delta {
+		t.Fatalf("EXPECTING [ %d - %d ], GOT %s", 500+delta, 500+delta, single)
+	}
+	for x := range before {
+		got := tree.Containing(x + delta)
+		if len(got) != len(before[x]) {
+			t.Fatalf("CONTAINING(%d) AFTER TRANSLATING BY %d: EXPECTING %d VALUES, GOT %d", x+delta, delta, len(before[x]), len(got))
+		}
+	}
+	if start, _ := tree.MinStart(); len(tree.Containing(start-1)) != 0 || len(tree.Containing(start)) == 0 {
+		t.Fatalf("THE COVERAGE MUST BE TRANSLATED")
+	}
+
+	// overflows are rejected and leave the tree untouched
+	end, _ := tree.MaxEnd()
+	if err := tree.Translate(math.MaxInt - end + 1); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("EXPECTING ErrOverflow, GOT %v", err)
+	}
+	_ = tree.Translate(-20_000) // every coordinate is now negative
+	if err := tree.Translate(math.MinInt); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("EXPECTING ErrOverflow, GOT %v", err)
+	}
+	_ = tree.Translate(20_000)
+	checkQueries(t, rnd, tree, intervals, 1000)
+	start, _ := tree.MinStart()
+	_ = tree.Translate(-start)
+	end, _ = tree.MaxEnd()
+	if err := tree.Translate(math.MaxInt - end); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	if got, _ := tree.MaxEnd(); got != math.MaxInt {
+		t.Fatalf("EXPECTING THE LAST END AT math.MaxInt, GOT %d", got)
+	}
+	checkStructure(t, tree)
+	if err := MustNewIntervalTree(nil).Translate(math.MaxInt); err != nil {
+		t.Fatalf("AN EMPTY TREE CANNOT OVERFLOW, GOT %v", err)
+	}
+}
+
+// bruteScale returns x * num / den rounded as asked
+// PRE: den > 0
+func bruteScale(x, num, den int, rounding RoundingMode) int {
+	v := float64(x*num) / float64(den)
+	switch rounding {
+	case RoundFloor:
+		return int(math.Floor(v))
+	case RoundCeil:
+		return int(math.Ceil(v))
+	default:
+		return int(math.Floor(v + 0.5))
+	}
+}
+
+func TestIntervalTree_Scale(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, rounding := range []RoundingMode{RoundFloor, RoundCeil, RoundHalfUp} {
+		for _, factor := range [][2]int{{1, 1}, {3, 1}, {1, 10}, {7, 3}, {-1, 2}, {2, -5}} {
+			intervals := randomIntervals(rnd, 500, 1000, 50)
+			for _, in := range intervals {
+				in.Start -= 500
+				in.End -= 500
+			}
+			want := make([]*Interval, len(intervals))
+			for i, in := range intervals {
+				num, den := factor[0], factor[1]
+				if den < 0 {
+					num, den = -num, -den
+				}
+				start, end := bruteScale(in.Start, num, den, rounding), bruteScale(in.End, num, den, rounding)
+				if start > end {
+					start, end = end, start
+				}
+				want[i] = &Interval{Start: start, End: end}
+			}
+			tree := MustNewIntervalTree(intervals, WithSequenceNumbers())
+			if factor[0]*factor[1] < 0 {
+				if err := tree.Scale(factor[0], factor[1], rounding); !errors.Is(err, ErrReversedInterval) {
+					t.Fatalf("EXPECTING ErrReversedInterval FOR A NEGATIVE FACTOR, GOT %v", err)
+				}
+				checkQueries(t, rnd, tree, intervals, 500)
+			}
+			if err := tree.Scale(factor[0], factor[1], rounding, SwapReversed()); err != nil {
+				t.Fatalf("UNEXPECTED ERROR %v", err)
+			}
+			checkStructure(t, tree)
+			for i, in := range intervals {
+				if in.Start != want[i].Start || in.End != want[i].End {
+					t.Fatalf("SCALING BY %d / %d (%d): EXPECTING %s, GOT %s", factor[0], factor[1], rounding, want[i], in)
+				}
+			}
+			checkQueries(t, rnd, tree, intervals, 1000)
+			if seq, _ := tree.SeqOf(intervals[10]); seq != 10 {
+				t.Fatalf("SCALE MUST KEEP THE SEQUENCE NUMBERS")
+			}
+		}
+	}
+
+	// the endpoints collapse onto 0 and 1
+	intervals := []*Interval{{Start: 0, End: 1}, {Start: 2, End: 3}, {Start: 4, End: 9}, {Start: 10, End: 14}, {Start: 7, End: 7}}
+	tree := MustNewIntervalTree(intervals)
+	if err := tree.Scale(1, 10, RoundFloor); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	checkStructure(t, tree)
+	if tree.points().size() != 2 || len(tree.Containing(0)) != 4 || len(tree.Containing(1)) != 1 {
+		t.Fatalf("EXPECTING THE POINTS 0 AND 1, GOT %s", tree.Stats())
+	}
+	if tree.TotalCoveredLength() != 2 {
+		t.Fatalf("EXPECTING 2 COVERED, GOT %d", tree.TotalCoveredLength())
+	}
+	if err := tree.Scale(-3, 1, RoundFloor, ClampReversed()); err != nil {
+		t.Fatalf("UNEXPECTED ERROR %v", err)
+	}
+	if in := intervals[3]; in.Start != -3 || in.End != -3 {
+		t.Fatalf("EXPECTING [ -3 - -3 ], GOT %s", in)
+	}
+
+	// errors leave the tree untouched
+	tree = MustNewIntervalTree([]*Interval{{Start: -10, End: 10}, {Start: math.MaxInt / 2, End: math.MaxInt/2 + 1}})
+	for _, factor := range [][2]int{{1, 0}, {3, 1}, {math.MinInt, -1}} {
+		if err := tree.Scale(factor[0], factor[1], RoundFloor); err == nil {
+			t.Fatalf("EXPECTING AN ERROR SCALING BY %d / %d", factor[0], factor[1])
+		}
+	}
+	if !tree.Has(-10, 10) || !tree.Has(math.MaxInt/2, math.MaxInt/2+1) {
+		t.Fatalf("A FAILED SCALE MUST NOT MODIFY THE TREE")
+	}
+	if got, ok := scaleCoord(math.MinInt, 1, 1, RoundFloor); !ok || got != math.MinInt {
+		t.Fatalf("EXPECTING math.MinInt, GOT %d (%t)", got, ok)
+	}
+	if got, ok := scaleCoord(math.MaxInt, 3, 3, RoundCeil); !ok || got != math.MaxInt {
+		t.Fatalf("EXPECTING math.MaxInt, GOT %d (%t)", got, ok)
+	}
+}
+
+func TestIntervalTree_Clip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 1000, 1000, 100)
+	for i, in := range intervals {
+		in.Payload = i
+	}
+	tree := MustNewIntervalTree(intervals)
+	window := &Interval{Start: rnd.Intn(800), End: 0}
+	window.End = window.Start + rnd.Intn(200)
+	// touching the window on both sides
+	for _, in := range []*Interval{{Start: window.Start - 10, End: window.Start}, {Start: window.End, End: window.End + 5}} {
+		intervals = append(intervals, in)
+		_ = tree.Insert(in)
+	}
+	var want, touching []*Interval
+	for _, in := range intervals {
+		if in.Start <= window.End && window.Start <= in.End {
+			c := &Interval{Start: maxInt(in.Start, window.Start), End: minInt(in.End, window.End)}
+			want = append(want, c)
+			if window.Start < window.End && (in.End == window.Start || in.Start == window.End) {
+				touching = append(touching, c)
+			}
+		}
+	}
+	clipped := tree.Clip(window)
+	checkStructure(t, clipped)
+	checkQueries(t, rnd, clipped, want, 1000)
+	for _, in := range clipped.All() {
+		if in.Start < window.Start || in.End > window.End {
+			t.Fatalf("%s IS NOT CLIPPED TO %s", in, window)
+		}
+		if in.Payload != nil {
+			original := intervals[in.Payload.(int)]
+			if original == in || original.Start > in.Start || original.End < in.End {
+				t.Fatalf("EXPECTING A CLIPPED COPY OF %s, GOT %s", original, in)
+			}
+		}
+	}
+	// the originals are untouched
+	checkQueries(t, rnd, tree, intervals, 1000)
+
+	dropped := tree.Clip(window, DropTouching())
+	checkStructure(t, dropped)
+	if dropped.Len() != len(want)-len(touching) {
+		t.Fatalf("EXPECTING %d INTERVALS WITHOUT THE TOUCHING ONES, GOT %d", len(want)-len(touching), dropped.Len())
+	}
+	// the open sides are compared by their points
+	flagged := MustNewIntervalTree([]*Interval{{Start: 1, End: 6, EndOpen: true}, {Start: 1, End: 5, EndOpen: true}})
+	if got := flagged.Clip(&Interval{Start: 5, End: 9}, DropTouching()); got.Len() != 0 {
+		t.Fatalf("[1, 6) ONLY TOUCHES [5, 9] AT 5, GOT %v", got.All())
+	}
+	if got := flagged.Clip(&Interval{Start: 3, End: 9}, DropTouching()); got.Len() != 2 {
+		t.Fatalf("EXPECTING BOTH INTERVALS TO OVERLAP [3, 9], GOT %v", got.All())
+	}
+
+	// a nil or reversed window clips nothing, unless the reversed one is swapped
+	split := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 20, End: 30}})
+	swapped := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 20, End: 30}}, WithSwappedQueries())
+	for _, window := range []*Interval{nil, {Start: 9, End: 1}, {Start: 25, End: 3}} {
+		if got := split.Clip(window); got.Len() != 0 {
+			t.Fatalf("EXPECTING NOTHING CLIPPED TO %v, GOT %v", window, got.All())
+		}
+	}
+	if got := swapped.Clip(nil); got.Len() != 0 {
+		t.Fatalf("EXPECTING NOTHING CLIPPED TO A NIL WINDOW, GOT %v", got.All())
+	}
+	got := swapped.Clip(&Interval{Start: 25, End: 3, StartOpen: true})
+	checkStructure(t, got)
+	if all := got.All(); len(all) != 2 || *all[0] != (Interval{Start: 3, End: 5}) || *all[1] != (Interval{Start: 20, End: 25, EndOpen: true}) {
+		t.Fatalf("EXPECTING [3, 5] AND [20, 25) CLIPPED TO THE SWAPPED WINDOW, GOT %v", all)
+	}
+}
@@ -0,0 +1,283 @@
+package intervaltree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// -----------------------------------------------------
+// 				STRUCTURE VALIDATION
+// -----------------------------------------------------
+
+// Validate checks the structural invariants of the IntervalTree and returns every violation found, joined with
+// errors.Join and each wrapping ErrBrokenInvariant, or nil if the tree is well-formed. It checks that:
+//   - every node sorts its intervals by lessStart in leftSorted and their positions by lessEnd in byEnd, each
+//     position once, ties ordered by WithTieBreaker if given, and only an inner node may be empty
+//   - every interval of a node holds its xMid, and the intervals and nodes of its left and right subtrees lie
+//     strictly before and after it
+//   - the bounds of every node hold its intervals and the bounds of its children
+//   - every stored interval is referenced by the BST Points of its first and last points, and the Points reference
+//     no other interval
+//   - the number of intervals, the sorted slice of a small tree and the merged coverage match the stored intervals
+//
+// It is meant for tests and fuzzing, after a series of Insert and Delete for instance.
+// Complexity: O(n log n), n = len(intervals in struct)
+func (t *IntervalTree) Validate() error {
+	t = t.orEmpty()
+	var errs []error
+	violation := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrBrokenInvariant, fmt.Sprintf(format, args...)))
+	}
+	stored := t.validateNodes(violation)
+	if len(stored) != t.size {
+		violation("%d intervals stored in the nodes, Len is %d", len(stored), t.size)
+	}
+	t.validatePoints(stored, violation)
+	if t.small != nil {
+		if len(t.small) != t.size {
+			violation("%d intervals in the small tree, Len is %d", len(t.small), t.size)
+		}
+		for i := 1; i < len(t.small); i++ {
+			if startOrder(t.small[i], t.small[i-1], t.tie) {
+				violation("small tree: %s sorted after %s", t.small[i], t.small[i-1])
+			}
+		}
+	}
+	intervals := make([]*Interval, 0, len(stored))
+	for in := range stored {
+		intervals = append(intervals, in)
+	}
+	want := newCoverage(intervals, t.open)
+	runs, wantRuns := t.cover.runs(), want.runs()
+	if t.cover.total != want.total || len(runs) != len(wantRuns) || t.cover.count != len(runs) {
+		violation(
+			"coverage of %d runs (%d counted) and %d coordinates, expecting %d and %d",
+			len(runs), t.cover.count, t.cover.total, len(wantRuns), want.total,
+		)
+	} else {
+		for i, r := range runs {
+			if r.Start != wantRuns[i].Start || r.End != wantRuns[i].End {
+				violation("coverage run %s, expecting %s", &r, &wantRuns[i])
+			}
+		}
+	}
+	t.cover.top.all(
+		func(r *runNode) {
+			for _, child := range []*runNode{r.left, r.right} {
+				if child != nil && child.priority() > r.priority() {
+					violation("coverage run [%d, %d] above the run [%d, %d] of higher priority", r.start, r.end, child.start, child.end)
+				}
+			}
+		},
+	)
+	return errors.Join(errs...)
+}
+
+// validateNodes checks the nodes of the tree, see Validate, and returns the stored intervals. The traversal is
+// iterative so it does not depend on the depth of the tree
+func (t *IntervalTree) validateNodes(violation func(format string, args ...interface{})) map[*Interval]bool {
+	type frame struct {
+		itr          *iterator
+		lower, upper int // points the intervals and the xMid of the subtree must lie in
+	}
+	stored := make(map[*Interval]bool, t.size)
+	stack := []frame{{t.nodes().root(), math.MinInt, math.MaxInt}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if f.itr.isBottom() {
+			continue
+		}
+		e := f.itr.consult()
+		if e.xMid < f.lower || e.xMid > f.upper {
+			violation("node at %d out of its subtree [%d, %d]", e.xMid, f.lower, f.upper)
+		}
+		if len(e.leftSorted) != len(e.byEnd) {
+			violation("node at %d: %d intervals by start and %d by end", e.xMid, len(e.leftSorted), len(e.byEnd))
+		}
+		if len(e.leftSorted) == 0 && f.itr.isLeaf() {
+			violation("node at %d: empty leaf", e.xMid)
+		}
+		for _, c := range [...]*elt{e.left, e.right} {
+			if c != nil && c.lo <= c.hi && (c.lo < e.lo || c.hi > e.hi) {
+				violation("node at %d: bounds [%d, %d] out of the ones of its parent [%d, %d]", c.xMid, c.lo, c.hi, e.lo, e.hi)
+			}
+		}
+		inNode := make(map[*Interval]bool, len(e.leftSorted))
+		for i, in := range e.leftSorted {
+			if stored[in] || inNode[in] {
+				violation("node at %d: %s stored twice", e.xMid, in)
+			}
+			inNode[in] = true
+			if in.first() > e.xMid || t.last(in) < e.xMid {
+				violation("node at %d: %s does not hold it", e.xMid, in)
+			}
+			if in.first() < e.lo || t.last(in) > e.hi {
+				violation("node at %d: %s out of its bounds [%d, %d]", e.xMid, in, e.lo, e.hi)
+			}
+			if in.first() < f.lower || t.last(in) > f.upper {
+				violation("node at %d: %s out of its subtree [%d, %d]", e.xMid, in, f.lower, f.upper)
+			}
+			if i > 0 && startOrder(in, e.leftSorted[i-1], t.tie) {
+				violation("node at %d: %s sorted by start after %s", e.xMid, in, e.leftSorted[i-1])
+			}
+		}
+		listed := make([]bool, len(e.leftSorted))
+		var previous *Interval
+		for _, pos := range e.byEnd {
+			if pos < 0 || int(pos) >= len(e.leftSorted) || listed[pos] {
+				violation("node at %d: position %d out of range or sorted by end twice", e.xMid, pos)
+				continue
+			}
+			listed[pos] = true
+			in := e.leftSorted[pos]
+			if previous != nil && endOrder(in, previous, t.tie) {
+				violation("node at %d: %s sorted by end after %s", e.xMid, in, previous)
+			}
+			previous = in
+		}
+		for in := range inNode {
+			stored[in] = true
+		}
+		// nothing can lie before math.MinInt nor after math.MaxInt
+		if e.xMid > math.MinInt {
+			stack = append(stack, frame{f.itr.left(), f.lower, e.xMid - 1})
+		} else if !f.itr.left().isBottom() {
+			violation("node at %d: left subtree before math.MinInt", e.xMid)
+		}
+		if e.xMid < math.MaxInt {
+			stack = append(stack, frame{f.itr.right(), e.xMid + 1, f.upper})
+		} else if !f.itr.right().isBottom() {
+			violation("node at %d: right subtree after math.MaxInt", e.xMid)
+		}
+	}
+	return stored
+}
+
+// validatePoints checks that the BST Points reference every stored interval at its first and last points and no
+// other interval, see Validate
+func (t *IntervalTree) validatePoints(stored map[*Interval]bool, violation func(format string, args ...interface{})) {
+	firstRefs, lastRefs := make(map[*Interval]int, len(stored)), make(map[*Interval]int, len(stored))
+	points := t.pointsIn(math.MinInt, math.MaxInt)
+	for i, p := range points {
+		if i > 0 && points[i-1].x == p.x {
+			violation("point %d stored twice", p.x)
+		}
+		if len(p.ptrs) == 0 {
+			violation("point %d references no interval", p.x)
+		}
+		for _, in := range p.ptrs {
+			switch {
+			case !stored[in]:
+				violation("point %d references %s, not stored in the nodes", p.x, in)
+			case in.first() == p.x && firstRefs[in] == 0:
+				firstRefs[in]++ // a single point interval is referenced twice by the same point
+			case t.last(in) == p.x:
+				lastRefs[in]++
+			default:
+				violation("point %d references %s again or not at an endpoint", p.x, in)
+			}
+		}
+	}
+	intervals := make([]*Interval, 0, len(stored))
+	for in := range stored {
+		if firstRefs[in] != 1 || lastRefs[in] != 1 {
+			intervals = append(intervals, in)
+		}
+	}
+	sort.Slice(
+		intervals, func(i, j int) bool {
+			return intervals[i].lessStart(intervals[j])
+		},
+	)
+	for _, in := range intervals {
+		violation("%s referenced %d times at its first point and %d at its last", in, firstRefs[in], lastRefs[in])
+	}
+}
+
+// CheckIntegrity tells if the stored intervals still have the endpoints they were indexed with. The IntervalTree
+// stores the given pointers, so modifying the Start, the End or a boundary flag of a stored interval corrupts the
+// results of the queries: it returns an error wrapping ErrModifiedInterval for every interval whose first or last
+// point is no longer the one recorded in the BST, joined with errors.Join, or nil. A small tree, not indexed until
+// a method needs it, is only checked against its order by Start and its coverage, which misses the modifications
+// keeping them. Likewise, a tree whose BST is not built yet, see WithEagerIndex, is only checked against the
+// medians and the orders of its nodes and its coverage. WithIntegrityChecks indexes the tree at construction and
+// runs the check before every query. Rebuild indexes the intervals again with their current endpoints.
+// Complexity: O(n log n), n = len(intervals in struct)
+func (t *IntervalTree) CheckIntegrity() error {
+	t = t.orEmpty()
+	var errs []error
+	if t.small != nil && t.tree == nil {
+		for i := 1; i < len(t.small); i++ {
+			if startOrder(t.small[i], t.small[i-1], t.tie) {
+				errs = append(errs, fmt.Errorf("%w: %s sorted after %s", ErrModifiedInterval, t.small[i], t.small[i-1]))
+			}
+		}
+		if !t.cover.equal(newCoverage(t.small, t.open)) {
+			errs = append(errs, fmt.Errorf("%w: the coverage of the intervals changed", ErrModifiedInterval))
+		}
+		return errors.Join(errs...)
+	}
+	if t.bst == nil {
+		return t.checkNodes()
+	}
+	// the points are sorted, so the first one referencing an interval is its first point and the last one its last
+	first, last := make(map[*Interval]int, t.size), make(map[*Interval]int, t.size)
+	for _, p := range t.pointsIn(math.MinInt, math.MaxInt) {
+		for _, in := range p.ptrs {
+			if _, ok := first[in]; !ok {
+				first[in] = p.x
+			}
+			last[in] = p.x
+		}
+	}
+	for _, in := range t.intervals() {
+		if first[in] != in.first() || last[in] != t.last(in) {
+			errs = append(
+				errs, fmt.Errorf("%w: %s indexed with the points %d to %d", ErrModifiedInterval, in, first[in], last[in]),
+			)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkNodes tells if the intervals of the nodes still hold the xMid of their node, in the orders of the node, and
+// give the recorded coverage, see CheckIntegrity
+func (t *IntervalTree) checkNodes() error {
+	var errs []error
+	walk(
+		t.nodes(), func(e *elt) bool {
+			for i, in := range e.leftSorted {
+				if in.first() > e.xMid || t.last(in) < e.xMid {
+					errs = append(errs, fmt.Errorf("%w: %s no longer holds its median %d", ErrModifiedInterval, in, e.xMid))
+				}
+				if i > 0 && startOrder(in, e.leftSorted[i-1], t.tie) {
+					errs = append(errs, fmt.Errorf("%w: %s sorted after %s", ErrModifiedInterval, in, e.leftSorted[i-1]))
+				}
+			}
+			for k := 1; k < len(e.byEnd); k++ {
+				if endOrder(e.endAt(k), e.endAt(k-1), t.tie) {
+					errs = append(errs, fmt.Errorf("%w: %s sorted by end after %s", ErrModifiedInterval, e.endAt(k), e.endAt(k-1)))
+				}
+			}
+			return true
+		},
+	)
+	if !t.cover.equal(newCoverage(t.intervals(), t.open)) {
+		errs = append(errs, fmt.Errorf("%w: the coverage of the intervals changed", ErrModifiedInterval))
+	}
+	return errors.Join(errs...)
+}
+
+// checkIntegrity panics with an invariantPanic wrapping the error of CheckIntegrity if the tree was built
+// WithIntegrityChecks and a stored interval was modified
+func (t *IntervalTree) checkIntegrity() {
+	if !t.checked {
+		return
+	}
+	if err := t.CheckIntegrity(); err != nil {
+		panic(invariantPanic{fmt.Errorf("%w: %w", ErrBrokenInvariant, err)})
+	}
+}
@@ -0,0 +1,154 @@
+package intervaltree
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_Validate(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 1000, 1000, 100)
+	for _, tree := range treesOf(t, intervals) {
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("UNEXPECTED VIOLATIONS: %v", err)
+		}
+		for i := 0; i < 200; i++ {
+			if in := randomIntervals(rnd, 1, 1000, 100)[0]; i%2 == 0 {
+				tree.Insert(in)
+			} else {
+				tree.Delete(tree.All()[rnd.Intn(tree.Len())])
+			}
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("UNEXPECTED VIOLATIONS AFTER MUTATIONS: %v", err)
+		}
+	}
+	if err := MustNewIntervalTree(nil).Validate(); err != nil {
+		t.Fatalf("UNEXPECTED VIOLATIONS IN AN EMPTY TREE: %v", err)
+	}
+
+	corruptions := map[string]struct {
+		corrupt func(tree *IntervalTree, e *elt)
+		want    []string // parts of the expected violations
+	}{
+		"UNBALANCED LISTS": {
+			func(tree *IntervalTree, e *elt) { e.byEnd = e.byEnd[1:] },
+			[]string{"intervals by start and"},
+		},
+		"UNSORTED LISTS": {
+			func(tree *IntervalTree, e *elt) {
+				// the positions by end follow the swapped intervals, so that each order is broken on its own
+				last := len(e.leftSorted) - 1
+				e.leftSorted[0], e.leftSorted[last] = e.leftSorted[last], e.leftSorted[0]
+				for k, pos := range e.byEnd {
+					switch pos {
+					case 0:
+						e.byEnd[k] = int32(last)
+					case int32(last):
+						e.byEnd[k] = 0
+					}
+				}
+				e.byEnd[0], e.byEnd[last] = e.byEnd[last], e.byEnd[0]
+			},
+			[]string{"sorted by start after", "sorted by end after"},
+		},
+		"MOVED INTERVAL": {
+			// before every endpoint, randomIntervals starting at 0
+			func(tree *IntervalTree, e *elt) { e.leftSorted[0].Start, e.leftSorted[0].End = -1, -1 },
+			[]string{"does not hold it", "referenced 0 times"},
+		},
+		"REPEATED POSITION": {
+			func(tree *IntervalTree, e *elt) { e.byEnd[0] = e.byEnd[1] },
+			[]string{"sorted by end twice"},
+		},
+		"LOST INTERVAL": {
+			func(tree *IntervalTree, e *elt) {
+				last := e.leftSorted[len(e.leftSorted)-1]
+				e.retain(func(in *Interval) bool { return in != last })
+			},
+			[]string{"not stored in the nodes", "Len is"},
+		},
+		"STALE COVERAGE": {
+			func(tree *IntervalTree, e *elt) { tree.cover = &coverage{} },
+			[]string{"coverage of 0 runs"},
+		},
+	}
+	for name, c := range corruptions {
+		intervals := randomIntervals(rnd, 1000, 1000, 100)
+		tree := MustNewIntervalTree(intervals, WithSmallThreshold(0), WithEagerIndex())
+		e := tree.nodes().root().consult()
+		if last := len(e.leftSorted) - 1; last < 1 || e.leftSorted[0].Start == e.leftSorted[last].Start || e.endAt(0).End == e.endAt(last).End {
+			continue // the corruptions need the first and last intervals of both orders to differ
+		}
+		c.corrupt(tree, e)
+		err := tree.Validate()
+		if !errors.Is(err, ErrBrokenInvariant) {
+			t.Fatalf("%s: EXPECTING ErrBrokenInvariant, GOT %v", name, err)
+		}
+		for _, want := range c.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Fatalf("%s: EXPECTING A VIOLATION WITH %q, GOT %v", name, want, err)
+			}
+		}
+	}
+}
+
+func TestIntervalTree_CheckIntegrity(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 1000, 1000, 100)
+	trees := append(treesOf(t, intervals, WithEagerIndex()), MustNewIntervalTree(intervals, WithIntegrityChecks()))
+	for _, tree := range trees {
+		if err := tree.CheckIntegrity(); err != nil {
+			t.Fatalf("UNEXPECTED MODIFICATION: %v", err)
+		}
+		for i := 0; i < 20; i++ {
+			in := tree.All()[rnd.Intn(tree.Len())]
+			start, end := in.Start, in.End
+			if in.End += 1 + rnd.Intn(10); i%2 == 0 {
+				in.Start, in.End = start-1-rnd.Intn(10), end
+			}
+			err := tree.CheckIntegrity()
+			if !errors.Is(err, ErrModifiedInterval) || !strings.Contains(err.Error(), in.String()) {
+				t.Fatalf("THE MODIFICATION OF %s MUST BE DETECTED, GOT %v", in, err)
+			}
+			if _, err := tree.IntersectingE(in); tree.checked != errors.Is(err, ErrModifiedInterval) {
+				t.Fatalf("QUERIES MUST ONLY FAIL WITH INTEGRITY CHECKS, GOT %v", err)
+			}
+			in.Start, in.End = start, end
+			if err := tree.CheckIntegrity(); err != nil {
+				t.Fatalf("UNEXPECTED MODIFICATION AFTER RESTORING %s: %v", in, err)
+			}
+		}
+		// a rebuild indexes the intervals with their new endpoints, the other trees sharing them are left as built
+		in := tree.All()[0]
+		in.End++
+		tree.Rebuild()
+		if err := tree.CheckIntegrity(); err != nil {
+			t.Fatalf("UNEXPECTED MODIFICATION AFTER REBUILD: %v", err)
+		}
+		in.End--
+	}
+
+	// a small tree is only checked against its order and its coverage
+	small := randomIntervals(rnd, 10, 1000, 100)
+	tree := MustNewIntervalTree(small)
+	moved := tree.small[0]
+	moved.Start, moved.End = moved.Start+2000, moved.End+2000
+	if err := tree.CheckIntegrity(); !errors.Is(err, ErrModifiedInterval) {
+		t.Fatalf("MOVING %s MUST BE DETECTED IN A SMALL TREE, GOT %v", moved, err)
+	}
+	checked := MustNewIntervalTree(randomIntervals(rnd, 10, 1000, 100), WithIntegrityChecks())
+	checked.All()[0].End += 5
+	if _, err := checked.ContainingE(0); !errors.Is(err, ErrModifiedInterval) || !errors.Is(err, ErrBrokenInvariant) {
+		t.Fatalf("CONTAININGE MUST FAIL ON A MODIFIED INTERVAL, GOT %v", err)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("CONTAINING MUST PANIC ON A MODIFIED INTERVAL")
+		}
+	}()
+	checked.Containing(0)
+}
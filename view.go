@@ -0,0 +1,81 @@
+package intervaltree
+
+import (
+	"fmt"
+	"iter"
+)
+
+// -----------------------------------------------------
+// 				NODE VIEWS
+// -----------------------------------------------------
+
+// NodeView gives read access to the intervals stored in one node of the IntervalTree without copying them.
+// A view is only valid until the next mutation of the tree, using it afterwards panics
+type NodeView struct {
+	tree  *IntervalTree
+	e     *elt
+	epoch uint64
+}
+
+// WalkViews calls fn with a view of every node of the IntervalTree in ascending order of XMid, stops as soon as fn
+// returns false
+func (t *IntervalTree) WalkViews(fn func(NodeView) bool) {
+	t = t.orEmpty()
+	walk(
+		t.nodes(), func(e *elt) bool {
+			return fn(NodeView{tree: t, e: e, epoch: t.epoch})
+		},
+	)
+}
+
+// check panics if the tree has been mutated since the view was created
+func (v NodeView) check() {
+	if v.tree.epoch != v.epoch {
+		panic("intervaltree: NodeView used after the IntervalTree was mutated")
+	}
+}
+
+// XMid returns the median point of the node, contained by all its intervals
+func (v NodeView) XMid() int {
+	v.check()
+	return v.e.xMid
+}
+
+// Len returns the number of intervals stored in the node
+func (v NodeView) Len() int {
+	v.check()
+	return len(v.e.leftSorted)
+}
+
+// At returns the i-th interval of the node in ascending order of Start
+// PRE: 0 <= i < Len(), panics otherwise
+func (v NodeView) At(i int) *Interval {
+	v.check()
+	if i < 0 || i >= len(v.e.leftSorted) {
+		panic(fmt.Sprintf("intervaltree: NodeView index %d out of range [0, %d)", i, len(v.e.leftSorted)))
+	}
+	return v.e.leftSorted[i]
+}
+
+// AscendingStarts returns an iterator over the intervals of the node in ascending order of Start
+func (v NodeView) AscendingStarts() iter.Seq[*Interval] {
+	return v.iterate(func(k int) *Interval { return v.e.leftSorted[k] })
+}
+
+// DescendingEnds returns an iterator over the intervals of the node in descending order of End
+func (v NodeView) DescendingEnds() iter.Seq[*Interval] {
+	return v.iterate(v.e.endAt)
+}
+
+// iterate returns an iterator over the intervals of the node in the order of at, checking the view is still valid
+// at every step
+func (v NodeView) iterate(at func(k int) *Interval) iter.Seq[*Interval] {
+	return func(yield func(*Interval) bool) {
+		for k := 0; ; k++ {
+			v.check()
+			if k >= len(v.e.leftSorted) || !yield(at(k)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package intervaltree
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntervalTree_WalkViews(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	intervals := randomIntervals(rnd, 1000, 5000, 300)
+	tree := MustNewIntervalTree(intervals)
+	seen := make(map[*Interval]bool)
+	lastMid := 0
+	first := true
+	tree.WalkViews(
+		func(v NodeView) bool {
+			if !first && v.XMid() <= lastMid {
+				t.Fatalf("NODES NOT VISITED IN ASCENDING XMID: %d AFTER %d", v.XMid(), lastMid)
+			}
+			first, lastMid = false, v.XMid()
+			for i := 0; i < v.Len(); i++ {
+				in := v.At(i)
+				if in.Start > v.XMid() || in.End < v.XMid() {
+					t.Fatalf("%s DOES NOT CONTAIN XMID %d", in, v.XMid())
+				}
+				if i > 0 && in.Start < v.At(i-1).Start {
+					t.Fatalf("AT IS NOT IN ASCENDING START ORDER")
+				}
+				seen[in] = true
+			}
+			prev := (*Interval)(nil)
+			v.DescendingEnds()(
+				func(in *Interval) bool {
+					if prev != nil && in.End > prev.End {
+						t.Fatalf("DESCENDINGENDS IS NOT IN DESCENDING END ORDER")
+					}
+					prev = in
+					return true
+				},
+			)
+			return true
+		},
+	)
+	if len(seen) != len(intervals) {
+		t.Fatalf("EXPECTING %d INTERVALS IN THE VIEWS, GOT %d", len(intervals), len(seen))
+	}
+
+	visited := 0
+	tree.WalkViews(func(v NodeView) bool { visited++; return false })
+	if visited != 1 {
+		t.Fatalf("WALK MUST STOP WHEN FN RETURNS FALSE, VISITED %d", visited)
+	}
+}
+
+func TestNodeView_Guards(t *testing.T) {
+	tree := MustNewIntervalTree([]*Interval{{Start: 1, End: 5}, {Start: 2, End: 4}})
+	var view NodeView
+	tree.WalkViews(func(v NodeView) bool { view = v; return false })
+	mustPanic := func(name, message string, fn func()) {
+		defer func() {
+			r := recover()
+			if r == nil || !strings.Contains(r.(string), message) {
+				t.Fatalf("%s: EXPECTING A PANIC WITH %q, GOT %v", name, message, r)
+			}
+		}()
+		fn()
+	}
+	mustPanic("OUT OF RANGE", "out of range", func() { view.At(2) })
+	mustPanic("NEGATIVE", "out of range", func() { view.At(-1) })
+	tree.epoch++ // what any mutation does
+	mustPanic("STALE", "mutated", func() { view.Len() })
+	mustPanic("STALE ITERATOR", "mutated", func() { view.AscendingStarts()(func(*Interval) bool { return true }) })
+}